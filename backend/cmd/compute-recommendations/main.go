@@ -0,0 +1,30 @@
+// Command compute-recommendations recomputes the stored recommendations once
+// and exits, for deployments that run the job from cron rather than inside
+// the server (RECOMMENDATIONS_INTERVAL=0).
+//
+//	go run ./cmd/compute-recommendations
+package main
+
+import (
+	"context"
+	"log"
+	"movie-system/config"
+	"movie-system/internal/repositories"
+	"movie-system/internal/services"
+)
+
+func main() {
+	db, err := config.InitDB()
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	// Movies are not read when computing, so they need no translations
+	service := services.NewRecommendationService(repositories.NewRecommendationRepository(db),
+		repositories.NewMovieRepository(db), repositories.NewShowtimeRepository(db), nil)
+	if err := service.Refresh(context.Background()); err != nil {
+		db.Close()
+		log.Fatalf("Failed to compute recommendations: %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"movie-system/internal/site"
 	"os"
 	"time"
 
@@ -25,6 +26,8 @@ func InitDB() (*pgxpool.Pool, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse connection string: %w", err)
 	}
+	// Dates such as CURRENT_DATE are those of the site
+	config.ConnConfig.RuntimeParams["timezone"] = site.Location().String()
 
 	db, err := pgxpool.NewWithConfig(context.Background(), config)
 	if err != nil {
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"log"
+	"movie-system/internal/mail"
+	"os"
+)
+
+// InitMailer returns an SMTP mailer when SMTP_HOST is set and falls back to
+// writing messages locally otherwise.
+func InitMailer() mail.Mailer {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@movie-system.local"
+	}
+
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		log.Println("SMTP_HOST is not set, outgoing mail will be written locally")
+		return mail.NewLogMailer(os.Getenv("MAIL_DIR"), from)
+	}
+
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+
+	return mail.NewSMTPMailer(host, port, os.Getenv("SMTP_USER"), os.Getenv("SMTP_PASSWORD"), from)
+}
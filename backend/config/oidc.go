@@ -0,0 +1,41 @@
+package config
+
+import (
+	"log"
+	"movie-system/internal/oidc"
+	"os"
+	"strings"
+)
+
+// LoadOIDCProviders reads the identity providers listed in OIDC_PROVIDERS.
+// For a provider named corp the settings are read from OIDC_CORP_ISSUER,
+// OIDC_CORP_CLIENT_ID, OIDC_CORP_CLIENT_SECRET and, optionally,
+// OIDC_CORP_SCOPES. Callbacks are served under publicURL.
+func LoadOIDCProviders(publicURL string) []oidc.Config {
+	var configs []oidc.Config
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		config := oidc.Config{
+			Name:         name,
+			IssuerURL:    os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  strings.TrimSuffix(publicURL, "/") + "/auth/oidc/callback/" + name,
+		}
+		if scopes := os.Getenv(prefix + "SCOPES"); scopes != "" {
+			config.Scopes = strings.Fields(scopes)
+		}
+
+		if config.IssuerURL == "" || config.ClientID == "" {
+			log.Printf("Skipping OIDC provider %s: issuer and client id are required", name)
+			continue
+		}
+		configs = append(configs, config)
+	}
+	return configs
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"log"
+	"movie-system/internal/storage"
+	"os"
+)
+
+// InitBlobStore returns an S3-compatible store when STORAGE_DRIVER is "s3"
+// and keeps uploads on the local filesystem, below STORAGE_DIR, otherwise.
+func InitBlobStore() storage.BlobStore {
+	if os.Getenv("STORAGE_DRIVER") == "s3" {
+		region := os.Getenv("S3_REGION")
+		if region == "" {
+			region = "us-east-1"
+		}
+		bucket := os.Getenv("S3_BUCKET")
+		if bucket == "" {
+			log.Fatal("S3_BUCKET is not set in the environment")
+		}
+		return storage.NewS3Store(os.Getenv("S3_ENDPOINT"), region, bucket,
+			os.Getenv("S3_ACCESS_KEY_ID"), os.Getenv("S3_SECRET_ACCESS_KEY"))
+	}
+
+	dir := os.Getenv("STORAGE_DIR")
+	if dir == "" {
+		dir = "uploads"
+	}
+	log.Printf("Storing uploaded media in %s", dir)
+	return storage.NewLocalStore(dir)
+}
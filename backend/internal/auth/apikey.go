@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"movie-system/metrics"
+	"net/http"
+	"strconv"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyPrincipal describes the machine client behind an API key.
+type APIKeyPrincipal struct {
+	ID        uint
+	Name      string
+	Prefix    string
+	Scopes    []string
+	RateLimit int
+}
+
+func (p *APIKeyPrincipal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyAuthenticator resolves a raw API key to the client it was issued to.
+// It must return an error for unknown, revoked and expired keys.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*APIKeyPrincipal, error)
+}
+
+type apiKeyContextKey struct{}
+
+// APIKeyFromContext returns the API key client stored by APIKeyMiddleware.
+func APIKeyFromContext(ctx context.Context) (*APIKeyPrincipal, bool) {
+	principal, ok := ctx.Value(apiKeyContextKey{}).(*APIKeyPrincipal)
+	return principal, ok
+}
+
+// APIKeyMiddleware lets requests carrying an X-API-Key header through if the
+// key is valid, grants scope and is within its rate limit. Requests without
+// the header are passed to fallback, which normally checks a Bearer token.
+func APIKeyMiddleware(keys APIKeyAuthenticator, limiter *RateLimiter, scope string, fallback, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(APIKeyHeader)
+		if key == "" {
+			fallback.ServeHTTP(w, r)
+			return
+		}
+
+		principal, err := keys.AuthenticateAPIKey(r.Context(), key)
+		if err != nil {
+			log.Printf("Invalid API key: %v", err)
+			http.Error(w, "Invalid API key", http.StatusUnauthorized)
+			return
+		}
+
+		if !principal.HasScope(scope) {
+			metrics.APIKeyRequestsTotal.WithLabelValues(principal.Prefix, r.URL.Path, "forbidden").Inc()
+			http.Error(w, "Forbidden: API key lacks scope "+scope, http.StatusForbidden)
+			return
+		}
+
+		allowed, remaining, retryAfter := limiter.Allow(principal.ID, principal.RateLimit)
+		if principal.RateLimit > 0 {
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(principal.RateLimit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		}
+		if !allowed {
+			metrics.APIKeyRequestsTotal.WithLabelValues(principal.Prefix, r.URL.Path, "rate_limited").Inc()
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+
+		metrics.APIKeyRequestsTotal.WithLabelValues(principal.Prefix, r.URL.Path, "ok").Inc()
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, principal)))
+	})
+}
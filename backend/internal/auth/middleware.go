@@ -1,8 +1,10 @@
 package auth
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"movie-system/internal/models"
 	"net/http"
 	"os"
 	"strings"
@@ -10,13 +12,57 @@ import (
 	"github.com/dgrijalva/jwt-go"
 )
 
+// Tokens with a purpose are only good for finishing a two-factor login and
+// are never accepted by RoleMiddleware.
+const (
+	PurposeMFA      = "mfa"
+	PurposeMFASetup = "mfa_setup"
+)
+
 type TokenClaims struct {
 	Username string `json:"username"`
 	Role     string `json:"role"`
+	// CinemaID is the cinema an admin runs.
+	CinemaID uint   `json:"cinema_id,omitempty"`
+	Purpose  string `json:"purpose,omitempty"`
 	jwt.StandardClaims
 }
 
-func RoleMiddleware(requiredRole string, next http.Handler) http.Handler {
+// ParseToken verifies the signature of tokenString and returns its claims.
+func ParseToken(tokenString, secret string) (*TokenClaims, error) {
+	claims := &TokenClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(secret), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, fmt.Errorf("token is not valid")
+	}
+	return claims, nil
+}
+
+// ClaimsValidator performs checks on a token that cannot be answered by its
+// signature alone, such as whether the account it was issued to is disabled.
+type ClaimsValidator interface {
+	ValidateClaims(ctx context.Context, claims *TokenClaims) error
+}
+
+type contextKey struct{}
+
+// ClaimsFromContext returns the claims stored by RoleMiddleware, if any.
+func ClaimsFromContext(ctx context.Context) (*TokenClaims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*TokenClaims)
+	return claims, ok
+}
+
+// RoleMiddleware lets requests through whose token has requiredRole or a
+// role above it.
+func RoleMiddleware(validator ClaimsValidator, requiredRole string, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
@@ -30,29 +76,30 @@ func RoleMiddleware(requiredRole string, next http.Handler) http.Handler {
 			return
 		}
 
-		claims := &TokenClaims{}
 		jwtSecret := os.Getenv("SECRET_KEY")
 
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(jwtSecret), nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := ParseToken(tokenString, jwtSecret)
+		if err != nil || claims.Purpose != "" {
 			log.Printf("Invalid token: %v", err)
 			http.Error(w, "Invalid token", http.StatusUnauthorized)
 			return
 		}
 
-		if claims.Role != requiredRole && claims.Role != "admin" {
+		if validator != nil {
+			if err := validator.ValidateClaims(r.Context(), claims); err != nil {
+				log.Printf("Rejected token for user %s: %v", claims.Username, err)
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		if !models.RoleAllows(claims.Role, requiredRole) {
 			http.Error(w, "Forbidden: Insufficient permissions", http.StatusForbidden)
 			return
 		}
 
 		log.Printf("User %s authenticated successfully with role: %s", claims.Username, claims.Role)
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, claims)))
 	})
 }
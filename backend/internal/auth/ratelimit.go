@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter enforces a per-key limit of requests per fixed one minute
+// window. State is kept in memory, so limits apply per server instance.
+type RateLimiter struct {
+	mu      sync.Mutex
+	window  time.Duration
+	now     func() time.Time
+	buckets map[uint]*rateBucket
+}
+
+type rateBucket struct {
+	start time.Time
+	count int
+}
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		window:  time.Minute,
+		now:     time.Now,
+		buckets: make(map[uint]*rateBucket),
+	}
+}
+
+// Allow counts a request for id against limit. A limit of zero or less means
+// the key is not rate limited. It returns whether the request may proceed,
+// how many requests are left in the current window and, when the limit is
+// exhausted, how long until the window resets.
+func (l *RateLimiter) Allow(id uint, limit int) (bool, int, time.Duration) {
+	if limit <= 0 {
+		return true, 0, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	bucket, ok := l.buckets[id]
+	if !ok || now.Sub(bucket.start) >= l.window {
+		bucket = &rateBucket{start: now}
+		l.buckets[id] = bucket
+	}
+
+	if bucket.count >= limit {
+		return false, 0, bucket.start.Add(l.window).Sub(now)
+	}
+
+	bucket.count++
+	return true, limit - bucket.count, 0
+}
@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter()
+	limiter.now = func() time.Time { return now }
+
+	for i := 2; i >= 0; i-- {
+		allowed, remaining, _ := limiter.Allow(1, 3)
+		assert.True(t, allowed)
+		assert.Equal(t, i, remaining)
+	}
+
+	allowed, _, retryAfter := limiter.Allow(1, 3)
+	assert.False(t, allowed)
+	assert.Equal(t, time.Minute, retryAfter)
+
+	allowed, _, _ = limiter.Allow(2, 3)
+	assert.True(t, allowed, "limits are tracked per key")
+
+	now = now.Add(time.Minute)
+	allowed, _, _ = limiter.Allow(1, 3)
+	assert.True(t, allowed, "a new window starts after a minute")
+
+	allowed, _, _ = limiter.Allow(3, 0)
+	assert.True(t, allowed, "zero means unlimited")
+}
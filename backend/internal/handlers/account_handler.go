@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"movie-system/internal/auth"
+	"movie-system/internal/repositories"
+	"movie-system/internal/services"
+	"net/http"
+)
+
+type AccountHandler struct {
+	Service  *services.AccountService
+	UserRepo *repositories.UserRepository
+}
+
+func NewAccountHandler(service *services.AccountService, userRepo *repositories.UserRepository) *AccountHandler {
+	return &AccountHandler{Service: service, UserRepo: userRepo}
+}
+
+func (h *AccountHandler) HandleSendVerification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Missing token", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByUsername(context.Background(), claims.Username)
+	if err != nil {
+		writeUserError(w, err, "Failed to fetch user")
+		return
+	}
+	if user.Email == "" {
+		http.Error(w, "No email address on file", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.SendVerificationEmail(context.Background(), user); err != nil {
+		if errors.Is(err, services.ErrEmailAlreadyVerified) {
+			http.Error(w, "Email is already verified", http.StatusConflict)
+			return
+		}
+		log.Printf("Error sending verification email: %v", err)
+		http.Error(w, "Failed to send verification email", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Verification email sent"})
+}
+
+func (h *AccountHandler) HandleVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var requestBody struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil || requestBody.Token == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.VerifyEmail(context.Background(), requestBody.Token); err != nil {
+		writeTokenError(w, err, "Failed to verify email")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Email verified successfully"})
+}
+
+func (h *AccountHandler) HandleForgotPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var requestBody struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil || requestBody.Email == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.RequestPasswordReset(context.Background(), requestBody.Email); err != nil {
+		log.Printf("Error requesting password reset: %v", err)
+	}
+
+	// The response is the same whether or not the address is known.
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"message": "If the address is registered, a reset link has been sent"})
+}
+
+func (h *AccountHandler) HandleResetPassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var requestBody struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil || requestBody.Token == "" || requestBody.Password == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.ResetPassword(context.Background(), requestBody.Token, requestBody.Password); err != nil {
+		writeTokenError(w, err, "Failed to reset password")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Password reset successfully"})
+}
+
+func writeTokenError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, repositories.ErrInvalidToken) {
+		http.Error(w, "Invalid or expired token", http.StatusBadRequest)
+		return
+	}
+	log.Printf("%s: %v", message, err)
+	http.Error(w, message, http.StatusInternalServerError)
+}
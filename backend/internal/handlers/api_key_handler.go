@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"movie-system/internal/auth"
+	"movie-system/internal/models"
+	"movie-system/internal/repositories"
+	"movie-system/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type APIKeyHandler struct {
+	Service *services.APIKeyService
+}
+
+func NewAPIKeyHandler(service *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{Service: service}
+}
+
+func (h *APIKeyHandler) HandleGetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := h.Service.GetAPIKeys(context.Background(), params)
+	if err != nil {
+		writeListError(w, err, "Failed to fetch API keys")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(keys)
+}
+
+func (h *APIKeyHandler) HandleAddAPIKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var keyData struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		RateLimit int        `json:"rate_limit"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&keyData); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
+	key := models.APIKey{
+		Name:      keyData.Name,
+		Scopes:    keyData.Scopes,
+		RateLimit: keyData.RateLimit,
+		ExpiresAt: keyData.ExpiresAt,
+	}
+
+	if claims, ok := auth.ClaimsFromContext(r.Context()); ok {
+		key.CreatedBy = claims.Username
+	}
+
+	plain, err := h.Service.CreateAPIKey(context.Background(), &key)
+	if err != nil {
+		log.Printf("Error creating api key: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The plain key is only ever shown in this response.
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"key":     plain,
+		"api_key": key,
+	})
+}
+
+func (h *APIKeyHandler) HandleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/api-keys/revoke/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid API key ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.RevokeAPIKey(context.Background(), id); err != nil {
+		if errors.Is(err, repositories.ErrAPIKeyNotFound) {
+			http.Error(w, "API key not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error revoking api key: %v", err)
+		http.Error(w, "Failed to revoke API key", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "API key revoked successfully"})
+}
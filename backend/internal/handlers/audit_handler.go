@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"movie-system/internal/auth"
+	"movie-system/internal/models"
+	"movie-system/internal/repositories"
+	"movie-system/internal/services"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type AuditHandler struct {
+	Audit *services.AuditService
+}
+
+func NewAuditHandler(audit *services.AuditService) *AuditHandler {
+	return &AuditHandler{Audit: audit}
+}
+
+// HandleGetAuditLog lists audit entries, newest first, filtered by the
+// entity, entity_id, actor, from and to query parameters.
+func (h *AuditHandler) HandleGetAuditLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := models.AuditFilter{
+		EntityType: query.Get("entity"),
+		Actor:      query.Get("actor"),
+	}
+	if value := query.Get("entity_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid entity_id", http.StatusBadRequest)
+			return
+		}
+		filter.EntityID = id
+	}
+	if filter.From, ok = timeParam(w, query, "from"); !ok {
+		return
+	}
+	if filter.To, ok = timeParam(w, query, "to"); !ok {
+		return
+	}
+
+	entries, err := h.Audit.List(context.Background(), filter, params)
+	if err != nil {
+		writeListError(w, err, "Failed to fetch audit log")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(entries)
+}
+
+// HandleRevert puts an entity back into the state recorded after the given
+// audit entry and answers with the audit entry of the revert.
+func (h *AuditHandler) HandleRevert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/audit/revert/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid audit entry ID", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := h.Audit.Revert(context.Background(), actorOf(r), id)
+	if err != nil {
+		writeAuditError(w, err, "Failed to revert change")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(entry)
+}
+
+// timeParam reads an optional RFC 3339 query parameter, answering with 400
+// if it is malformed.
+func timeParam(w http.ResponseWriter, query url.Values, name string) (*time.Time, bool) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, true
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		http.Error(w, "Invalid "+name+": expected an RFC 3339 timestamp", http.StatusBadRequest)
+		return nil, false
+	}
+	t = t.UTC()
+	return &t, true
+}
+
+func writeAuditError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrAuditEntryNotFound):
+		http.Error(w, "Audit entry not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrNotRevertable):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, repositories.ErrMovieNotFound), errors.Is(err, repositories.ErrShowtimeNotFound),
+		errors.Is(err, repositories.ErrGenreNotFound), errors.Is(err, repositories.ErrPersonNotFound),
+		errors.Is(err, repositories.ErrGenreExists), errors.Is(err, repositories.ErrAuditoriumNotFound),
+		errors.Is(err, repositories.ErrCinemaNotFound),
+		errors.Is(err, repositories.ErrShowtimeConflict), errors.Is(err, repositories.ErrRuntimeUnknown):
+		// The entity, or something the old version refers to, has been
+		// deleted or taken since
+		http.Error(w, "Cannot revert: "+err.Error(), http.StatusConflict)
+	default:
+		log.Printf("%s: %v", message, err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
+// actorOf names the signed-in user for the audit log.
+func actorOf(r *http.Request) string {
+	if claims, ok := auth.ClaimsFromContext(r.Context()); ok {
+		return claims.Username
+	}
+	return "unknown"
+}
+
+// recordAudit adds an entry to the audit log for a change that has already
+// been made. A failure is logged but does not fail the request.
+func recordAudit(r *http.Request, audit *services.AuditService, action, entityType string, entityID int, before, after any) {
+	if _, err := audit.Record(context.Background(), actorOf(r), action, entityType, entityID, before, after); err != nil {
+		log.Printf("Failed to record %s of %s %d: %v", action, entityType, entityID, err)
+	}
+}
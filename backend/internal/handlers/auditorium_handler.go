@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"movie-system/internal/models"
+	"movie-system/internal/repositories"
+	"movie-system/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxBufferMinutes bounds the ad and cleaning buffers of an auditorium.
+const maxBufferMinutes = 240
+
+type AuditoriumHandler struct {
+	Repo  *repositories.AuditoriumRepository
+	Audit *services.AuditService
+}
+
+func NewAuditoriumHandler(repo *repositories.AuditoriumRepository, audit *services.AuditService) *AuditoriumHandler {
+	return &AuditoriumHandler{Repo: repo, Audit: audit}
+}
+
+// HandleGetAuditoriums lists the auditoriums, of one cinema with cinema_id.
+func (h *AuditoriumHandler) HandleGetAuditoriums(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+	cinemaID, ok := cinemaParam(w, r)
+	if !ok {
+		return
+	}
+
+	auditoriums, err := h.Repo.ListAuditoriums(context.Background(), cinemaID, params)
+	if err != nil {
+		writeListError(w, err, "Failed to fetch auditoriums")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(auditoriums)
+}
+
+// HandleAddAuditorium adds an auditorium to a cinema, by default to the one
+// the admin runs.
+func (h *AuditoriumHandler) HandleAddAuditorium(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	auditorium, ok := decodeAuditorium(w, r)
+	if !ok {
+		return
+	}
+	if auditorium.CinemaID == 0 {
+		auditorium.CinemaID = cinemaScope(r)
+	}
+	if auditorium.CinemaID == 0 {
+		http.Error(w, "cinema_id is required", http.StatusBadRequest)
+		return
+	}
+	if !checkCinema(w, r, auditorium.CinemaID) {
+		return
+	}
+
+	if err := h.Repo.InsertAuditorium(context.Background(), auditorium); err != nil {
+		writeAuditoriumError(w, err, "Failed to add auditorium")
+		return
+	}
+	recordAudit(r, h.Audit, models.AuditActionCreate, models.AuditEntityAuditorium, int(auditorium.ID), nil, auditorium)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(auditorium)
+}
+
+// HandleUpdateAuditorium renames an auditorium or changes its buffers, which
+// moves the end of its upcoming showtimes.
+func (h *AuditoriumHandler) HandleUpdateAuditorium(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/auditoriums/update/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid auditorium ID", http.StatusBadRequest)
+		return
+	}
+
+	auditorium, ok := decodeAuditorium(w, r)
+	if !ok {
+		return
+	}
+
+	before, err := h.Repo.GetAuditoriumByID(context.Background(), id)
+	if err != nil {
+		writeAuditoriumError(w, err, "Failed to fetch auditorium")
+		return
+	}
+	if !checkCinema(w, r, before.CinemaID) {
+		return
+	}
+
+	updated, err := h.Repo.UpdateAuditorium(context.Background(), id, auditorium)
+	if err != nil {
+		writeAuditoriumError(w, err, "Failed to update auditorium")
+		return
+	}
+	recordAudit(r, h.Audit, models.AuditActionUpdate, models.AuditEntityAuditorium, id, before, updated)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updated)
+}
+
+func (h *AuditoriumHandler) HandleDeleteAuditorium(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/auditoriums/delete/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid auditorium ID", http.StatusBadRequest)
+		return
+	}
+
+	before, err := h.Repo.GetAuditoriumByID(context.Background(), id)
+	if err != nil {
+		writeAuditoriumError(w, err, "Failed to fetch auditorium")
+		return
+	}
+	if !checkCinema(w, r, before.CinemaID) {
+		return
+	}
+
+	if err := h.Repo.DeleteAuditorium(context.Background(), id); err != nil {
+		writeAuditoriumError(w, err, "Failed to delete auditorium")
+		return
+	}
+	recordAudit(r, h.Audit, models.AuditActionDelete, models.AuditEntityAuditorium, id, before, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Auditorium deleted successfully"})
+}
+
+// decodeAuditorium reads and validates an auditorium from the request body.
+func decodeAuditorium(w http.ResponseWriter, r *http.Request) (*models.Auditorium, bool) {
+	var auditorium models.Auditorium
+	if err := json.NewDecoder(r.Body).Decode(&auditorium); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return nil, false
+	}
+	auditorium.Name = strings.TrimSpace(auditorium.Name)
+	if auditorium.Name == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
+		return nil, false
+	}
+	if auditorium.AdMinutes < 0 || auditorium.AdMinutes > maxBufferMinutes ||
+		auditorium.CleaningMinutes < 0 || auditorium.CleaningMinutes > maxBufferMinutes {
+		http.Error(w, "ad_minutes and cleaning_minutes must be between 0 and 240", http.StatusBadRequest)
+		return nil, false
+	}
+	return &auditorium, true
+}
+
+func writeAuditoriumError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrAuditoriumNotFound):
+		http.Error(w, "Auditorium not found", http.StatusNotFound)
+	case errors.Is(err, repositories.ErrCinemaNotFound):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repositories.ErrAuditoriumExists), errors.Is(err, repositories.ErrAuditoriumInUse),
+		errors.Is(err, repositories.ErrShowtimeConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("%s: %v", message, err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
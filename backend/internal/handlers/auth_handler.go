@@ -3,18 +3,23 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
-	"movie-system/internal/models"
+	"math"
+	"movie-system/internal/repositories"
 	"movie-system/internal/services"
+	"net"
 	"net/http"
+	"strconv"
 )
 
 type AuthHandler struct {
-	service *services.AuthService
+	service  *services.AuthService
+	accounts *services.AccountService
 }
 
-func NewAuthHandler(service *services.AuthService) *AuthHandler {
-	return &AuthHandler{service: service}
+func NewAuthHandler(service *services.AuthService, accounts *services.AccountService) *AuthHandler {
+	return &AuthHandler{service: service, accounts: accounts}
 }
 
 func (h *AuthHandler) SignUp(w http.ResponseWriter, r *http.Request) {
@@ -23,18 +28,42 @@ func (h *AuthHandler) SignUp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var user models.User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		http.Error(w, "Failed to create user", http.StatusInternalServerError)
+	var signupData struct {
+		Username string `json:"username"`
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&signupData); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if signupData.Username == "" || signupData.Password == "" {
+		http.Error(w, "Username and password are required", http.StatusBadRequest)
 		return
 	}
 
-	if err := h.service.SignUp(context.Background(), &user); err != nil {
+	user, err := h.service.SignUp(context.Background(), signupData.Username, signupData.Email, signupData.Password)
+	if errors.Is(err, services.ErrInvalidEmail) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, repositories.ErrEmailTaken) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
 		log.Printf("Error signing up user: %v", err)
 		http.Error(w, "Failed to create user", http.StatusInternalServerError)
 		return
 	}
 
+	if user.Email != "" {
+		if err := h.accounts.SendVerificationEmail(context.Background(), user); err != nil {
+			log.Printf("Error sending verification email to %s: %v", user.Username, err)
+		}
+	}
+
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]string{"message": "User created successfully"})
 }
@@ -57,25 +86,17 @@ func (h *AuthHandler) LogIn(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Authenticate user and generate JWT
-	token, err := h.service.LogIn(context.Background(), loginData.Username, loginData.Password)
+	result, err := h.service.LogIn(context.Background(), loginData.Username, loginData.Password, clientIP(r))
 	if err != nil {
 		log.Printf("Authentication failed for user %s: %v", loginData.Username, err)
-
-		response := map[string]string{
-			"error": "Invalid credentials",
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(response)
+		writeLoginError(w, err)
 		return
 	}
 
-	// Send the token
+	// Send the token, or the pending token if a second factor is needed
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"token": token})
+	json.NewEncoder(w).Encode(result)
 }
 
 func (h *AuthHandler) GetId(w http.ResponseWriter, r *http.Request) {
@@ -116,3 +137,38 @@ func (h *AuthHandler) GetId(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Error encoding response", http.StatusInternalServerError)
 	}
 }
+
+// writeLoginError answers a failed login. Unknown usernames, wrong passwords
+// and wrong codes all get the same response so that the endpoint does not
+// reveal which accounts exist. A disabled account is only reported once the
+// correct password was given.
+func writeLoginError(w http.ResponseWriter, err error) {
+	status := http.StatusUnauthorized
+	response := map[string]string{
+		"error": "Invalid credentials",
+	}
+
+	var lockedOut *services.LockedOutError
+	switch {
+	case errors.As(err, &lockedOut):
+		status = http.StatusTooManyRequests
+		response["error"] = "Too many failed attempts, try again later"
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(lockedOut.RetryAfter.Seconds()))))
+	case errors.Is(err, repositories.ErrUserDisabled):
+		status = http.StatusForbidden
+		response["error"] = "Account is disabled"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// clientIP returns the address of the peer that sent the request.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"movie-system/internal/auth"
+	"movie-system/internal/models"
+	"movie-system/internal/repositories"
+	"movie-system/internal/services"
+	"movie-system/internal/site"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type CinemaHandler struct {
+	Repo  *repositories.CinemaRepository
+	Audit *services.AuditService
+}
+
+func NewCinemaHandler(repo *repositories.CinemaRepository, audit *services.AuditService) *CinemaHandler {
+	return &CinemaHandler{Repo: repo, Audit: audit}
+}
+
+func (h *CinemaHandler) HandleGetCinemas(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	cinemas, err := h.Repo.ListCinemas(context.Background(), params)
+	if err != nil {
+		writeListError(w, err, "Failed to fetch cinemas")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(cinemas)
+}
+
+func (h *CinemaHandler) HandleAddCinema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cinema, ok := decodeCinema(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Repo.InsertCinema(context.Background(), cinema); err != nil {
+		writeCinemaError(w, err, "Failed to add cinema")
+		return
+	}
+	recordAudit(r, h.Audit, models.AuditActionCreate, models.AuditEntityCinema, int(cinema.ID), nil, cinema)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(cinema)
+}
+
+// HandleUpdateCinema changes a cinema. A new time zone moves the local times
+// its showtimes are shown at, not the showtimes.
+func (h *CinemaHandler) HandleUpdateCinema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/cinemas/update/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid cinema ID", http.StatusBadRequest)
+		return
+	}
+
+	cinema, ok := decodeCinema(w, r)
+	if !ok {
+		return
+	}
+
+	before, err := h.Repo.GetCinemaByID(context.Background(), id)
+	if err != nil {
+		writeCinemaError(w, err, "Failed to fetch cinema")
+		return
+	}
+
+	updated, err := h.Repo.UpdateCinema(context.Background(), id, cinema)
+	if err != nil {
+		writeCinemaError(w, err, "Failed to update cinema")
+		return
+	}
+	recordAudit(r, h.Audit, models.AuditActionUpdate, models.AuditEntityCinema, id, before, updated)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updated)
+}
+
+func (h *CinemaHandler) HandleDeleteCinema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/cinemas/delete/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid cinema ID", http.StatusBadRequest)
+		return
+	}
+
+	before, err := h.Repo.GetCinemaByID(context.Background(), id)
+	if err != nil {
+		writeCinemaError(w, err, "Failed to fetch cinema")
+		return
+	}
+
+	if err := h.Repo.DeleteCinema(context.Background(), id); err != nil {
+		writeCinemaError(w, err, "Failed to delete cinema")
+		return
+	}
+	recordAudit(r, h.Audit, models.AuditActionDelete, models.AuditEntityCinema, id, before, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Cinema deleted successfully"})
+}
+
+// decodeCinema reads and validates a cinema from the request body. The time
+// zone defaults to the one of the site.
+func decodeCinema(w http.ResponseWriter, r *http.Request) (*models.Cinema, bool) {
+	var cinema models.Cinema
+	if err := json.NewDecoder(r.Body).Decode(&cinema); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return nil, false
+	}
+	cinema.Name = strings.TrimSpace(cinema.Name)
+	cinema.Address = strings.TrimSpace(cinema.Address)
+	if cinema.Name == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
+		return nil, false
+	}
+	if cinema.TimeZone == "" {
+		cinema.TimeZone = site.Location().String()
+	}
+	if _, err := site.Zone(cinema.TimeZone); err != nil {
+		http.Error(w, "Invalid timezone: expected an IANA name such as Europe/Berlin", http.StatusBadRequest)
+		return nil, false
+	}
+	if cinema.SeatPrice < 0 {
+		http.Error(w, "seat_price must not be negative", http.StatusBadRequest)
+		return nil, false
+	}
+	return &cinema, true
+}
+
+func writeCinemaError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrCinemaNotFound):
+		http.Error(w, "Cinema not found", http.StatusNotFound)
+	case errors.Is(err, repositories.ErrCinemaExists), errors.Is(err, repositories.ErrCinemaInUse):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("%s: %v", message, err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
+// cinemaScope returns the cinema an admin is restricted to, or 0 for head
+// office and anyone else who is not.
+func cinemaScope(r *http.Request) uint {
+	if claims, ok := auth.ClaimsFromContext(r.Context()); ok && claims.Role == models.RoleAdmin {
+		return claims.CinemaID
+	}
+	return 0
+}
+
+// checkCinema answers 403 and returns false if the request is by an admin
+// of another cinema than cinemaID.
+func checkCinema(w http.ResponseWriter, r *http.Request, cinemaID uint) bool {
+	if scope := cinemaScope(r); scope != 0 && scope != cinemaID {
+		http.Error(w, "Forbidden: the cinema is run by other admins", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// cinemaParam reads the cinema_id parameter that listings are filtered by,
+// 0 if there is none.
+func cinemaParam(w http.ResponseWriter, r *http.Request) (int, bool) {
+	value := r.URL.Query().Get("cinema_id")
+	if value == "" {
+		return 0, true
+	}
+	cinemaID, err := strconv.Atoi(value)
+	if err != nil || cinemaID < 1 {
+		http.Error(w, "Invalid cinema_id", http.StatusBadRequest)
+		return 0, false
+	}
+	return cinemaID, true
+}
+
+// scopedCinema is cinemaParam for listings of staff: an admin always gets the
+// cinema they run, and may not ask for another.
+func scopedCinema(w http.ResponseWriter, r *http.Request) (int, bool) {
+	cinemaID, ok := cinemaParam(w, r)
+	if !ok {
+		return 0, false
+	}
+	scope := cinemaScope(r)
+	if scope == 0 {
+		return cinemaID, true
+	}
+	if cinemaID != 0 && !checkCinema(w, r, uint(cinemaID)) {
+		return 0, false
+	}
+	return int(scope), true
+}
+
+// placeInCinema keeps an admin to the cinema they run: a showtime or schedule
+// that names no cinema is put in it, and the repositories reject an
+// auditorium elsewhere. Head office may leave the cinema to the auditorium.
+func placeInCinema(w http.ResponseWriter, r *http.Request, cinemaID *uint) bool {
+	if *cinemaID == 0 {
+		*cinemaID = cinemaScope(r)
+	}
+	return *cinemaID == 0 || checkCinema(w, r, *cinemaID)
+}
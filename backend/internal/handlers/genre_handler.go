@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"movie-system/internal/models"
+	"movie-system/internal/repositories"
+	"movie-system/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type GenreHandler struct {
+	Repo         *repositories.GenreRepository
+	Audit        *services.AuditService
+	Translations *services.TranslationService
+}
+
+func NewGenreHandler(repo *repositories.GenreRepository, audit *services.AuditService, translations *services.TranslationService) *GenreHandler {
+	return &GenreHandler{Repo: repo, Audit: audit, Translations: translations}
+}
+
+// HandleGetGenres lists the genres with their names in the negotiated
+// language.
+func (h *GenreHandler) HandleGetGenres(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+	locales, ok := localesOf(w, r, h.Translations)
+	if !ok {
+		return
+	}
+
+	genres, err := h.Repo.ListGenres(context.Background(), params)
+	if err != nil {
+		writeListError(w, err, "Failed to fetch genres")
+		return
+	}
+	localized := make([]*models.Genre, len(genres.Items))
+	for i := range genres.Items {
+		localized[i] = &genres.Items[i]
+	}
+	if err := h.Translations.LocalizeGenres(context.Background(), locales, localized...); err != nil {
+		writeListError(w, err, "Failed to fetch genres")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(genres)
+}
+
+func (h *GenreHandler) HandleAddGenre(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var genre models.Genre
+	if err := json.NewDecoder(r.Body).Decode(&genre); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+	genre.Name = strings.TrimSpace(genre.Name)
+	if models.Slugify(genre.Name) == "" {
+		http.Error(w, "Name must contain letters or digits", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Repo.InsertGenre(context.Background(), &genre); err != nil {
+		writeGenreError(w, err, "Failed to add genre")
+		return
+	}
+	recordAudit(r, h.Audit, models.AuditActionCreate, models.AuditEntityGenre, int(genre.ID), nil, &genre)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(genre)
+}
+
+func (h *GenreHandler) HandleUpdateGenre(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/genres/update/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid genre ID", http.StatusBadRequest)
+		return
+	}
+
+	var genreData struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&genreData); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+	genreData.Name = strings.TrimSpace(genreData.Name)
+	if models.Slugify(genreData.Name) == "" {
+		http.Error(w, "Name must contain letters or digits", http.StatusBadRequest)
+		return
+	}
+
+	before, err := h.Repo.GetGenreByID(context.Background(), id)
+	if err != nil {
+		writeGenreError(w, err, "Failed to fetch genre")
+		return
+	}
+
+	genre, err := h.Repo.UpdateGenre(context.Background(), id, genreData.Name)
+	if err != nil {
+		writeGenreError(w, err, "Failed to update genre")
+		return
+	}
+	recordAudit(r, h.Audit, models.AuditActionUpdate, models.AuditEntityGenre, id, before, genre)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(genre)
+}
+
+func (h *GenreHandler) HandleDeleteGenre(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/genres/delete/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid genre ID", http.StatusBadRequest)
+		return
+	}
+
+	before, err := h.Repo.GetGenreByID(context.Background(), id)
+	if err != nil {
+		writeGenreError(w, err, "Failed to fetch genre")
+		return
+	}
+
+	if err := h.Repo.DeleteGenre(context.Background(), id); err != nil {
+		writeGenreError(w, err, "Failed to delete genre")
+		return
+	}
+	recordAudit(r, h.Audit, models.AuditActionDelete, models.AuditEntityGenre, id, before, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Genre deleted successfully"})
+}
+
+func writeGenreError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrGenreNotFound):
+		http.Error(w, "Genre not found", http.StatusNotFound)
+	case errors.Is(err, repositories.ErrGenreExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("%s: %v", message, err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"movie-system/internal/models"
+	"movie-system/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxImportBytes bounds the size of an uploaded import file.
+const maxImportBytes = 10 << 20
+
+type ImportHandler struct {
+	Service *services.ImportService
+}
+
+func NewImportHandler(service *services.ImportService) *ImportHandler {
+	return &ImportHandler{Service: service}
+}
+
+// HandleImportMovies upserts movies from a CSV or JSON body. The format is
+// taken from the format query parameter or else the Content-Type;
+// dry_run=true and atomic=true select the import mode.
+func (h *ImportHandler) HandleImportMovies(w http.ResponseWriter, r *http.Request) {
+	h.handleImport(w, r, h.Service.ImportMovies)
+}
+
+func (h *ImportHandler) HandleImportShowtimes(w http.ResponseWriter, r *http.Request) {
+	h.handleImport(w, r, h.Service.ImportShowtimes)
+}
+
+// HandleExportMovies writes all movies as JSON or, with format=csv, CSV.
+func (h *ImportHandler) HandleExportMovies(w http.ResponseWriter, r *http.Request) {
+	h.handleExport(w, r, "movies", h.Service.ExportMovies)
+}
+
+func (h *ImportHandler) HandleExportShowtimes(w http.ResponseWriter, r *http.Request) {
+	h.handleExport(w, r, "showtimes", h.Service.ExportShowtimes)
+}
+
+type importFunc func(ctx context.Context, actor, format string, data io.Reader, opts models.ImportOptions) (*models.ImportReport, error)
+
+func (h *ImportHandler) handleImport(w http.ResponseWriter, r *http.Request, importRows importFunc) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = models.ImportFormatJSON
+		if strings.Contains(r.Header.Get("Content-Type"), "csv") {
+			format = models.ImportFormatCSV
+		}
+	}
+
+	var opts models.ImportOptions
+	var ok bool
+	if opts.DryRun, ok = boolParam(w, query.Get("dry_run"), "dry_run"); !ok {
+		return
+	}
+	if opts.Atomic, ok = boolParam(w, query.Get("atomic"), "atomic"); !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	report, err := importRows(context.Background(), actorOf(r), format, r.Body, opts)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			http.Error(w, fmt.Sprintf("Imports must be at most %d MB", maxImportBytes>>20), http.StatusRequestEntityTooLarge)
+		case errors.Is(err, services.ErrInvalidImport):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			log.Printf("Failed to import: %v", err)
+			http.Error(w, "Failed to import", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *ImportHandler) handleExport(w http.ResponseWriter, r *http.Request, name string, export func(ctx context.Context, format string, w io.Writer) error) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	contentType := "application/json"
+	switch format {
+	case "":
+		format = models.ImportFormatJSON
+	case models.ImportFormatJSON:
+	case models.ImportFormatCSV:
+		contentType = "text/csv; charset=utf-8"
+	default:
+		http.Error(w, "format must be json or csv", http.StatusBadRequest)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := export(context.Background(), format, &buf); err != nil {
+		log.Printf("Failed to export %s: %v", name, err)
+		http.Error(w, "Failed to export "+name, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// boolParam reads an optional boolean query parameter, answering with 400
+// if it is malformed.
+func boolParam(w http.ResponseWriter, value, name string) (bool, bool) {
+	if value == "" {
+		return false, true
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		http.Error(w, "Invalid "+name+": expected true or false", http.StatusBadRequest)
+		return false, false
+	}
+	return b, true
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"movie-system/internal/models"
+	"movie-system/internal/repositories"
+	"movie-system/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type MediaHandler struct {
+	Service *services.MediaService
+	Audit   *services.AuditService
+}
+
+func NewMediaHandler(service *services.MediaService, audit *services.AuditService) *MediaHandler {
+	return &MediaHandler{Service: service, Audit: audit}
+}
+
+// HandleUploadMedia accepts a multipart form with a "kind" field (poster,
+// backdrop or still) and the image in a "file" field.
+func (h *MediaHandler) HandleUploadMedia(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/media/upload/")
+	movieID, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid movie ID", http.StatusBadRequest)
+		return
+	}
+
+	// Leave some room for the multipart framing around the file
+	r.Body = http.MaxBytesReader(w, r.Body, services.MaxUploadBytes+1<<20)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeMediaError(w, services.ErrMediaTooLarge, "")
+			return
+		}
+		http.Error(w, "A multipart form with a file field is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, services.MaxUploadBytes+1))
+	if err != nil {
+		http.Error(w, "Failed to read upload", http.StatusBadRequest)
+		return
+	}
+
+	media, err := h.Service.Upload(context.Background(), movieID, r.FormValue("kind"), data)
+	if err != nil {
+		writeMediaError(w, err, "Failed to upload media")
+		return
+	}
+	recordAudit(r, h.Audit, models.AuditActionCreate, models.AuditEntityMedia, int(media.ID), nil, media)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(media)
+}
+
+func (h *MediaHandler) HandleGetMovieMedia(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/media/movie/")
+	movieID, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid movie ID", http.StatusBadRequest)
+		return
+	}
+
+	media, err := h.Service.ListMedia(context.Background(), movieID)
+	if err != nil {
+		writeMediaError(w, err, "Failed to fetch media")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(media)
+}
+
+// HandleServeMedia serves /media/{id}/{size}. It is public so that images
+// can be used in <img> tags. A stored image never changes, so clients may
+// cache it for good.
+func (h *MediaHandler) HandleServeMedia(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr, size, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/media/"), "/")
+	id, err := strconv.Atoi(idStr)
+	if !ok || err != nil {
+		http.Error(w, "Invalid media path", http.StatusBadRequest)
+		return
+	}
+
+	etag := fmt.Sprintf(`"%d-%s"`, id, size)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	blob, err := h.Service.Open(context.Background(), id, size)
+	if err != nil {
+		w.Header().Del("Cache-Control")
+		w.Header().Del("ETag")
+		writeMediaError(w, err, "Failed to fetch media")
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", blob.ContentType)
+	if blob.Size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(blob.Size, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		if _, err := io.Copy(w, blob); err != nil {
+			log.Printf("Error serving media %d: %v", id, err)
+		}
+	}
+}
+
+func (h *MediaHandler) HandleDeleteMedia(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/media/delete/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid media ID", http.StatusBadRequest)
+		return
+	}
+
+	media, err := h.Service.Delete(context.Background(), id)
+	if err != nil {
+		writeMediaError(w, err, "Failed to delete media")
+		return
+	}
+	recordAudit(r, h.Audit, models.AuditActionDelete, models.AuditEntityMedia, id, media, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Media deleted successfully"})
+}
+
+func writeMediaError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrMediaNotFound):
+		http.Error(w, "Media not found", http.StatusNotFound)
+	case errors.Is(err, repositories.ErrMovieNotFound):
+		http.Error(w, "Movie not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrInvalidMedia):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrMediaTooLarge):
+		http.Error(w, fmt.Sprintf("Images must be at most %d MB", services.MaxUploadBytes>>20), http.StatusRequestEntityTooLarge)
+	default:
+		log.Printf("%s: %v", message, err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
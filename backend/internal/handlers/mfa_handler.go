@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"movie-system/internal/auth"
+	"movie-system/internal/models"
+	"movie-system/internal/repositories"
+	"movie-system/internal/services"
+	"net/http"
+	"strings"
+)
+
+type MFAHandler struct {
+	Service     *services.MFAService
+	AuthService *services.AuthService
+	UserRepo    *repositories.UserRepository
+}
+
+func NewMFAHandler(service *services.MFAService, authService *services.AuthService, userRepo *repositories.UserRepository) *MFAHandler {
+	return &MFAHandler{Service: service, AuthService: authService, UserRepo: userRepo}
+}
+
+type mfaCodeRequest struct {
+	Code string `json:"code"`
+}
+
+func (h *MFAHandler) HandleLoginMFA(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var requestBody struct {
+		MFAToken string `json:"mfa_token"`
+		Code     string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil || requestBody.MFAToken == "" || requestBody.Code == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.AuthService.CompleteMFALogin(context.Background(), requestBody.MFAToken, requestBody.Code, clientIP(r))
+	if err != nil {
+		log.Printf("Two-factor login failed: %v", err)
+		writeLoginError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"token": token})
+}
+
+func (h *MFAHandler) HandleEnroll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := h.enrollmentClaims(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByUsername(context.Background(), claims.Username)
+	if err != nil {
+		writeUserError(w, err, "Failed to fetch user")
+		return
+	}
+
+	enrollment, err := h.Service.Enroll(context.Background(), user)
+	if err != nil {
+		writeMFAError(w, err, "Failed to start enrollment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(enrollment)
+}
+
+func (h *MFAHandler) HandleConfirm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claims, ok := h.enrollmentClaims(w, r)
+	if !ok {
+		return
+	}
+
+	var requestBody mfaCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil || requestBody.Code == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByUsername(context.Background(), claims.Username)
+	if err != nil {
+		writeUserError(w, err, "Failed to fetch user")
+		return
+	}
+
+	codes, err := h.Service.Confirm(context.Background(), user, requestBody.Code)
+	if err != nil {
+		writeMFAError(w, err, "Failed to confirm enrollment")
+		return
+	}
+
+	response := map[string]interface{}{
+		"recovery_codes": codes,
+	}
+
+	// Users who were forced to enroll during login receive their session
+	// token now that the second factor is in place.
+	if claims.Purpose == auth.PurposeMFASetup {
+		token, err := h.AuthService.GenerateJWT(user)
+		if err != nil {
+			http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+			return
+		}
+		response["token"] = token
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *MFAHandler) HandleDisable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, requestBody, ok := h.userAndCode(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.Disable(context.Background(), user, requestBody.Code); err != nil {
+		writeMFAError(w, err, "Failed to disable two-factor authentication")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Two-factor authentication disabled"})
+}
+
+func (h *MFAHandler) HandleRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, requestBody, ok := h.userAndCode(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := h.Service.RegenerateRecoveryCodes(context.Background(), user, requestBody.Code)
+	if err != nil {
+		writeMFAError(w, err, "Failed to regenerate recovery codes")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{"recovery_codes": codes})
+}
+
+func (h *MFAHandler) HandleGetRequiredRoles(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	roles, err := h.Service.RequiredRoles(context.Background())
+	if err != nil {
+		http.Error(w, "Failed to fetch roles", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{"roles": roles})
+}
+
+func (h *MFAHandler) HandleUpdateRequiredRoles(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var requestBody struct {
+		Roles []string `json:"roles"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.SetRequiredRoles(context.Background(), requestBody.Roles); err != nil {
+		log.Printf("Error updating required roles: %v", err)
+		http.Error(w, "Failed to update roles", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{"roles": requestBody.Roles})
+}
+
+// enrollmentClaims authenticates enrollment requests, which may come from a
+// fully logged in user or from one who must enroll before logging in.
+func (h *MFAHandler) enrollmentClaims(w http.ResponseWriter, r *http.Request) (*auth.TokenClaims, bool) {
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		http.Error(w, "Missing token", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	claims, err := h.AuthService.EnrollmentClaims(context.Background(), strings.TrimPrefix(authHeader, "Bearer "))
+	if err != nil {
+		log.Printf("Rejected enrollment token: %v", err)
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return nil, false
+	}
+	return claims, true
+}
+
+func (h *MFAHandler) userAndCode(w http.ResponseWriter, r *http.Request) (*models.User, mfaCodeRequest, bool) {
+	var requestBody mfaCodeRequest
+
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Missing token", http.StatusUnauthorized)
+		return nil, requestBody, false
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil || requestBody.Code == "" {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return nil, requestBody, false
+	}
+
+	user, err := h.UserRepo.GetUserByUsername(context.Background(), claims.Username)
+	if err != nil {
+		writeUserError(w, err, "Failed to fetch user")
+		return nil, requestBody, false
+	}
+	return user, requestBody, true
+}
+
+func writeMFAError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidMFACode):
+		http.Error(w, "Invalid code", http.StatusUnauthorized)
+	case errors.Is(err, services.ErrMFAAlreadyEnabled):
+		http.Error(w, "Two-factor authentication is already enabled", http.StatusConflict)
+	case errors.Is(err, services.ErrMFANotEnrolled):
+		http.Error(w, "Two-factor authentication has not been set up", http.StatusBadRequest)
+	case errors.Is(err, services.ErrMFARequired):
+		http.Error(w, "Two-factor authentication is required for your role", http.StatusForbidden)
+	default:
+		log.Printf("%s: %v", message, err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
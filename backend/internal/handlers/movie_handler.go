@@ -3,19 +3,25 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"log"
 	"movie-system/internal/models"
 	"movie-system/internal/repositories"
+	"movie-system/internal/services"
 	"net/http"
 	"strconv"
 	"strings"
 )
 
 type MovieHandler struct {
-	Repo *repositories.MovieRepository
+	Repo         *repositories.MovieRepository
+	Media        *services.MediaService
+	Audit        *services.AuditService
+	Translations *services.TranslationService
 }
 
-func NewMovieHandler(repo *repositories.MovieRepository) *MovieHandler {
-	return &MovieHandler{Repo: repo}
+func NewMovieHandler(repo *repositories.MovieRepository, media *services.MediaService, audit *services.AuditService, translations *services.TranslationService) *MovieHandler {
+	return &MovieHandler{Repo: repo, Media: media, Audit: audit, Translations: translations}
 }
 
 func (h *MovieHandler) HandleAddMovie(w http.ResponseWriter, r *http.Request) {
@@ -29,29 +35,60 @@ func (h *MovieHandler) HandleAddMovie(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
 		return
 	}
+	if err := movie.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	err := h.Repo.InsertMovie(context.Background(), &movie)
 	if err != nil {
-		http.Error(w, "Failed to add movie", http.StatusInternalServerError)
+		writeMovieError(w, err, "Failed to add movie")
 		return
 	}
+	recordAudit(r, h.Audit, models.AuditActionCreate, models.AuditEntityMovie, int(movie.ID), nil, &movie)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(movie)
 }
 
+// HandleGetMovies lists movies with their title, description and genres in
+// the language negotiated from the locale parameter or Accept-Language.
 func (h *MovieHandler) HandleGetMovies(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
 		return
 	}
 
-	genre := r.URL.Query().Get("genre")
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+	locales, ok := localesOf(w, r, h.Translations)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := models.MovieFilter{
+		Genres:        splitList(query["genre"]),
+		Certification: query.Get("certification"),
+		Language:      query.Get("language"),
+		Status:        query.Get("status"),
+		Sort:          query.Get("sort"),
+	}
 
-	movies, err := h.Repo.GetMovies(context.Background(), genre)
+	movies, err := h.Repo.ListMovies(context.Background(), filter, params)
 	if err != nil {
-		http.Error(w, "Failed to fetch movies", http.StatusInternalServerError)
+		writeListError(w, err, "Failed to fetch movies")
+		return
+	}
+	localized := make([]*models.Movie, len(movies.Items))
+	for i := range movies.Items {
+		localized[i] = &movies.Items[i]
+	}
+	if err := h.Translations.LocalizeMovies(context.Background(), locales, localized...); err != nil {
+		writeListError(w, err, "Failed to fetch movies")
 		return
 	}
 
@@ -60,6 +97,46 @@ func (h *MovieHandler) HandleGetMovies(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(movies)
 }
 
+func (h *MovieHandler) HandleSearchMovies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		http.Error(w, "Search query is required", http.StatusBadRequest)
+		return
+	}
+
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+	locales, ok := localesOf(w, r, h.Translations)
+	if !ok {
+		return
+	}
+
+	results, err := h.Repo.SearchMovies(context.Background(), query, locales, params)
+	if err != nil {
+		writeListError(w, err, "Failed to search movies")
+		return
+	}
+	localized := make([]*models.Movie, len(results.Items))
+	for i := range results.Items {
+		localized[i] = &results.Items[i].Movie
+	}
+	if err := h.Translations.LocalizeMovies(context.Background(), locales, localized...); err != nil {
+		writeListError(w, err, "Failed to search movies")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(results)
+}
+
 func (h *MovieHandler) HandleUpdateMovie(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
 		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
@@ -78,12 +155,44 @@ func (h *MovieHandler) HandleUpdateMovie(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "Invalid input", http.StatusBadRequest)
 		return
 	}
+	if err := movie.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	before, err := h.Repo.GetMovieByID(context.Background(), id)
+	if err != nil {
+		writeMovieError(w, err, "Failed to fetch movie")
+		return
+	}
+
+	// A movie shown in another locale is edited in that locale: its title
+	// and description go to the translation
+	translation, err := h.Translations.SplitTranslation(&movie, before)
+	if err != nil {
+		http.Error(w, "Invalid locale", http.StatusBadRequest)
+		return
+	}
 
 	updatedMovie, err := h.Repo.UpdateMovie(context.Background(), id, &movie)
 	if err != nil {
-		http.Error(w, "Failed to update movie", http.StatusInternalServerError)
+		writeMovieError(w, err, "Failed to update movie")
 		return
 	}
+	recordAudit(r, h.Audit, models.AuditActionUpdate, models.AuditEntityMovie, id, before, updatedMovie)
+
+	if translation != nil {
+		previous, err := h.Translations.SetMovieTranslation(context.Background(), id, translation)
+		if err != nil {
+			writeTranslationError(w, err, "Failed to save translation")
+			return
+		}
+		recordAudit(r, h.Audit, models.AuditActionUpdate, models.AuditEntityMovieTranslation, id, previous, translation)
+		if err := h.Translations.LocalizeMovies(context.Background(), []string{translation.Locale}, updatedMovie); err != nil {
+			writeMovieError(w, err, "Failed to update movie")
+			return
+		}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -91,6 +200,8 @@ func (h *MovieHandler) HandleUpdateMovie(w http.ResponseWriter, r *http.Request)
 }
 
 
+// HandleDeleteMovie archives a movie. Reservations and revenue stay intact;
+// the movie can be restored or purged later.
 func (h *MovieHandler) HandleDeleteMovie(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
 		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
@@ -104,12 +215,166 @@ func (h *MovieHandler) HandleDeleteMovie(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = h.Repo.DeleteMovie(context.Background(), id)
+	before, err := h.Repo.GetMovieByID(context.Background(), id)
+	if err != nil {
+		writeMovieError(w, err, "Failed to fetch movie")
+		return
+	}
+
+	err = h.Repo.ArchiveMovie(context.Background(), id)
 	if err != nil {
-		http.Error(w, "Failed to delete movie", http.StatusInternalServerError)
+		writeMovieError(w, err, "Failed to delete movie")
 		return
 	}
+	h.recordAudit(r, models.AuditActionArchive, id, before)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Movie archived successfully"})
+}
+
+func (h *MovieHandler) HandleGetArchivedMovies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	movies, err := h.Repo.ListMovies(context.Background(), models.MovieFilter{Archived: true}, params)
+	if err != nil {
+		writeListError(w, err, "Failed to fetch archived movies")
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"message": "Movie deleted successfully"})
+	json.NewEncoder(w).Encode(movies)
+}
+
+func (h *MovieHandler) HandleRestoreMovie(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/movies/restore/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid movie ID", http.StatusBadRequest)
+		return
+	}
+
+	before, err := h.Repo.GetMovieByID(context.Background(), id)
+	if err != nil {
+		writeMovieError(w, err, "Failed to fetch movie")
+		return
+	}
+
+	if err := h.Repo.RestoreMovie(context.Background(), id); err != nil {
+		writeMovieError(w, err, "Failed to restore movie")
+		return
+	}
+
+	movie, err := h.Repo.GetMovieByID(context.Background(), id)
+	if err != nil {
+		writeMovieError(w, err, "Failed to fetch movie")
+		return
+	}
+	recordAudit(r, h.Audit, models.AuditActionRestore, models.AuditEntityMovie, id, before, movie)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(movie)
+}
+
+// HandlePurgeMovie deletes an archived movie for good, including its
+// showtimes, reservations and images.
+func (h *MovieHandler) HandlePurgeMovie(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/movies/purge/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid movie ID", http.StatusBadRequest)
+		return
+	}
+
+	movie, err := h.Repo.GetMovieByID(context.Background(), id)
+	if err != nil {
+		writeMovieError(w, err, "Failed to fetch movie")
+		return
+	}
+	if movie.ArchivedAt == nil {
+		writeMovieError(w, repositories.ErrNotArchived, "")
+		return
+	}
+
+	// The records of the images go with the movie, so they are read first.
+	// Files are only deleted once the movie is gone, so that a failed purge
+	// leaves it with its images intact.
+	media, err := h.Media.ListMedia(context.Background(), id)
+	if err != nil {
+		writeMovieError(w, err, "Failed to fetch movie media")
+		return
+	}
+	if err := h.Repo.PurgeMovie(context.Background(), id); err != nil {
+		writeMovieError(w, err, "Failed to purge movie")
+		return
+	}
+	h.Media.DeleteFiles(context.Background(), media)
+	recordAudit(r, h.Audit, models.AuditActionDelete, models.AuditEntityMovie, id, movie, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Movie purged successfully"})
+}
+
+// recordAudit records a change to a movie that still exists, reading its new
+// state back from the database.
+func (h *MovieHandler) recordAudit(r *http.Request, action string, id int, before *models.Movie) {
+	after, err := h.Repo.GetMovieByID(context.Background(), id)
+	if err != nil {
+		log.Printf("Failed to record %s of movie %d: %v", action, id, err)
+		return
+	}
+	recordAudit(r, h.Audit, action, models.AuditEntityMovie, id, before, after)
+}
+
+func writeMovieError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrMovieNotFound):
+		http.Error(w, "Movie not found", http.StatusNotFound)
+	case errors.Is(err, repositories.ErrPersonNotFound), errors.Is(err, repositories.ErrGenreNotFound):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repositories.ErrNotArchived):
+		http.Error(w, "Archive the movie before purging it", http.StatusConflict)
+	case errors.Is(err, repositories.ErrMovieExists), errors.Is(err, repositories.ErrExternalIDExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, repositories.ErrShowtimeConflict), errors.Is(err, repositories.ErrRuntimeUnknown):
+		// The new runtime does not fit the schedule
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("%s: %v", message, err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
+// splitList flattens repeated and comma-separated query values, so that
+// ?genre=a,b and ?genre=a&genre=b mean the same.
+func splitList(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
 }
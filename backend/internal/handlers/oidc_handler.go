@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"movie-system/internal/repositories"
+	"movie-system/internal/services"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type OIDCHandler struct {
+	Service *services.OIDCService
+	AppURL  string
+}
+
+func NewOIDCHandler(service *services.OIDCService, appURL string) *OIDCHandler {
+	return &OIDCHandler{Service: service, AppURL: appURL}
+}
+
+func (h *OIDCHandler) HandleGetProviders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{"providers": h.Service.Providers()})
+}
+
+func (h *OIDCHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	provider := strings.TrimPrefix(r.URL.Path, "/auth/oidc/login/")
+
+	authURL, err := h.Service.BeginLogin(context.Background(), provider)
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownProvider) {
+			http.Error(w, "Unknown identity provider", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error starting %s login: %v", provider, err)
+		http.Error(w, "Failed to start login", http.StatusBadGateway)
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// HandleCallback finishes the login and sends the browser back to the
+// frontend with the result in the URL fragment, so tokens never reach server
+// logs of the frontend host.
+func (h *OIDCHandler) HandleCallback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	provider := strings.TrimPrefix(r.URL.Path, "/auth/oidc/callback/")
+	query := r.URL.Query()
+
+	fragment := url.Values{}
+	if providerError := query.Get("error"); providerError != "" {
+		log.Printf("%s login was not completed: %s", provider, providerError)
+		fragment.Set("error", "login_cancelled")
+		h.redirectToApp(w, r, fragment)
+		return
+	}
+
+	result, err := h.Service.CompleteLogin(context.Background(), provider, query.Get("code"), query.Get("state"), clientIP(r))
+	if err != nil {
+		log.Printf("Error completing %s login: %v", provider, err)
+		switch {
+		case errors.Is(err, services.ErrUnknownProvider):
+			http.Error(w, "Unknown identity provider", http.StatusNotFound)
+			return
+		case errors.Is(err, repositories.ErrInvalidState):
+			fragment.Set("error", "invalid_state")
+		case errors.Is(err, services.ErrEmailNotVerified):
+			fragment.Set("error", "email_not_verified")
+		case errors.Is(err, services.ErrAccountLinkPending):
+			fragment.Set("error", "account_link_pending")
+		case errors.Is(err, repositories.ErrUserDisabled):
+			fragment.Set("error", "account_disabled")
+		default:
+			fragment.Set("error", "login_failed")
+		}
+		h.redirectToApp(w, r, fragment)
+		return
+	}
+
+	if result.Token != "" {
+		fragment.Set("token", result.Token)
+	}
+	if result.MFAToken != "" {
+		fragment.Set("mfa_token", result.MFAToken)
+	}
+	if result.MFARequired {
+		fragment.Set("mfa_required", "true")
+	}
+	if result.MFASetupRequired {
+		fragment.Set("mfa_setup_required", "true")
+	}
+	h.redirectToApp(w, r, fragment)
+}
+
+func (h *OIDCHandler) redirectToApp(w http.ResponseWriter, r *http.Request, fragment url.Values) {
+	http.Redirect(w, r, h.AppURL+"/oidc/callback#"+fragment.Encode(), http.StatusFound)
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"movie-system/internal/pagination"
+	"movie-system/internal/repositories"
+	"net/http"
+)
+
+// pageParams reads the limit and cursor query parameters, answering with 400
+// if they are malformed.
+func pageParams(w http.ResponseWriter, r *http.Request) (pagination.Params, bool) {
+	params, err := pagination.ParseParams(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return params, false
+	}
+	return params, true
+}
+
+// writeListError answers a failed list request. Bad cursors and filters are
+// the client's fault; anything else is logged.
+func writeListError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, pagination.ErrInvalidCursor) || errors.Is(err, repositories.ErrInvalidFilter) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Printf("%s: %v", message, err)
+	http.Error(w, message, http.StatusInternalServerError)
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"movie-system/internal/models"
+	"movie-system/internal/repositories"
+	"movie-system/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type PersonHandler struct {
+	Repo  *repositories.PersonRepository
+	Audit *services.AuditService
+}
+
+func NewPersonHandler(repo *repositories.PersonRepository, audit *services.AuditService) *PersonHandler {
+	return &PersonHandler{Repo: repo, Audit: audit}
+}
+
+func (h *PersonHandler) HandleGetPeople(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	people, err := h.Repo.ListPeople(context.Background(), r.URL.Query().Get("search"), params)
+	if err != nil {
+		writeListError(w, err, "Failed to fetch people")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(people)
+}
+
+func (h *PersonHandler) HandleAddPerson(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var person models.Person
+	if err := json.NewDecoder(r.Body).Decode(&person); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+	person.Name = strings.TrimSpace(person.Name)
+	if person.Name == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Repo.InsertPerson(context.Background(), &person); err != nil {
+		writePersonError(w, err, "Failed to add person")
+		return
+	}
+	recordAudit(r, h.Audit, models.AuditActionCreate, models.AuditEntityPerson, int(person.ID), nil, &person)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(person)
+}
+
+func (h *PersonHandler) HandleUpdatePerson(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/people/update/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid person ID", http.StatusBadRequest)
+		return
+	}
+
+	var personData struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&personData); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+	personData.Name = strings.TrimSpace(personData.Name)
+	if personData.Name == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+
+	before, err := h.Repo.GetPersonByID(context.Background(), id)
+	if err != nil {
+		writePersonError(w, err, "Failed to fetch person")
+		return
+	}
+
+	person, err := h.Repo.UpdatePerson(context.Background(), id, personData.Name)
+	if err != nil {
+		writePersonError(w, err, "Failed to update person")
+		return
+	}
+	recordAudit(r, h.Audit, models.AuditActionUpdate, models.AuditEntityPerson, id, before, person)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(person)
+}
+
+func (h *PersonHandler) HandleDeletePerson(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/people/delete/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid person ID", http.StatusBadRequest)
+		return
+	}
+
+	before, err := h.Repo.GetPersonByID(context.Background(), id)
+	if err != nil {
+		writePersonError(w, err, "Failed to fetch person")
+		return
+	}
+
+	if err := h.Repo.DeletePerson(context.Background(), id); err != nil {
+		writePersonError(w, err, "Failed to delete person")
+		return
+	}
+	recordAudit(r, h.Audit, models.AuditActionDelete, models.AuditEntityPerson, id, before, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Person deleted successfully"})
+}
+
+func writePersonError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, repositories.ErrPersonNotFound) {
+		http.Error(w, "Person not found", http.StatusNotFound)
+		return
+	}
+	log.Printf("%s: %v", message, err)
+	http.Error(w, message, http.StatusInternalServerError)
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"movie-system/internal/auth"
+	"movie-system/internal/models"
+	"movie-system/internal/repositories"
+	"movie-system/internal/services"
+	"net/http"
+)
+
+type ProfileHandler struct {
+	Service  *services.AccountService
+	Privacy  *services.PrivacyService
+	UserRepo *repositories.UserRepository
+}
+
+func NewProfileHandler(service *services.AccountService, privacy *services.PrivacyService, userRepo *repositories.UserRepository) *ProfileHandler {
+	return &ProfileHandler{Service: service, Privacy: privacy, UserRepo: userRepo}
+}
+
+func (h *ProfileHandler) HandleGetProfile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(user)
+}
+
+func (h *ProfileHandler) HandleUpdateProfile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var update models.ProfileUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	updated, err := h.Service.UpdateProfile(context.Background(), user, update)
+	if err != nil {
+		writeProfileError(w, err, "Failed to update profile")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updated)
+}
+
+func (h *ProfileHandler) HandleChangePassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.ChangePassword(context.Background(), user, requestBody.CurrentPassword, requestBody.NewPassword); err != nil {
+		writeProfileError(w, err, "Failed to change password")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Password changed successfully"})
+}
+
+func (h *ProfileHandler) HandleDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Privacy.EraseAccount(context.Background(), user, requestBody.Password); err != nil {
+		writeProfileError(w, err, "Failed to delete account")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Account deleted successfully"})
+}
+
+func (h *ProfileHandler) HandleExportData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	writeDataExport(w, h.Privacy, int(user.ID))
+}
+
+func (h *ProfileHandler) currentUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Missing token", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	user, err := h.UserRepo.GetUserByUsername(context.Background(), claims.Username)
+	if err != nil {
+		writeUserError(w, err, "Failed to fetch user")
+		return nil, false
+	}
+	return user, true
+}
+
+func writeProfileError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidProfile):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repositories.ErrInvalidCredentials):
+		http.Error(w, "Current password is incorrect", http.StatusUnauthorized)
+	case errors.Is(err, repositories.ErrEmailTaken):
+		http.Error(w, "Email is already in use", http.StatusConflict)
+	default:
+		writeUserError(w, err, message)
+	}
+}
+
+// writeDataExport sends the data export of userID as a JSON file download.
+func writeDataExport(w http.ResponseWriter, privacy *services.PrivacyService, userID int) {
+	export, err := privacy.Export(context.Background(), userID)
+	if err != nil {
+		writeUserError(w, err, "Failed to export user data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%d-export.json"`, userID))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(export)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"movie-system/internal/auth"
+	"movie-system/internal/repositories"
+	"movie-system/internal/services"
+	"net/http"
+)
+
+type RecommendationHandler struct {
+	Service      *services.RecommendationService
+	Translations *services.TranslationService
+	UserRepo     *repositories.UserRepository
+}
+
+func NewRecommendationHandler(service *services.RecommendationService, translations *services.TranslationService, userRepo *repositories.UserRepository) *RecommendationHandler {
+	return &RecommendationHandler{Service: service, Translations: translations, UserRepo: userRepo}
+}
+
+// HandleGetRecommendations suggests movies with upcoming showtimes to the
+// signed-in user.
+func (h *RecommendationHandler) HandleGetRecommendations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	locales, ok := localesOf(w, r, h.Translations)
+	if !ok {
+		return
+	}
+
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Missing token", http.StatusUnauthorized)
+		return
+	}
+	user, err := h.UserRepo.GetUserByUsername(context.Background(), claims.Username)
+	if err != nil {
+		writeUserError(w, err, "Failed to fetch user")
+		return
+	}
+
+	page, err := h.Service.ForUser(context.Background(), int(user.ID), locales, params)
+	if err != nil {
+		writeListError(w, err, "Failed to fetch recommendations")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(page)
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"movie-system/internal/models"
@@ -69,7 +70,14 @@ func (h *ReservationHandler) HandleReservation(w http.ResponseWriter, r *http.Re
 	}
 
 	if err := h.Repo.ReserveSeat(context.Background(), &reservation); err != nil {
-		http.Error(w, fmt.Sprintf("Error creating resevation: %v", err), http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, repositories.ErrShowtimeNotFound):
+			http.Error(w, "Showtime not found", http.StatusNotFound)
+		case errors.Is(err, repositories.ErrShowtimeUnavailable):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, fmt.Sprintf("Error creating resevation: %v", err), http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -131,9 +139,14 @@ func (h *ReservationHandler) HandleGetReservations(w http.ResponseWriter, r *htt
 		return
 	}
 
-	reservations, err := h.Repo.GetReservations(context.Background(), userID)
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	reservations, err := h.Repo.GetReservations(context.Background(), userID, params)
 	if err != nil {
-		http.Error(w, "Error fetching reservations", http.StatusInternalServerError)
+		writeListError(w, err, "Error fetching reservations")
 		return
 	}
 
@@ -146,9 +159,18 @@ func (h *ReservationHandler) HandleGetAllReservations(w http.ResponseWriter, r *
 		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
 		return
 	}
-	reservations, err := h.Repo.GetAllReservations(context.Background())
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+	cinemaID, ok := scopedCinema(w, r)
+	if !ok {
+		return
+	}
+
+	reservations, err := h.Repo.GetAllReservations(context.Background(), cinemaID, params)
 	if err != nil {
-		http.Error(w, "Error fetching reservations", http.StatusInternalServerError)
+		writeListError(w, err, "Error fetching reservations")
 		return
 	}
 
@@ -167,8 +189,12 @@ func (h *ReservationHandler) HandleGetReservationsPerMovie(w http.ResponseWriter
 		http.Error(w, "Invalid movie ID", http.StatusBadRequest)
 		return
 	}
+	cinemaID, ok := scopedCinema(w, r)
+	if !ok {
+		return
+	}
 
-	counts, err := h.ReservationService.GetReservationsPerMovie(movieID)
+	counts, err := h.ReservationService.GetReservationsPerMovie(movieID, cinemaID)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error fetching reservations per movie: %v", err), http.StatusInternalServerError)
 		return
@@ -183,8 +209,12 @@ func (h *ReservationHandler) HandleGetTotalRevenue(w http.ResponseWriter, r *htt
 		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
 		return
 	}
+	cinemaID, ok := scopedCinema(w, r)
+	if !ok {
+		return
+	}
 
-	seatsReserved, revenue, totalRevenue, err := h.Repo.GetTotalRevenue(context.Background())
+	seatsReserved, revenue, totalRevenue, err := h.Repo.GetTotalRevenue(context.Background(), cinemaID)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error fetching total revenue: %v", err), http.StatusInternalServerError)
 		return
@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"movie-system/internal/auth"
+	"movie-system/internal/models"
+	"movie-system/internal/repositories"
+	"movie-system/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type ReviewHandler struct {
+	Service  *services.ReviewService
+	UserRepo *repositories.UserRepository
+}
+
+func NewReviewHandler(service *services.ReviewService, userRepo *repositories.UserRepository) *ReviewHandler {
+	return &ReviewHandler{Service: service, UserRepo: userRepo}
+}
+
+// HandleGetMovieReviews lists the reviews of a movie that are not hidden,
+// newest first.
+func (h *ReviewHandler) HandleGetMovieReviews(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/reviews/movie/")
+	movieID, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid movie ID", http.StatusBadRequest)
+		return
+	}
+
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.Service.ListForMovie(context.Background(), movieID, params)
+	if err != nil {
+		if errors.Is(err, repositories.ErrMovieNotFound) {
+			http.Error(w, "Movie not found", http.StatusNotFound)
+			return
+		}
+		writeListError(w, err, "Failed to fetch reviews")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(page)
+}
+
+// HandleGetMyReviews lists the reviews of the signed-in user, hidden ones
+// included.
+func (h *ReviewHandler) HandleGetMyReviews(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.Service.ListByUser(context.Background(), int(user.ID), params)
+	if err != nil {
+		writeListError(w, err, "Failed to fetch reviews")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(page)
+}
+
+func (h *ReviewHandler) HandleAddReview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var review models.Review
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.Service.Add(context.Background(), int(user.ID), &review)
+	if err != nil {
+		writeReviewError(w, err, "Failed to add review")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+func (h *ReviewHandler) HandleUpdateReview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, ok := reviewID(w, r, "/reviews/update/")
+	if !ok {
+		return
+	}
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var review models.Review
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
+	updated, err := h.Service.Update(context.Background(), int(user.ID), id, review.Rating, review.Body)
+	if err != nil {
+		writeReviewError(w, err, "Failed to update review")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updated)
+}
+
+// HandleDeleteReview deletes a review of the signed-in user. Admins may
+// delete any review.
+func (h *ReviewHandler) HandleDeleteReview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, ok := reviewID(w, r, "/reviews/delete/")
+	if !ok {
+		return
+	}
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.Delete(context.Background(), int(user.ID), id, models.RoleAllows(user.Role, models.RoleAdmin)); err != nil {
+		writeReviewError(w, err, "Failed to delete review")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Review deleted successfully"})
+}
+
+// HandleReportReview flags a review for moderation.
+func (h *ReviewHandler) HandleReportReview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, ok := reviewID(w, r, "/reviews/report/")
+	if !ok {
+		return
+	}
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.Report(context.Background(), int(user.ID), id, requestBody.Reason); err != nil {
+		writeReviewError(w, err, "Failed to report review")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Review reported"})
+}
+
+// HandleGetModerationQueue lists the reviews in the status query parameter,
+// by default the reported ones.
+func (h *ReviewHandler) HandleGetModerationQueue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.Service.ListForModeration(context.Background(), r.URL.Query().Get("status"), params)
+	if err != nil {
+		writeListError(w, err, "Failed to fetch reviews")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(page)
+}
+
+func (h *ReviewHandler) HandleHideReview(w http.ResponseWriter, r *http.Request) {
+	h.handleModerate(w, r, "/reviews/hide/", h.Service.Hide)
+}
+
+func (h *ReviewHandler) HandleApproveReview(w http.ResponseWriter, r *http.Request) {
+	h.handleModerate(w, r, "/reviews/approve/", h.Service.Approve)
+}
+
+func (h *ReviewHandler) handleModerate(w http.ResponseWriter, r *http.Request, prefix string, moderate func(context.Context, string, int) (*models.Review, error)) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, ok := reviewID(w, r, prefix)
+	if !ok {
+		return
+	}
+
+	review, err := moderate(context.Background(), actorOf(r), id)
+	if err != nil {
+		writeReviewError(w, err, "Failed to moderate review")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(review)
+}
+
+func (h *ReviewHandler) currentUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Missing token", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	user, err := h.UserRepo.GetUserByUsername(context.Background(), claims.Username)
+	if err != nil {
+		writeUserError(w, err, "Failed to fetch user")
+		return nil, false
+	}
+	return user, true
+}
+
+func reviewID(w http.ResponseWriter, r *http.Request, prefix string) (int, bool) {
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, prefix))
+	if err != nil {
+		http.Error(w, "Invalid review ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+func writeReviewError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrReviewNotFound):
+		http.Error(w, "Review not found", http.StatusNotFound)
+	case errors.Is(err, repositories.ErrMovieNotFound):
+		http.Error(w, "Movie not found", http.StatusNotFound)
+	case errors.Is(err, repositories.ErrReviewExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrReviewNotAllowed), errors.Is(err, services.ErrNotReviewAuthor):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrInvalidReview):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("%s: %v", message, err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"movie-system/internal/models"
+	"movie-system/internal/repositories"
+	"movie-system/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type ScheduleHandler struct {
+	Service *services.ScheduleService
+	Audit   *services.AuditService
+}
+
+func NewScheduleHandler(service *services.ScheduleService, audit *services.AuditService) *ScheduleHandler {
+	return &ScheduleHandler{Service: service, Audit: audit}
+}
+
+// HandleGetSchedules lists the schedules of the cinema an admin runs, or for
+// head office of all cinemas or the one with cinema_id.
+func (h *ScheduleHandler) HandleGetSchedules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+	cinemaID, ok := scopedCinema(w, r)
+	if !ok {
+		return
+	}
+
+	schedules, err := h.Service.List(context.Background(), cinemaID, params)
+	if err != nil {
+		writeListError(w, err, "Failed to fetch schedules")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(schedules)
+}
+
+// HandleGetSchedule returns a schedule with all its showtimes.
+func (h *ScheduleHandler) HandleGetSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/schedules/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid schedule ID", http.StatusBadRequest)
+		return
+	}
+
+	schedule, err := h.Service.Get(context.Background(), id)
+	if err != nil {
+		writeScheduleError(w, err, "Failed to fetch schedule")
+		return
+	}
+	if !checkCinema(w, r, schedule.CinemaID) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// HandleAddSchedule creates a schedule and its showtimes, or with
+// dry_run=true previews them. The schedule is in the cinema of its
+// auditorium or, without one, in cinema_id or the cinema the admin runs.
+func (h *ScheduleHandler) HandleAddSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	dryRun, ok := boolParam(w, r.URL.Query().Get("dry_run"), "dry_run")
+	if !ok {
+		return
+	}
+	var schedule models.Schedule
+	if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+	if !placeInCinema(w, r, &schedule.CinemaID) {
+		return
+	}
+
+	report, err := h.Service.Create(context.Background(), &schedule, dryRun)
+	if err != nil {
+		writeScheduleError(w, err, "Failed to add schedule")
+		return
+	}
+	if report.Committed {
+		recordAudit(r, h.Audit, models.AuditActionCreate, models.AuditEntitySchedule, int(report.Schedule.ID), nil, &report.Schedule)
+	}
+	writeScheduleReport(w, report, http.StatusCreated)
+}
+
+// HandleUpdateSchedule changes a schedule and its remaining showtimes, or
+// with dry_run=true previews the changes.
+func (h *ScheduleHandler) HandleUpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/schedules/update/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid schedule ID", http.StatusBadRequest)
+		return
+	}
+	dryRun, ok := boolParam(w, r.URL.Query().Get("dry_run"), "dry_run")
+	if !ok {
+		return
+	}
+	var schedule models.Schedule
+	if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
+	before, err := h.Service.Get(context.Background(), id)
+	if err != nil {
+		writeScheduleError(w, err, "Failed to fetch schedule")
+		return
+	}
+	if !checkCinema(w, r, before.CinemaID) {
+		return
+	}
+	if schedule.CinemaID == 0 && schedule.AuditoriumID == 0 {
+		schedule.CinemaID = before.CinemaID
+	}
+	if !placeInCinema(w, r, &schedule.CinemaID) {
+		return
+	}
+
+	report, err := h.Service.Update(context.Background(), id, &schedule, dryRun)
+	if err != nil {
+		writeScheduleError(w, err, "Failed to update schedule")
+		return
+	}
+	if report.Committed {
+		before.Showtimes = nil
+		recordAudit(r, h.Audit, models.AuditActionUpdate, models.AuditEntitySchedule, id, before, &report.Schedule)
+	}
+	writeScheduleReport(w, report, http.StatusOK)
+}
+
+// HandleCancelSchedule deletes the remaining showtimes of a schedule that
+// are not booked, or with dry_run=true lists them.
+func (h *ScheduleHandler) HandleCancelSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/schedules/cancel/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid schedule ID", http.StatusBadRequest)
+		return
+	}
+	dryRun, ok := boolParam(w, r.URL.Query().Get("dry_run"), "dry_run")
+	if !ok {
+		return
+	}
+
+	before, err := h.Service.Get(context.Background(), id)
+	if err != nil {
+		writeScheduleError(w, err, "Failed to fetch schedule")
+		return
+	}
+	if !checkCinema(w, r, before.CinemaID) {
+		return
+	}
+
+	report, err := h.Service.Cancel(context.Background(), id, dryRun)
+	if err != nil {
+		writeScheduleError(w, err, "Failed to cancel schedule")
+		return
+	}
+	if report.Committed {
+		before.Showtimes = nil
+		recordAudit(r, h.Audit, models.AuditActionUpdate, models.AuditEntitySchedule, id, before, &report.Schedule)
+	}
+	writeScheduleReport(w, report, http.StatusOK)
+}
+
+// writeScheduleReport answers with status once a change is saved, with 200
+// for a preview and with 409 if showtimes failed and nothing was saved.
+func writeScheduleReport(w http.ResponseWriter, report *models.ScheduleReport, status int) {
+	switch {
+	case report.Failed > 0:
+		status = http.StatusConflict
+	case report.DryRun:
+		status = http.StatusOK
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
+
+func writeScheduleError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrScheduleNotFound):
+		http.Error(w, "Schedule not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrInvalidSchedule), errors.Is(err, repositories.ErrMovieNotFound),
+		errors.Is(err, repositories.ErrAuditoriumNotFound), errors.Is(err, repositories.ErrCinemaNotFound):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repositories.ErrScheduleCancelled):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("%s: %v", message, err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
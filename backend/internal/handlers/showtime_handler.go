@@ -3,19 +3,29 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"log"
 	"movie-system/internal/models"
 	"movie-system/internal/repositories"
+	"movie-system/internal/services"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// movieShowtimeDays is how many days ahead the showtimes of a movie are
+// listed, today included.
+const movieShowtimeDays = 7
+
 type ShowtimeHandler struct {
-	Repo *repositories.ShowtimeRepository
+	Repo  *repositories.ShowtimeRepository
+	Audit *services.AuditService
 }
 
-func NewShowtimeHandler(repo *repositories.ShowtimeRepository) *ShowtimeHandler {
-	return &ShowtimeHandler{Repo: repo}
+func NewShowtimeHandler(repo *repositories.ShowtimeRepository, audit *services.AuditService) *ShowtimeHandler {
+	return &ShowtimeHandler{Repo: repo, Audit: audit}
 }
 
 func (h *ShowtimeHandler) HandleAddShowtime(w http.ResponseWriter, r *http.Request) {
@@ -28,27 +38,57 @@ func (h *ShowtimeHandler) HandleAddShowtime(w http.ResponseWriter, r *http.Reque
 		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
 		return
 	}
+	if !placeInCinema(w, r, &showtime.CinemaID) {
+		return
+	}
 
 	err := h.Repo.InsertShowtime(context.Background(), &showtime)
 	if err != nil {
-		http.Error(w, "Failed to add showtime", http.StatusInternalServerError)
+		writeShowtimeError(w, err, "Failed to add showtime")
 		return
 	}
+	recordAudit(r, h.Audit, models.AuditActionCreate, models.AuditEntityShowtime, int(showtime.ID), nil, &showtime)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(showtime)
 }
 
+// HandleGetShowtimes lists the bookable showtimes that have not started,
+// filtered by the movie_id, cinema_id, auditorium_id, from, to and
+// min_available query parameters.
 func (h *ShowtimeHandler) HandleGetShowtimes(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
 		return
 	}
 
-	showtimes, err := h.Repo.GetShowtimes(context.Background())
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+	filter, ok := showtimeFilter(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	if filter.MovieID, ok = idParam(w, query, "movie_id"); !ok {
+		return
+	}
+	if filter.From, ok = dateParam(w, query, "from"); !ok {
+		return
+	}
+	if filter.To, ok = dateParam(w, query, "to"); !ok {
+		return
+	}
+	if filter.From != nil && filter.To != nil && filter.To.Before(*filter.From) {
+		http.Error(w, "to must not be before from", http.StatusBadRequest)
+		return
+	}
+
+	showtimes, err := h.Repo.ListShowtimes(context.Background(), filter, params)
 	if err != nil {
-		http.Error(w, "Failed to fetch showtimes", http.StatusInternalServerError)
+		writeListError(w, err, "Failed to fetch showtimes")
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -56,6 +96,89 @@ func (h *ShowtimeHandler) HandleGetShowtimes(w http.ResponseWriter, r *http.Requ
 	json.NewEncoder(w).Encode(showtimes)
 }
 
+// HandleGetMovieShowtimes lists the showtimes of a movie in the next seven
+// days by date, filtered by the cinema_id, auditorium_id and min_available
+// query parameters.
+func (h *ShowtimeHandler) HandleGetMovieShowtimes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/showtimes/movie/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid movie ID", http.StatusBadRequest)
+		return
+	}
+	filter, ok := showtimeFilter(w, r)
+	if !ok {
+		return
+	}
+	filter.Days = movieShowtimeDays
+
+	showtimes, err := h.Repo.GetMovieShowtimes(context.Background(), id, filter)
+	if err != nil {
+		if errors.Is(err, repositories.ErrMovieNotFound) {
+			http.Error(w, "Movie not found", http.StatusNotFound)
+			return
+		}
+		writeShowtimeError(w, err, "Failed to fetch showtimes")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(showtimes)
+}
+
+// showtimeFilter reads the cinema_id, auditorium_id and min_available query
+// parameters that both listings of upcoming showtimes take.
+func showtimeFilter(w http.ResponseWriter, r *http.Request) (models.ShowtimeFilter, bool) {
+	var filter models.ShowtimeFilter
+	var ok bool
+	if filter.CinemaID, ok = cinemaParam(w, r); !ok {
+		return filter, false
+	}
+	query := r.URL.Query()
+	if filter.AuditoriumID, ok = idParam(w, query, "auditorium_id"); !ok {
+		return filter, false
+	}
+	if filter.MinAvailable, ok = idParam(w, query, "min_available"); !ok {
+		return filter, false
+	}
+	return filter, true
+}
+
+// idParam reads an optional positive integer query parameter, answering with
+// 400 if it is malformed.
+func idParam(w http.ResponseWriter, query url.Values, name string) (int, bool) {
+	value := query.Get(name)
+	if value == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		http.Error(w, "Invalid "+name+": expected a positive integer", http.StatusBadRequest)
+		return 0, false
+	}
+	return n, true
+}
+
+// dateParam reads an optional YYYY-MM-DD query parameter, answering with 400
+// if it is malformed.
+func dateParam(w http.ResponseWriter, query url.Values, name string) (*time.Time, bool) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, true
+	}
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		http.Error(w, "Invalid "+name+": expected a date such as 2025-03-01", http.StatusBadRequest)
+		return nil, false
+	}
+	return &date, true
+}
+
 func (h *ShowtimeHandler) HandleUpdateShowtime(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
 		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
@@ -75,11 +198,27 @@ func (h *ShowtimeHandler) HandleUpdateShowtime(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	before, err := h.Repo.GetShowtimeByID(context.Background(), id)
+	if err != nil {
+		writeShowtimeError(w, err, "Failed to fetch showtime")
+		return
+	}
+	if !checkCinema(w, r, before.CinemaID) {
+		return
+	}
+	if showtime.CinemaID == 0 && showtime.AuditoriumID == 0 {
+		showtime.CinemaID = before.CinemaID
+	}
+	if !placeInCinema(w, r, &showtime.CinemaID) {
+		return
+	}
+
 	err = h.Repo.UpdateShowtime(context.Background(), id, &showtime)
 	if err != nil {
-		http.Error(w, "Failed to update showtime", http.StatusInternalServerError)
+		writeShowtimeError(w, err, "Failed to update showtime")
 		return
 	}
+	h.recordAudit(r, models.AuditActionUpdate, id, before)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -99,14 +238,115 @@ func (h *ShowtimeHandler) HandleDeleteShowtime(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	err = h.Repo.DeleteShowtime(context.Background(), id)
+	before, err := h.Repo.GetShowtimeByID(context.Background(), id)
 	if err != nil {
-		http.Error(w, "Failed to delete showtime", http.StatusInternalServerError)
+		writeShowtimeError(w, err, "Failed to fetch showtime")
+		return
+	}
+	if !checkCinema(w, r, before.CinemaID) {
 		return
 	}
+
+	err = h.Repo.ArchiveShowtime(context.Background(), id)
+	if err != nil {
+		writeShowtimeError(w, err, "Failed to delete showtime")
+		return
+	}
+	h.recordAudit(r, models.AuditActionArchive, id, before)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"message": "Showtime deleted successfully"})
+	json.NewEncoder(w).Encode(map[string]string{"message": "Showtime archived successfully"})
+}
+
+func (h *ShowtimeHandler) HandleGetArchivedShowtimes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+	cinemaID, ok := scopedCinema(w, r)
+	if !ok {
+		return
+	}
+
+	showtimes, err := h.Repo.ListShowtimes(context.Background(), models.ShowtimeFilter{Archived: true, CinemaID: cinemaID}, params)
+	if err != nil {
+		writeListError(w, err, "Failed to fetch archived showtimes")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(showtimes)
+}
+
+func (h *ShowtimeHandler) HandleRestoreShowtime(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/showtimes/restore/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid showtime ID", http.StatusBadRequest)
+		return
+	}
+
+	before, err := h.Repo.GetShowtimeByID(context.Background(), id)
+	if err != nil {
+		writeShowtimeError(w, err, "Failed to fetch showtime")
+		return
+	}
+	if !checkCinema(w, r, before.CinemaID) {
+		return
+	}
+
+	if err := h.Repo.RestoreShowtime(context.Background(), id); err != nil {
+		writeShowtimeError(w, err, "Failed to restore showtime")
+		return
+	}
+	h.recordAudit(r, models.AuditActionRestore, id, before)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Showtime restored successfully"})
+}
+
+// HandlePurgeShowtime deletes an archived showtime for good, including its
+// reservations.
+func (h *ShowtimeHandler) HandlePurgeShowtime(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/showtimes/purge/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid showtime ID", http.StatusBadRequest)
+		return
+	}
+
+	before, err := h.Repo.GetShowtimeByID(context.Background(), id)
+	if err != nil {
+		writeShowtimeError(w, err, "Failed to fetch showtime")
+		return
+	}
+	if !checkCinema(w, r, before.CinemaID) {
+		return
+	}
+
+	if err := h.Repo.PurgeShowtime(context.Background(), id); err != nil {
+		writeShowtimeError(w, err, "Failed to purge showtime")
+		return
+	}
+	recordAudit(r, h.Audit, models.AuditActionDelete, models.AuditEntityShowtime, id, before, nil)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Showtime purged successfully"})
 }
 
 func (h *ShowtimeHandler) HandleGetSeats(w http.ResponseWriter, r *http.Request) {
@@ -131,3 +371,31 @@ func (h *ShowtimeHandler) HandleGetSeats(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(seats)
 }
+
+// recordAudit records a change to a showtime that still exists, reading its
+// new state back from the database.
+func (h *ShowtimeHandler) recordAudit(r *http.Request, action string, id int, before *models.Showtime) {
+	after, err := h.Repo.GetShowtimeByID(context.Background(), id)
+	if err != nil {
+		log.Printf("Failed to record %s of showtime %d: %v", action, id, err)
+		return
+	}
+	recordAudit(r, h.Audit, action, models.AuditEntityShowtime, id, before, after)
+}
+
+func writeShowtimeError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrShowtimeNotFound):
+		http.Error(w, "Showtime not found", http.StatusNotFound)
+	case errors.Is(err, repositories.ErrNotArchived):
+		http.Error(w, "Archive the showtime before purging it", http.StatusConflict)
+	case errors.Is(err, repositories.ErrMovieNotFound), errors.Is(err, repositories.ErrAuditoriumNotFound),
+		errors.Is(err, repositories.ErrCinemaNotFound):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repositories.ErrShowtimeConflict), errors.Is(err, repositories.ErrRuntimeUnknown):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("%s: %v", message, err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"movie-system/internal/locale"
+	"movie-system/internal/models"
+	"movie-system/internal/repositories"
+	"movie-system/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type TranslationHandler struct {
+	Service *services.TranslationService
+	Audit   *services.AuditService
+}
+
+func NewTranslationHandler(service *services.TranslationService, audit *services.AuditService) *TranslationHandler {
+	return &TranslationHandler{Service: service, Audit: audit}
+}
+
+// HandleGetMovieTranslations lists the translations of a movie.
+func (h *TranslationHandler) HandleGetMovieTranslations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/movies/translations/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid movie ID", http.StatusBadRequest)
+		return
+	}
+
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.Service.ListMovieTranslations(context.Background(), id, params)
+	if err != nil {
+		if errors.Is(err, repositories.ErrMovieNotFound) {
+			http.Error(w, "Movie not found", http.StatusNotFound)
+			return
+		}
+		writeListError(w, err, "Failed to fetch translations")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(page)
+}
+
+// HandleSetMovieTranslation creates or replaces the translation of a movie
+// into the locale given in the path.
+func (h *TranslationHandler) HandleSetMovieTranslation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, tag, ok := translationPath(w, r, "/movies/translations/set/")
+	if !ok {
+		return
+	}
+
+	var translation models.MovieTranslation
+	if err := json.NewDecoder(r.Body).Decode(&translation); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+	translation.Locale = tag
+
+	before, err := h.Service.SetMovieTranslation(context.Background(), id, &translation)
+	if err != nil {
+		writeTranslationError(w, err, "Failed to save translation")
+		return
+	}
+	h.recordSet(r, models.AuditEntityMovieTranslation, id, before == nil, before, &translation)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(translation)
+}
+
+func (h *TranslationHandler) HandleDeleteMovieTranslation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, tag, ok := translationPath(w, r, "/movies/translations/delete/")
+	if !ok {
+		return
+	}
+
+	translation, err := h.Service.DeleteMovieTranslation(context.Background(), id, tag)
+	if err != nil {
+		writeTranslationError(w, err, "Failed to delete translation")
+		return
+	}
+	recordAudit(r, h.Audit, models.AuditActionDelete, models.AuditEntityMovieTranslation, id, translation, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Translation deleted successfully"})
+}
+
+// HandleGetGenreTranslations lists the translations of a genre.
+func (h *TranslationHandler) HandleGetGenreTranslations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/genres/translations/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid genre ID", http.StatusBadRequest)
+		return
+	}
+
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.Service.ListGenreTranslations(context.Background(), id, params)
+	if err != nil {
+		if errors.Is(err, repositories.ErrGenreNotFound) {
+			http.Error(w, "Genre not found", http.StatusNotFound)
+			return
+		}
+		writeListError(w, err, "Failed to fetch translations")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(page)
+}
+
+func (h *TranslationHandler) HandleSetGenreTranslation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, tag, ok := translationPath(w, r, "/genres/translations/set/")
+	if !ok {
+		return
+	}
+
+	var translation models.GenreTranslation
+	if err := json.NewDecoder(r.Body).Decode(&translation); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+	translation.Locale = tag
+
+	before, err := h.Service.SetGenreTranslation(context.Background(), id, &translation)
+	if err != nil {
+		writeTranslationError(w, err, "Failed to save translation")
+		return
+	}
+	h.recordSet(r, models.AuditEntityGenreTranslation, id, before == nil, before, &translation)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(translation)
+}
+
+func (h *TranslationHandler) HandleDeleteGenreTranslation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, tag, ok := translationPath(w, r, "/genres/translations/delete/")
+	if !ok {
+		return
+	}
+
+	translation, err := h.Service.DeleteGenreTranslation(context.Background(), id, tag)
+	if err != nil {
+		writeTranslationError(w, err, "Failed to delete translation")
+		return
+	}
+	recordAudit(r, h.Audit, models.AuditActionDelete, models.AuditEntityGenreTranslation, id, translation, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Translation deleted successfully"})
+}
+
+// recordSet records a saved translation, which is new unless it replaced
+// one.
+func (h *TranslationHandler) recordSet(r *http.Request, entityType string, id int, created bool, before, after any) {
+	action := models.AuditActionUpdate
+	if created {
+		action = models.AuditActionCreate
+	}
+	recordAudit(r, h.Audit, action, entityType, id, before, after)
+}
+
+// translationPath reads the id and locale from a path of the form
+// prefix{id}/{locale}, answering with 400 if either is malformed.
+func translationPath(w http.ResponseWriter, r *http.Request, prefix string) (int, string, bool) {
+	idStr, tag, found := strings.Cut(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	id, err := strconv.Atoi(idStr)
+	if !found || err != nil {
+		http.Error(w, "Invalid translation path: expected {id}/{locale}", http.StatusBadRequest)
+		return 0, "", false
+	}
+	return id, tag, true
+}
+
+// localesOf negotiates the locales to translate a response into from the
+// locale query parameter or the Accept-Language header, answering with 400
+// if the parameter is malformed.
+func localesOf(w http.ResponseWriter, r *http.Request, translations *services.TranslationService) ([]string, bool) {
+	w.Header().Add("Vary", "Accept-Language")
+	locales, err := translations.Negotiate(r.Header.Get("Accept-Language"), r.URL.Query().Get("locale"))
+	if err != nil {
+		http.Error(w, "Invalid locale", http.StatusBadRequest)
+		return nil, false
+	}
+	return locales, true
+}
+
+func writeTranslationError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrMovieNotFound):
+		http.Error(w, "Movie not found", http.StatusNotFound)
+	case errors.Is(err, repositories.ErrGenreNotFound):
+		http.Error(w, "Genre not found", http.StatusNotFound)
+	case errors.Is(err, repositories.ErrTranslationNotFound):
+		http.Error(w, "Translation not found", http.StatusNotFound)
+	case errors.Is(err, locale.ErrInvalidLocale):
+		http.Error(w, "Invalid locale", http.StatusBadRequest)
+	case errors.Is(err, services.ErrInvalidTranslation):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("%s: %v", message, err)
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"movie-system/internal/models"
+	"movie-system/internal/repositories"
+	"movie-system/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type UserHandler struct {
+	Repo          *repositories.UserRepository
+	LoginAttempts *repositories.LoginAttemptRepository
+	AuthService   *services.AuthService
+	Privacy       *services.PrivacyService
+}
+
+func NewUserHandler(repo *repositories.UserRepository, loginAttempts *repositories.LoginAttemptRepository, authService *services.AuthService, privacy *services.PrivacyService) *UserHandler {
+	return &UserHandler{Repo: repo, LoginAttempts: loginAttempts, AuthService: authService, Privacy: privacy}
+}
+
+func (h *UserHandler) HandleGetUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	search := r.URL.Query().Get("search")
+
+	// Admins see the users they may manage, as checkUser allows.
+	users, err := h.Repo.ListUsers(context.Background(), search, cinemaScope(r), params)
+	if err != nil {
+		writeListError(w, err, "Failed to fetch users")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(users)
+}
+
+func (h *UserHandler) HandleAddUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var userData struct {
+		Username string `json:"username"`
+		Email    string `json:"email"`
+		Password string `json:"password"`
+		Role     string `json:"role"`
+		CinemaID uint   `json:"cinema_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&userData); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
+	if userData.Username == "" || userData.Password == "" {
+		http.Error(w, "Username and password are required", http.StatusBadRequest)
+		return
+	}
+	if userData.Role == "" {
+		userData.Role = models.RoleUser
+	}
+	if !assignRole(w, r, userData.Role, &userData.CinemaID) {
+		return
+	}
+
+	user, err := h.AuthService.CreateUser(context.Background(), userData.Username, userData.Email, userData.Password, userData.Role, userData.CinemaID)
+	if errors.Is(err, repositories.ErrCinemaNotFound) {
+		http.Error(w, "Cinema not found", http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, services.ErrInvalidEmail) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, repositories.ErrEmailTaken) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating user: %v", err)
+		http.Error(w, "Failed to create user", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(user)
+}
+
+func (h *UserHandler) HandleUpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/users/role/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var roleData struct {
+		Role     string `json:"role"`
+		CinemaID uint   `json:"cinema_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&roleData); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	if !assignRole(w, r, roleData.Role, &roleData.CinemaID) || !h.checkUser(w, r, id) {
+		return
+	}
+
+	if err := h.Repo.UpdateUserRole(context.Background(), id, roleData.Role, roleData.CinemaID); err != nil {
+		writeUserError(w, err, "Failed to update user role")
+		return
+	}
+
+	h.writeUser(w, id)
+}
+
+func (h *UserHandler) HandleSetUserStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/users/status/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var statusData struct {
+		Disabled bool `json:"disabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&statusData); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+	if !h.checkUser(w, r, id) {
+		return
+	}
+
+	if err := h.Repo.SetUserDisabled(context.Background(), id, statusData.Disabled); err != nil {
+		writeUserError(w, err, "Failed to update user status")
+		return
+	}
+
+	h.writeUser(w, id)
+}
+
+func (h *UserHandler) HandleDeleteUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/users/delete/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	if !h.checkUser(w, r, id) {
+		return
+	}
+
+	if err := h.Repo.DeleteUser(context.Background(), id); err != nil {
+		writeUserError(w, err, "Failed to delete user")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "User deleted successfully"})
+}
+
+func (h *UserHandler) HandleExportUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/users/export/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	if !h.checkUser(w, r, id) {
+		return
+	}
+
+	writeDataExport(w, h.Privacy, id)
+}
+
+// HandleEraseUser anonymizes a user on request of the data subject while
+// keeping their reservations for accounting.
+func (h *UserHandler) HandleEraseUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/users/erase/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	if !h.checkUser(w, r, id) {
+		return
+	}
+
+	if err := h.Privacy.Erase(context.Background(), id); err != nil {
+		writeUserError(w, err, "Failed to erase user")
+		return
+	}
+
+	h.writeUser(w, id)
+}
+
+func (h *UserHandler) HandleGetLoginAttempts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	attempts, err := h.LoginAttempts.GetAttempts(context.Background(), r.URL.Query().Get("username"), params)
+	if err != nil {
+		writeListError(w, err, "Failed to fetch login attempts")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(attempts)
+}
+
+// checkUser answers 403 and returns false if an admin asks to manage head
+// office or the admins of another cinema.
+func (h *UserHandler) checkUser(w http.ResponseWriter, r *http.Request, id int) bool {
+	scope := cinemaScope(r)
+	if scope == 0 {
+		return true
+	}
+	user, err := h.Repo.GetUserByID(context.Background(), id)
+	if err != nil {
+		writeUserError(w, err, "Failed to fetch user")
+		return false
+	}
+	if user.Role == models.RoleHeadOffice || user.Role == models.RoleAdmin && user.CinemaID != scope {
+		http.Error(w, "Forbidden: the user is managed by head office", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// assignRole checks that role may be given. Admins run a cinema, by default
+// the one of the admin giving the role, and only head office appoints head
+// office or the admins of other cinemas. Other roles have no cinema.
+func assignRole(w http.ResponseWriter, r *http.Request, role string, cinemaID *uint) bool {
+	if !models.IsValidRole(role) {
+		http.Error(w, "Invalid role", http.StatusBadRequest)
+		return false
+	}
+	if role != models.RoleAdmin {
+		*cinemaID = 0
+		if role == models.RoleHeadOffice && cinemaScope(r) != 0 {
+			http.Error(w, "Forbidden: only head office can appoint head office", http.StatusForbidden)
+			return false
+		}
+		return true
+	}
+	if !placeInCinema(w, r, cinemaID) {
+		return false
+	}
+	if *cinemaID == 0 {
+		http.Error(w, "cinema_id is required for admins", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func (h *UserHandler) writeUser(w http.ResponseWriter, id int) {
+	user, err := h.Repo.GetUserByID(context.Background(), id)
+	if err != nil {
+		writeUserError(w, err, "Failed to fetch user")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(user)
+}
+
+func writeUserError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, repositories.ErrCinemaNotFound) {
+		http.Error(w, "Cinema not found", http.StatusBadRequest)
+		return
+	}
+	log.Printf("%s: %v", message, err)
+	http.Error(w, message, http.StatusInternalServerError)
+}
@@ -0,0 +1,91 @@
+// Package locale negotiates the language of content from Accept-Language
+// headers. Locales are BCP 47 tags kept in lower case, such as "en" or
+// "pt-br".
+package locale
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidLocale = errors.New("invalid locale")
+
+var tagPattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// Normalize lowercases tag and checks that it looks like a language tag.
+// Underscores are accepted in place of dashes.
+func Normalize(tag string) (string, error) {
+	tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	if len(tag) > 35 || !tagPattern.MatchString(tag) {
+		return "", ErrInvalidLocale
+	}
+	return tag, nil
+}
+
+// Parse reads an Accept-Language header into locales ordered by preference.
+// Each locale is followed by its more general forms unless the header lists
+// them itself, so "de-CH" also accepts "de". Wildcards, malformed tags and
+// q=0 are skipped.
+func Parse(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		normalized, err := Normalize(tag)
+		if err != nil || q <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{normalized, q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	listed := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		listed[tag.tag] = true
+	}
+	seen := make(map[string]bool)
+	var locales []string
+	add := func(tag string) {
+		if !seen[tag] {
+			seen[tag] = true
+			locales = append(locales, tag)
+		}
+	}
+	for _, tag := range tags {
+		add(tag.tag)
+		for general := tag.tag; strings.Contains(general, "-"); {
+			general = general[:strings.LastIndex(general, "-")]
+			if listed[general] {
+				break
+			}
+			add(general)
+		}
+	}
+	return locales
+}
+
+// Candidates cuts preferred off at the default locale: content in the
+// default locale is the movie itself, so translations ranked lower are
+// never used.
+func Candidates(preferred []string, defaultLocale string) []string {
+	for i, tag := range preferred {
+		if tag == defaultLocale {
+			return preferred[:i]
+		}
+	}
+	return preferred
+}
@@ -0,0 +1,31 @@
+package locale
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tag, err := Normalize(" pt_BR ")
+	assert.NoError(t, err)
+	assert.Equal(t, "pt-br", tag)
+
+	for _, invalid := range []string{"", "*", "english", "e", "en--us", "en-"} {
+		_, err := Normalize(invalid)
+		assert.ErrorIs(t, err, ErrInvalidLocale, invalid)
+	}
+}
+
+func TestParse(t *testing.T) {
+	assert.Equal(t, []string{"ru", "en-us", "en"}, Parse("en-US;q=0.8, ru, *;q=0.1"))
+	assert.Equal(t, []string{"de-ch", "fr", "de"}, Parse("de-CH, fr;q=0.9, de;q=0.5"), "a listed general form keeps its own rank")
+	assert.Equal(t, []string{"kk"}, Parse("kk, ru;q=0, en;q=oops"))
+	assert.Empty(t, Parse(""))
+}
+
+func TestCandidates(t *testing.T) {
+	assert.Equal(t, []string{"ru"}, Candidates([]string{"ru", "en", "de"}, "en"))
+	assert.Empty(t, Candidates([]string{"en-us", "en"}, "en-us"))
+	assert.Equal(t, []string{"de"}, Candidates([]string{"de"}, "en"))
+}
@@ -0,0 +1,73 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogMailer is meant for local development. It writes every message to Dir
+// as an .eml file, or to the log when Dir is empty.
+type LogMailer struct {
+	Dir  string
+	From string
+}
+
+func NewLogMailer(dir, from string) *LogMailer {
+	return &LogMailer{Dir: dir, From: from}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	raw := format(m.From, msg)
+
+	if m.Dir == "" {
+		log.Printf("mail to %s:\n%s", msg.To, raw)
+		return nil
+	}
+
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return fmt.Errorf("error creating mail directory: %w", err)
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), strings.NewReplacer("@", "_at_", "/", "_").Replace(msg.To))
+	if err := os.WriteFile(filepath.Join(m.Dir, name), raw, 0o644); err != nil {
+		return fmt.Errorf("error writing mail file: %w", err)
+	}
+	return nil
+}
+
+// RecordingMailer keeps every message in memory so tests can inspect what
+// would have been sent.
+type RecordingMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func (m *RecordingMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of the messages sent so far.
+func (m *RecordingMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
+
+// Last returns the most recently sent message.
+func (m *RecordingMailer) Last() (Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.messages) == 0 {
+		return Message{}, false
+	}
+	return m.messages[len(m.messages)-1], true
+}
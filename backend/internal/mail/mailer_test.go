@@ -0,0 +1,48 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogMailer(t *testing.T) {
+	dir := t.TempDir()
+	mailer := NewLogMailer(dir, "no-reply@example.com")
+
+	err := mailer.Send(context.Background(), Message{
+		To:      "jane@example.com",
+		Subject: "Hello",
+		Body:    "Hello Jane",
+	})
+	assert.NoError(t, err)
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.True(t, strings.HasSuffix(entries[0].Name(), "jane_at_example.com.eml"))
+
+	raw, err := os.ReadFile(dir + "/" + entries[0].Name())
+	assert.NoError(t, err)
+	assert.Contains(t, string(raw), "From: no-reply@example.com\r\n")
+	assert.Contains(t, string(raw), "Subject: Hello\r\n")
+	assert.True(t, strings.HasSuffix(string(raw), "\r\n\r\nHello Jane"))
+}
+
+func TestRecordingMailer(t *testing.T) {
+	mailer := &RecordingMailer{}
+
+	_, ok := mailer.Last()
+	assert.False(t, ok)
+
+	assert.NoError(t, mailer.Send(context.Background(), Message{To: "a@example.com", Subject: "first"}))
+	assert.NoError(t, mailer.Send(context.Background(), Message{To: "b@example.com", Subject: "second"}))
+
+	assert.Len(t, mailer.Messages(), 2)
+	last, ok := mailer.Last()
+	assert.True(t, ok)
+	assert.Equal(t, "second", last.Subject)
+}
@@ -0,0 +1,300 @@
+// Package metadata reads movie metadata dumps in the JSON formats of TMDB
+// (movie details with credits and release_dates appended) and OMDb.
+package metadata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"movie-system/internal/models"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrUnknownFormat = errors.New("neither TMDB nor OMDb format")
+
+// Options controls how dump entries are turned into movies.
+type Options struct {
+	// PosterBaseURL is prepended to TMDB poster paths, which are relative.
+	PosterBaseURL string
+	// Region picks the country whose TMDB certification is used.
+	Region string
+	// CastLimit keeps only the first cast members; 0 keeps them all.
+	CastLimit int
+}
+
+// Parse reads a dump holding one movie, an array of movies or a TMDB list
+// page with a results array. Entries may mix the two formats. Only the
+// fields of the returned movies that the dump has are set; genres and
+// credits carry names and need to be resolved to ids before saving.
+func Parse(data io.Reader, opts Options) ([]models.Movie, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(data).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("error decoding metadata: %w", err)
+	}
+
+	var entries []json.RawMessage
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		var page struct {
+			Results []json.RawMessage `json:"results"`
+		}
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return nil, fmt.Errorf("error decoding metadata: %w", err)
+		}
+		entries = page.Results
+		if entries == nil {
+			entries = []json.RawMessage{raw}
+		}
+	}
+
+	movies := make([]models.Movie, 0, len(entries))
+	for i, entry := range entries {
+		movie, err := parseEntry(entry, opts)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i, err)
+		}
+		movies = append(movies, *movie)
+	}
+	return movies, nil
+}
+
+func parseEntry(entry json.RawMessage, opts Options) (*models.Movie, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(entry, &fields); err != nil {
+		return nil, fmt.Errorf("error decoding entry: %w", err)
+	}
+
+	var movie *models.Movie
+	var err error
+	switch {
+	case fields["imdbID"] != nil || fields["Title"] != nil:
+		movie, err = parseOMDb(entry)
+	case fields["id"] != nil && fields["title"] != nil:
+		movie, err = parseTMDB(entry, opts)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	if movie.Title == "" {
+		return nil, errors.New("title is missing")
+	}
+	if movie.ExternalID == "" {
+		return nil, errors.New("id is missing")
+	}
+	movie.Credits = dedupeCredits(movie.Credits)
+	return movie, nil
+}
+
+type tmdbMovie struct {
+	ID               int    `json:"id"`
+	IMDbID           string `json:"imdb_id"`
+	Title            string `json:"title"`
+	Overview         string `json:"overview"`
+	ReleaseDate      string `json:"release_date"`
+	Runtime          int    `json:"runtime"`
+	OriginalLanguage string `json:"original_language"`
+	PosterPath       string `json:"poster_path"`
+	Genres           []struct {
+		Name string `json:"name"`
+	} `json:"genres"`
+	Credits struct {
+		Cast []struct {
+			Name      string `json:"name"`
+			Character string `json:"character"`
+			Order     int    `json:"order"`
+		} `json:"cast"`
+		Crew []struct {
+			Name string `json:"name"`
+			Job  string `json:"job"`
+		} `json:"crew"`
+	} `json:"credits"`
+	ReleaseDates struct {
+		Results []struct {
+			Country      string `json:"iso_3166_1"`
+			ReleaseDates []struct {
+				Certification string `json:"certification"`
+			} `json:"release_dates"`
+		} `json:"results"`
+	} `json:"release_dates"`
+}
+
+// tmdbCrewRoles maps the crew jobs worth crediting to credit roles.
+var tmdbCrewRoles = map[string]string{
+	"Director":   models.CreditRoleDirector,
+	"Screenplay": models.CreditRoleWriter,
+	"Writer":     models.CreditRoleWriter,
+	"Story":      models.CreditRoleWriter,
+	"Producer":   models.CreditRoleProducer,
+}
+
+// parseTMDB reads a TMDB movie. It is keyed by its IMDb id when it has one,
+// so that it matches the same movie imported from OMDb, and by
+// "tmdb:<id>" otherwise.
+func parseTMDB(entry json.RawMessage, opts Options) (*models.Movie, error) {
+	var tmdb tmdbMovie
+	if err := json.Unmarshal(entry, &tmdb); err != nil {
+		return nil, fmt.Errorf("error decoding TMDB movie: %w", err)
+	}
+
+	movie := &models.Movie{
+		ExternalID:       tmdb.IMDbID,
+		Title:            strings.TrimSpace(tmdb.Title),
+		Description:      strings.TrimSpace(tmdb.Overview),
+		RuntimeMinutes:   tmdb.Runtime,
+		OriginalLanguage: tmdb.OriginalLanguage,
+	}
+	if movie.ExternalID == "" && tmdb.ID != 0 {
+		movie.ExternalID = "tmdb:" + strconv.Itoa(tmdb.ID)
+	}
+	if tmdb.PosterPath != "" {
+		movie.PosterImage = strings.TrimSuffix(opts.PosterBaseURL, "/") + tmdb.PosterPath
+	}
+	if tmdb.ReleaseDate != "" {
+		date, err := time.Parse(time.DateOnly, tmdb.ReleaseDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid release_date %q", tmdb.ReleaseDate)
+		}
+		movie.ReleaseDate = &date
+	}
+	for _, release := range tmdb.ReleaseDates.Results {
+		if release.Country != opts.Region {
+			continue
+		}
+		for _, date := range release.ReleaseDates {
+			if date.Certification != "" {
+				movie.Certification = date.Certification
+				break
+			}
+		}
+	}
+	for _, genre := range tmdb.Genres {
+		movie.Genres = append(movie.Genres, models.Genre{Name: genre.Name})
+	}
+
+	cast := tmdb.Credits.Cast
+	sort.SliceStable(cast, func(i, j int) bool { return cast[i].Order < cast[j].Order })
+	if opts.CastLimit > 0 && len(cast) > opts.CastLimit {
+		cast = cast[:opts.CastLimit]
+	}
+	for _, member := range cast {
+		movie.Credits = append(movie.Credits, models.Credit{Name: member.Name, Role: models.CreditRoleCast, Character: member.Character})
+	}
+	for _, member := range tmdb.Credits.Crew {
+		if role, ok := tmdbCrewRoles[member.Job]; ok {
+			movie.Credits = append(movie.Credits, models.Credit{Name: member.Name, Role: role})
+		}
+	}
+	return movie, nil
+}
+
+type omdbMovie struct {
+	IMDbID   string `json:"imdbID"`
+	Title    string `json:"Title"`
+	Plot     string `json:"Plot"`
+	Released string `json:"Released"`
+	Runtime  string `json:"Runtime"`
+	Rated    string `json:"Rated"`
+	Genre    string `json:"Genre"`
+	Director string `json:"Director"`
+	Writer   string `json:"Writer"`
+	Actors   string `json:"Actors"`
+	Poster   string `json:"Poster"`
+	Response string `json:"Response"`
+	Error    string `json:"Error"`
+}
+
+// omdbNote matches the notes OMDb adds to writers, as in
+// "Jonathan Nolan (screenplay)".
+var omdbNote = regexp.MustCompile(`\s*\([^)]*\)`)
+
+// parseOMDb reads an OMDb movie. OMDb only names the languages of a movie,
+// so its original language is left unset.
+func parseOMDb(entry json.RawMessage) (*models.Movie, error) {
+	var omdb omdbMovie
+	if err := json.Unmarshal(entry, &omdb); err != nil {
+		return nil, fmt.Errorf("error decoding OMDb movie: %w", err)
+	}
+	if omdb.Response == "False" {
+		return nil, fmt.Errorf("OMDb error: %s", omdb.Error)
+	}
+
+	movie := &models.Movie{
+		ExternalID:    omdbValue(omdb.IMDbID),
+		Title:         omdbValue(omdb.Title),
+		Description:   omdbValue(omdb.Plot),
+		PosterImage:   omdbValue(omdb.Poster),
+		Certification: omdbValue(omdb.Rated),
+	}
+	if released := omdbValue(omdb.Released); released != "" {
+		date, err := time.Parse("02 Jan 2006", released)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Released %q", released)
+		}
+		movie.ReleaseDate = &date
+	}
+	if runtime := omdbValue(omdb.Runtime); runtime != "" {
+		minutes, err := strconv.Atoi(strings.TrimSuffix(runtime, " min"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid Runtime %q", runtime)
+		}
+		movie.RuntimeMinutes = minutes
+	}
+	for _, name := range omdbList(omdb.Genre) {
+		movie.Genres = append(movie.Genres, models.Genre{Name: name})
+	}
+	for _, name := range omdbList(omdb.Actors) {
+		movie.Credits = append(movie.Credits, models.Credit{Name: name, Role: models.CreditRoleCast})
+	}
+	for _, name := range omdbList(omdb.Director) {
+		movie.Credits = append(movie.Credits, models.Credit{Name: name, Role: models.CreditRoleDirector})
+	}
+	for _, name := range omdbList(omdbNote.ReplaceAllString(omdb.Writer, "")) {
+		movie.Credits = append(movie.Credits, models.Credit{Name: name, Role: models.CreditRoleWriter})
+	}
+	return movie, nil
+}
+
+// omdbValue treats OMDb's "N/A" as missing.
+func omdbValue(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "N/A" {
+		return ""
+	}
+	return value
+}
+
+func omdbList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(omdbValue(value), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// dedupeCredits keeps the first credit of each person in each role, as a
+// movie can credit someone only once per role, and numbers the credits of
+// each role in order.
+func dedupeCredits(credits []models.Credit) []models.Credit {
+	seen := make(map[[2]string]bool)
+	positions := make(map[string]int)
+	var deduped []models.Credit
+	for _, credit := range credits {
+		key := [2]string{credit.Name, credit.Role}
+		if credit.Name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		credit.Position = positions[credit.Role]
+		positions[credit.Role]++
+		deduped = append(deduped, credit)
+	}
+	return deduped
+}
@@ -0,0 +1,88 @@
+package metadata
+
+import (
+	"movie-system/internal/models"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func parseFile(t *testing.T, name string, opts Options) []models.Movie {
+	file, err := os.Open("testdata/" + name)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer file.Close()
+
+	movies, err := Parse(file, opts)
+	assert.NoError(t, err)
+	return movies
+}
+
+func TestParseTMDB(t *testing.T) {
+	movies := parseFile(t, "tmdb.json", Options{PosterBaseURL: "https://image.tmdb.org/t/p/w500/", Region: "US", CastLimit: 2})
+	if !assert.Len(t, movies, 2) {
+		return
+	}
+
+	inception := movies[0]
+	assert.Equal(t, "tt1375666", inception.ExternalID)
+	assert.Equal(t, "Inception", inception.Title)
+	assert.Equal(t, time.Date(2010, 7, 15, 0, 0, 0, 0, time.UTC), *inception.ReleaseDate)
+	assert.Equal(t, 148, inception.RuntimeMinutes)
+	assert.Equal(t, "en", inception.OriginalLanguage)
+	assert.Equal(t, "PG-13", inception.Certification)
+	assert.Equal(t, "https://image.tmdb.org/t/p/w500/oYuLEt3zVCKq57qu2F8dT7NIa6f.jpg", inception.PosterImage)
+	assert.Equal(t, []models.Genre{{Name: "Action"}, {Name: "Science Fiction"}}, inception.Genres)
+	assert.Equal(t, []models.Credit{
+		{Name: "Leonardo DiCaprio", Role: models.CreditRoleCast, Character: "Cobb", Position: 0},
+		{Name: "Joseph Gordon-Levitt", Role: models.CreditRoleCast, Character: "Arthur", Position: 1},
+		{Name: "Christopher Nolan", Role: models.CreditRoleDirector, Position: 0},
+		{Name: "Christopher Nolan", Role: models.CreditRoleWriter, Position: 0},
+		{Name: "Emma Thomas", Role: models.CreditRoleProducer, Position: 0},
+	}, inception.Credits, "cast is ordered and limited, crew deduplicated by role")
+
+	empire := movies[1]
+	assert.Equal(t, "tmdb:1891", empire.ExternalID, "movies without an IMDb id are keyed by their TMDB id")
+	assert.Nil(t, empire.ReleaseDate)
+	assert.Empty(t, empire.PosterImage)
+	assert.Empty(t, empire.Credits)
+}
+
+func TestParseOMDb(t *testing.T) {
+	movies := parseFile(t, "omdb.json", Options{})
+	if !assert.Len(t, movies, 2) {
+		return
+	}
+
+	matrix := movies[0]
+	assert.Equal(t, "tt0133093", matrix.ExternalID)
+	assert.Equal(t, time.Date(1999, 3, 31, 0, 0, 0, 0, time.UTC), *matrix.ReleaseDate)
+	assert.Equal(t, 136, matrix.RuntimeMinutes)
+	assert.Equal(t, "R", matrix.Certification)
+	assert.Equal(t, "https://m.media-amazon.com/images/M/matrix.jpg", matrix.PosterImage)
+	assert.Equal(t, []models.Genre{{Name: "Action"}, {Name: "Sci-Fi"}}, matrix.Genres)
+	assert.Equal(t, models.Credit{Name: "Carrie-Anne Moss", Role: models.CreditRoleCast, Position: 2}, matrix.Credits[2])
+	assert.Equal(t, models.Credit{Name: "Lana Wachowski", Role: models.CreditRoleWriter, Position: 1}, matrix.Credits[6])
+
+	short := movies[1]
+	assert.Equal(t, models.Movie{ExternalID: "tt0000001", Title: "Short Film"}, short, "N/A counts as missing")
+}
+
+func TestParseShapes(t *testing.T) {
+	movies, err := Parse(strings.NewReader(`{"id": 1, "title": "Solo"}`), Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Movie{{ExternalID: "tmdb:1", Title: "Solo"}}, movies)
+
+	_, err = Parse(strings.NewReader(`[{"name": "Not a movie"}]`), Options{})
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+
+	_, err = Parse(strings.NewReader(`{"Response": "False", "Error": "Movie not found!", "Title": ""}`), Options{})
+	assert.ErrorContains(t, err, "Movie not found!")
+
+	_, err = Parse(strings.NewReader(`{"id": 2, "title": "Bad", "release_date": "soon"}`), Options{})
+	assert.ErrorContains(t, err, "entry 0")
+}
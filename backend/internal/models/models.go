@@ -1,22 +1,306 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Admins run the cinema they are assigned to, User.CinemaID, while head
+// office runs every cinema.
+const (
+	RoleUser       = "user"
+	RoleAdmin      = "admin"
+	RoleHeadOffice = "head_office"
+)
+
+var roleRanks = map[string]int{RoleUser: 1, RoleAdmin: 2, RoleHeadOffice: 3}
+
+// IsValidRole reports whether role is one of the roles understood by the
+// authorization middleware.
+func IsValidRole(role string) bool {
+	return roleRanks[role] > 0
+}
+
+// RoleAllows reports whether role may do what required may: head office may
+// do whatever admins may, and admins whatever users may.
+func RoleAllows(role, required string) bool {
+	return IsValidRole(required) && roleRanks[role] >= roleRanks[required]
+}
 
 type User struct {
-	ID           uint      `json:"id"`
-	Username     string    `json:"username"`
-	PasswordHash string    `json:"password_hash"`
-	Role         string    `json:"role"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID                uint       `json:"id"`
+	Username          string     `json:"username"`
+	Email             string     `json:"email"`
+	EmailVerified     bool       `json:"email_verified"`
+	PasswordHash      string     `json:"-"`
+	Role              string     `json:"role"`
+	CinemaID          uint       `json:"cinema_id,omitempty"`
+	Disabled          bool       `json:"disabled"`
+	TOTPEnabled       bool       `json:"totp_enabled"`
+	DisplayName       string     `json:"display_name"`
+	Phone             string     `json:"phone"`
+	PreferredLanguage string     `json:"preferred_language"`
+	MarketingConsent  bool       `json:"marketing_consent"`
+	ErasedAt          *time.Time `json:"erased_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// ProfileUpdate holds the profile fields a user may change about themselves.
+// Fields left nil are not changed.
+type ProfileUpdate struct {
+	DisplayName       *string `json:"display_name"`
+	Email             *string `json:"email"`
+	Phone             *string `json:"phone"`
+	PreferredLanguage *string `json:"preferred_language"`
+	MarketingConsent  *bool   `json:"marketing_consent"`
+}
+
+const (
+	ScopeMoviesRead    = "movies:read"
+	ScopeShowtimesRead = "showtimes:read"
+)
+
+// IsValidScope reports whether scope can be granted to an API key.
+func IsValidScope(scope string) bool {
+	return scope == ScopeMoviesRead || scope == ScopeShowtimesRead
+}
+
+type APIKey struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	RateLimit  int        `json:"rate_limit"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type LoginAttempt struct {
+	ID        uint      `json:"id"`
+	Username  string    `json:"username"`
+	IP        string    `json:"ip"`
+	Success   bool      `json:"success"`
+	Result    string    `json:"result"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type Movie struct {
-	ID          uint   `json:"id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Genre       string `json:"genre"`
-	PosterImage string `json:"poster_image"`
+	ID uint `json:"id"`
+	// ExternalID identifies the movie in the programming team's own
+	// spreadsheets and keys bulk imports.
+	ExternalID       string     `json:"external_id,omitempty"`
+	Title            string     `json:"title"`
+	Description      string     `json:"description"`
+	PosterImage      string     `json:"poster_image"`
+	ReleaseDate      *time.Time `json:"release_date"`
+	RuntimeMinutes   int        `json:"runtime_minutes"`
+	Certification    string     `json:"certification"`
+	OriginalLanguage string     `json:"original_language"`
+	Subtitles        []string   `json:"subtitles"`
+	// Locale is the language the title and description are in, when
+	// responding to a request negotiated with Accept-Language.
+	Locale string `json:"locale,omitempty"`
+	// LockedFields names the MetadataFields an editor has taken over; a
+	// metadata import never overwrites them. Nil in an update request keeps
+	// the current ones.
+	LockedFields []string `json:"locked_fields"`
+	// ArchivedAt is set once an admin deleted the movie. Archived movies are
+	// hidden from listings and cannot be booked but stay in reports.
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	// AverageRating and ReviewCount summarize the reviews that are not
+	// hidden. They are never read from a request.
+	AverageRating float64 `json:"average_rating"`
+	ReviewCount   int     `json:"review_count"`
+	// Only the ids of the genres are read from a request. Genres and Credits
+	// are nil in an update request to keep the current ones.
+	Genres  []Genre  `json:"genres"`
+	Credits []Credit `json:"credits"`
+}
+
+const (
+	MediaKindPoster   = "poster"
+	MediaKindBackdrop = "backdrop"
+	MediaKindStill    = "still"
+)
+
+// IsValidMediaKind reports whether kind is a known kind of movie image.
+func IsValidMediaKind(kind string) bool {
+	switch kind {
+	case MediaKindPoster, MediaKindBackdrop, MediaKindStill:
+		return true
+	}
+	return false
+}
+
+// Media is an image uploaded for a movie. The original and its thumbnails
+// are stored under KeyPrefix in the blob store; URLs maps each size to the
+// address it is served from.
+type Media struct {
+	ID          uint              `json:"id"`
+	MovieID     uint              `json:"movie_id"`
+	Kind        string            `json:"kind"`
+	ContentType string            `json:"content_type"`
+	Width       int               `json:"width"`
+	Height      int               `json:"height"`
+	SizeBytes   int64             `json:"size_bytes"`
+	KeyPrefix   string            `json:"-"`
+	CreatedAt   time.Time         `json:"created_at"`
+	URLs        map[string]string `json:"urls"`
+}
+
+// Genre is an entry of the genre taxonomy. Slug is derived from the name and
+// identifies the genre in filters.
+type Genre struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+// Slugify lowercases name and joins its runs of letters and digits with
+// dashes, so that "Sci-Fi" and "sci fi" both become "sci-fi".
+func Slugify(name string) string {
+	var slug strings.Builder
+	separate := false
+	for _, r := range strings.ToLower(name) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			separate = true
+			continue
+		}
+		if separate && slug.Len() > 0 {
+			slug.WriteByte('-')
+		}
+		slug.WriteRune(r)
+		separate = false
+	}
+	return slug.String()
+}
+
+// MovieTranslation is the title and description of a movie in another
+// locale. An empty description falls back to the default one.
+type MovieTranslation struct {
+	Locale      string    `json:"locale"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type GenreTranslation struct {
+	Locale    string    `json:"locale"`
+	Name      string    `json:"name"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Person struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const (
+	CreditRoleCast     = "cast"
+	CreditRoleDirector = "director"
+	CreditRoleWriter   = "writer"
+	CreditRoleProducer = "producer"
+)
+
+// IsValidCreditRole reports whether role is a known kind of movie credit.
+func IsValidCreditRole(role string) bool {
+	switch role {
+	case CreditRoleCast, CreditRoleDirector, CreditRoleWriter, CreditRoleProducer:
+		return true
+	}
+	return false
+}
+
+// Credit links a person to a movie. Character is only used for cast credits;
+// Position orders the credits of the same role.
+type Credit struct {
+	PersonID  uint   `json:"person_id"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	Character string `json:"character,omitempty"`
+	Position  int    `json:"position"`
+}
+
+// Validate checks the fields of a movie sent by an admin.
+func (movie *Movie) Validate() error {
+	if strings.TrimSpace(movie.Title) == "" {
+		return errors.New("title is required")
+	}
+	if movie.RuntimeMinutes < 0 {
+		return errors.New("runtime_minutes must not be negative")
+	}
+	for i, genre := range movie.Genres {
+		if genre.ID == 0 {
+			return fmt.Errorf("genre %d: id is required", i)
+		}
+	}
+	seen := make(map[Credit]bool)
+	for i, credit := range movie.Credits {
+		key := Credit{PersonID: credit.PersonID, Role: credit.Role}
+		if seen[key] {
+			return fmt.Errorf("credit %d: person %d is already credited as %s", i, credit.PersonID, credit.Role)
+		}
+		seen[key] = true
+		if credit.PersonID == 0 {
+			return fmt.Errorf("credit %d: person_id is required", i)
+		}
+		if !IsValidCreditRole(credit.Role) {
+			return fmt.Errorf("credit %d: invalid role %q", i, credit.Role)
+		}
+	}
+	for _, field := range movie.LockedFields {
+		if !slices.Contains(MetadataFields, field) {
+			return fmt.Errorf("locked_fields: %q is not a metadata field", field)
+		}
+	}
+	return nil
+}
+
+// MetadataFields are the fields of a movie filled in by metadata imports,
+// and so the ones that can be locked.
+var MetadataFields = []string{"title", "description", "poster_image", "release_date", "runtime_minutes",
+	"certification", "original_language", "genres", "credits"}
+
+// MovieSearchResult is a movie found by a search together with its relevance
+// and a snippet of the description with the matches highlighted.
+type MovieSearchResult struct {
+	Movie
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+const (
+	MovieStatusNowShowing = "now_showing"
+	MovieStatusComingSoon = "coming_soon"
+)
+
+const (
+	MovieSortTitle       = "title"
+	MovieSortReleaseDate = "release_date"
+	MovieSortPopularity  = "popularity"
+)
+
+// MovieFilter narrows and orders a movie listing. Empty fields do not filter.
+// Genres holds ids or slugs; a movie matches if it has any of them. Sort is
+// one of the MovieSort constants, prefixed with "-" for descending. Archived
+// lists archived movies instead of the active ones.
+type MovieFilter struct {
+	Archived      bool
+	Genres        []string
+	Certification string
+	Language      string
+	Status        string
+	Sort          string
 }
 
 type Reservation struct {
@@ -26,14 +310,333 @@ type Reservation struct {
 	ShowtimeID uint      `json:"showtime_id"`
 	CreatedAt  time.Time `json:"created_at"`
 	Seats      []string  `json:"seats"`
+	// SeatPrice is what each seat cost in the cinema when it was booked.
+	SeatPrice int `json:"seat_price"`
+}
+
+const (
+	ReviewStatusPublished = "published"
+	// Reported reviews stay visible until a moderator hides or approves them.
+	ReviewStatusReported = "reported"
+	ReviewStatusHidden   = "hidden"
+)
+
+// IsValidReviewStatus reports whether status is a known review status.
+func IsValidReviewStatus(status string) bool {
+	switch status {
+	case ReviewStatusPublished, ReviewStatusReported, ReviewStatusHidden:
+		return true
+	}
+	return false
+}
+
+// Review is a star rating, with optional text, of a movie by a customer who
+// saw it. Author is the display name, or the username, of the customer.
+type Review struct {
+	ID          uint           `json:"id"`
+	MovieID     uint           `json:"movie_id"`
+	UserID      uint           `json:"user_id"`
+	Author      string         `json:"author"`
+	Rating      int            `json:"rating"`
+	Body        string         `json:"body"`
+	Status      string         `json:"status"`
+	ReportCount int            `json:"report_count"`
+	Reports     []ReviewReport `json:"reports,omitempty"`
+	ModeratedBy string         `json:"moderated_by,omitempty"`
+	ModeratedAt *time.Time     `json:"moderated_at,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+type ReviewReport struct {
+	UserID    uint      `json:"user_id"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const (
+	RecommendationReasonSimilar = "similar"
+	RecommendationReasonGenre   = "genre"
+	RecommendationReasonPopular = "popular"
+)
+
+// Recommendation suggests a movie with upcoming showtimes to a user. Reason
+// names the signal that weighed most; SimilarTo is the title of the booked
+// movie whose audience also booked this one when Reason is "similar".
+type Recommendation struct {
+	Movie     Movie      `json:"movie"`
+	Score     float64    `json:"score"`
+	Reason    string     `json:"reason"`
+	SimilarTo string     `json:"similar_to,omitempty"`
+	Showtimes []Showtime `json:"showtimes"`
 }
 
 type Showtime struct {
-	ID        uint      `json:"id"`
-	MovieID   uint      `json:"movie_id"`
+	ID         uint   `json:"id"`
+	ExternalID string `json:"external_id,omitempty"`
+	MovieID    uint   `json:"movie_id"`
+	// MovieExternalID may name the movie instead of MovieID in bulk imports.
+	MovieExternalID string `json:"movie_external_id,omitempty"`
+	// ScheduleID is set for the occurrences of a recurring schedule.
+	ScheduleID uint `json:"schedule_id,omitempty"`
+	// CinemaID is where the showtime is on. It defaults to the cinema of
+	// the auditorium.
+	CinemaID uint `json:"cinema_id"`
+	// AuditoriumID is the screen the showtime is in. Showtimes without one
+	// are not checked for overlaps.
+	AuditoriumID uint `json:"auditorium_id,omitempty"`
+	// StartTime is shown in the local time of the cinema, whose time zone
+	// is TimeZone.
 	StartTime time.Time `json:"start_time"`
-	Capacity  uint      `json:"capacity"`
-	Reserved  uint      `json:"reserved"`
+	TimeZone  string    `json:"timezone,omitempty"`
+	// EndsAt is when the auditorium is free again, after the ads, the movie
+	// and the cleaning. It is computed whenever the showtime is saved.
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+	Capacity uint       `json:"capacity"`
+	Reserved uint       `json:"reserved"`
+	// Available is the number of free seats, counted from the reservations
+	// rather than from Reserved. Exports leave it out.
+	Available *uint `json:"available,omitempty"`
+	// ArchivedAt is set once an admin deleted the showtime.
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+}
+
+// MovieShowtimes are the upcoming showtimes of a movie by day.
+type MovieShowtimes struct {
+	MovieID uint          `json:"movie_id"`
+	Days    []ShowtimeDay `json:"days"`
+}
+
+// ShowtimeDay holds the showtimes on a date, in the local time of their
+// cinemas.
+type ShowtimeDay struct {
+	Date      string     `json:"date"`
+	Showtimes []Showtime `json:"showtimes"`
+}
+
+// Schedule programs a movie at the same times of day, "HH:MM", on every
+// date its recurrence rule yields. StartDate is the DTSTART of the rule and
+// ExDates are dates left out, both "YYYY-MM-DD".
+type Schedule struct {
+	ID           uint       `json:"id"`
+	MovieID      uint       `json:"movie_id"`
+	CinemaID     uint       `json:"cinema_id"`
+	AuditoriumID uint       `json:"auditorium_id,omitempty"`
+	Capacity     uint       `json:"capacity"`
+	StartDate    string     `json:"start_date"`
+	Times        []string   `json:"times"`
+	RRule        string     `json:"rrule"`
+	ExDates      []string   `json:"exdates"`
+	CancelledAt  *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	// Showtimes are the occurrences, listed with a single schedule.
+	Showtimes []Showtime `json:"showtimes,omitempty"`
+}
+
+const (
+	ScheduleActionCreate = "create"
+	ScheduleActionUpdate = "update"
+	ScheduleActionKeep   = "keep"
+	ScheduleActionDelete = "delete"
+	ScheduleActionFail   = "fail"
+)
+
+// Reasons for keeping an occurrence a schedule no longer yields, or that is
+// left as it is although the schedule changed.
+const (
+	ScheduleKeepStarted  = "started"
+	ScheduleKeepBooked   = "booked"
+	ScheduleKeepArchived = "archived"
+)
+
+// ScheduleOccurrence is what saving a schedule does to one of its showtimes.
+type ScheduleOccurrence struct {
+	StartTime  time.Time `json:"start_time"`
+	ShowtimeID uint      `json:"showtime_id,omitempty"`
+	Action     string    `json:"action"`
+	Reason     string    `json:"reason,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// ScheduleReport is the outcome of saving or cancelling a schedule. Nothing
+// is saved on a dry run or if any occurrence fails.
+type ScheduleReport struct {
+	Schedule    Schedule             `json:"schedule"`
+	DryRun      bool                 `json:"dry_run"`
+	Committed   bool                 `json:"committed"`
+	Created     int                  `json:"created"`
+	Updated     int                  `json:"updated"`
+	Kept        int                  `json:"kept"`
+	Deleted     int                  `json:"deleted"`
+	Failed      int                  `json:"failed"`
+	Occurrences []ScheduleOccurrence `json:"occurrences"`
+}
+
+// Cinema is a site with its own auditoriums, showtimes and admins. Its
+// showtimes are shown and scheduled in its time zone, an IANA name such as
+// "Europe/Berlin", and each seat booked in it costs SeatPrice.
+type Cinema struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Address   string    `json:"address"`
+	TimeZone  string    `json:"timezone"`
+	SeatPrice int       `json:"seat_price"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Auditorium is a screen showtimes are scheduled in. Ads run for AdMinutes
+// from the start time of a showtime before the movie begins, and the
+// auditorium is cleaned for CleaningMinutes after it ends.
+type Auditorium struct {
+	ID              uint      `json:"id"`
+	CinemaID        uint      `json:"cinema_id"`
+	Name            string    `json:"name"`
+	AdMinutes       int       `json:"ad_minutes"`
+	CleaningMinutes int       `json:"cleaning_minutes"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// ShowtimeFilter narrows a showtime listing. Archived lists archived
+// showtimes instead of the bookable ones that have not started. Zero values
+// do not filter.
+type ShowtimeFilter struct {
+	Archived     bool
+	MovieID      int
+	CinemaID     int
+	AuditoriumID int
+	// From and To are the first and last day, in the local time of the
+	// cinema.
+	From *time.Time
+	To   *time.Time
+	// Days limits the listing to that many days from today, in the local
+	// time of the cinema.
+	Days int
+	// MinAvailable is the number of seats that must still be free.
+	MinAvailable int
+}
+
+const (
+	AuditEntityMovie    = "movie"
+	AuditEntityShowtime = "showtime"
+	AuditEntityGenre    = "genre"
+	AuditEntityPerson   = "person"
+	AuditEntityMedia    = "media"
+	// Cinema, auditorium and schedule changes are logged but cannot be
+	// reverted.
+	AuditEntityCinema     = "cinema"
+	AuditEntityAuditorium = "auditorium"
+	AuditEntitySchedule   = "schedule"
+	// Translations are logged under the id of their movie or genre.
+	AuditEntityMovieTranslation = "movie_translation"
+	AuditEntityGenreTranslation = "genre_translation"
+)
+
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionArchive = "archive"
+	AuditActionRestore = "restore"
+	AuditActionDelete  = "delete"
+	AuditActionRevert  = "revert"
+)
+
+// AuditEntry records one admin change to the catalogue. Before and After are
+// JSON snapshots of the entity; Before is empty for creations and After for
+// deletions. Changes lists the top-level fields that differ between them.
+type AuditEntry struct {
+	ID         uint                   `json:"id"`
+	Actor      string                 `json:"actor"`
+	Action     string                 `json:"action"`
+	EntityType string                 `json:"entity_type"`
+	EntityID   int                    `json:"entity_id"`
+	Before     json.RawMessage        `json:"before,omitempty"`
+	After      json.RawMessage        `json:"after,omitempty"`
+	Changes    map[string]AuditChange `json:"changes"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+type AuditChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+const (
+	ImportFormatJSON = "json"
+	ImportFormatCSV  = "csv"
+)
+
+const (
+	ImportActionCreate = "create"
+	ImportActionUpdate = "update"
+	ImportActionFail   = "fail"
+	// ImportActionUnchanged is only used by metadata imports.
+	ImportActionUnchanged = "unchanged"
+)
+
+// ImportOptions controls a bulk import. A dry run reports what would happen
+// without saving anything; an atomic import saves nothing unless every row
+// is valid.
+type ImportOptions struct {
+	DryRun bool
+	Atomic bool
+}
+
+// ImportResult is the outcome of one row of a bulk import. Rows are numbered
+// from 1, not counting a CSV header.
+type ImportResult struct {
+	Row        int      `json:"row"`
+	ExternalID string   `json:"external_id"`
+	Action     string   `json:"action"`
+	ID         uint     `json:"id,omitempty"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// MetadataConflict is a locked field whose imported value differs from the
+// one kept.
+type MetadataConflict struct {
+	Field    string `json:"field"`
+	Current  string `json:"current"`
+	Imported string `json:"imported"`
+}
+
+// MetadataResult is the outcome of importing the metadata of one movie.
+type MetadataResult struct {
+	ExternalID string             `json:"external_id"`
+	Title      string             `json:"title"`
+	Action     string             `json:"action"`
+	ID         uint               `json:"id,omitempty"`
+	Conflicts  []MetadataConflict `json:"conflicts,omitempty"`
+	Error      string             `json:"error,omitempty"`
+}
+
+type MetadataReport struct {
+	Created   int              `json:"created"`
+	Updated   int              `json:"updated"`
+	Unchanged int              `json:"unchanged"`
+	Failed    int              `json:"failed"`
+	Conflicts int              `json:"conflicts"`
+	Movies    []MetadataResult `json:"movies"`
+}
+
+type ImportReport struct {
+	DryRun    bool           `json:"dry_run"`
+	Atomic    bool           `json:"atomic"`
+	Committed bool           `json:"committed"`
+	Created   int            `json:"created"`
+	Updated   int            `json:"updated"`
+	Failed    int            `json:"failed"`
+	Rows      []ImportResult `json:"rows"`
+}
+
+// AuditFilter narrows the audit log. Empty fields do not filter; From and To
+// bound the time of the change, inclusive and exclusive respectively.
+type AuditFilter struct {
+	EntityType string
+	EntityID   int
+	Actor      string
+	From       *time.Time
+	To         *time.Time
 }
 
 type MovieReservationCount struct {
@@ -42,3 +645,35 @@ type MovieReservationCount struct {
 	ReservationCount int    `json:"reservation_count"`
 	SeatCount        int    `json:"seat_count"`
 }
+
+// UserDataExport is everything stored about a user, as handed out for a data
+// subject access request.
+type UserDataExport struct {
+	ExportedAt       time.Time              `json:"exported_at"`
+	Profile          *User                  `json:"profile"`
+	Reservations     []ReservationExport    `json:"reservations"`
+	LinkedIdentities []LinkedIdentityExport `json:"linked_identities"`
+	LoginHistory     []LoginAttempt         `json:"login_history"`
+	Reviews          []Review               `json:"reviews"`
+	// AuditEntries are the catalogue changes the user made as an admin.
+	AuditEntries []AuditEntry `json:"audit_entries"`
+}
+
+// ReservationExport is a reservation with what was paid for it: SeatPrice
+// for each seat, Total in all.
+type ReservationExport struct {
+	ID            uint      `json:"id"`
+	MovieTitle    string    `json:"movie_title"`
+	ShowtimeStart time.Time `json:"showtime_start"`
+	Seats         []string  `json:"seats"`
+	SeatPrice     int       `json:"seat_price"`
+	Total         int       `json:"total"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type LinkedIdentityExport struct {
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
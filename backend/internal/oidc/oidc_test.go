@@ -0,0 +1,121 @@
+package oidc
+
+import (
+	"context"
+	"movie-system/test"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+const redirectURL = "http://localhost:8080/auth/oidc/mock/callback"
+
+// authorize follows the authorization URL like a browser would and returns
+// the code and state the provider redirected back with.
+func authorize(t *testing.T, authURL string) (string, string) {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("Failed to call authorize endpoint: %v", err)
+	}
+	defer resp.Body.Close()
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("Failed to parse redirect: %v", err)
+	}
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestClient(t *testing.T) {
+	provider, err := test.NewMockOIDCProvider("movie-system", "client-secret")
+	if err != nil {
+		t.Fatalf("Failed to start mock provider: %v", err)
+	}
+	defer provider.Close()
+
+	client := NewClient(Config{
+		Name:         "mock",
+		IssuerURL:    provider.Issuer(),
+		ClientID:     "movie-system",
+		ClientSecret: "client-secret",
+		RedirectURL:  redirectURL,
+	})
+	ctx := context.Background()
+
+	t.Run("CodeFlowWithPKCE", func(t *testing.T) {
+		provider.SetUser(test.MockOIDCUser{Subject: "user-1", Email: "jane@example.com", EmailVerified: true, Name: "Jane"})
+
+		verifier, _ := RandomString()
+		authURL, err := client.AuthCodeURL(ctx, "state-1", "nonce-1", S256Challenge(verifier))
+		assert.NoError(t, err)
+
+		code, state := authorize(t, authURL)
+		assert.Equal(t, "state-1", state)
+
+		rawIDToken, err := client.Exchange(ctx, code, verifier)
+		assert.NoError(t, err)
+
+		idToken, err := client.VerifyIDToken(ctx, rawIDToken, "nonce-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "user-1", idToken.Subject)
+		assert.Equal(t, "jane@example.com", idToken.Email)
+		assert.True(t, idToken.EmailVerified)
+	})
+
+	t.Run("WrongVerifierIsRejected", func(t *testing.T) {
+		verifier, _ := RandomString()
+		authURL, err := client.AuthCodeURL(ctx, "state-2", "nonce-2", S256Challenge(verifier))
+		assert.NoError(t, err)
+
+		code, _ := authorize(t, authURL)
+		_, err = client.Exchange(ctx, code, "not-the-verifier")
+		assert.Error(t, err)
+	})
+
+	t.Run("InvalidIDTokens", func(t *testing.T) {
+		valid := jwt.MapClaims{
+			"iss":   provider.Issuer(),
+			"aud":   "movie-system",
+			"sub":   "user-1",
+			"nonce": "nonce-3",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		}
+		with := func(key string, value interface{}) jwt.MapClaims {
+			claims := jwt.MapClaims{}
+			for k, v := range valid {
+				claims[k] = v
+			}
+			claims[key] = value
+			return claims
+		}
+
+		token, _ := provider.SignIDToken(valid)
+		_, err := client.VerifyIDToken(ctx, token, "nonce-3")
+		assert.NoError(t, err)
+
+		_, err = client.VerifyIDToken(ctx, token, "other-nonce")
+		assert.Error(t, err)
+
+		for name, claims := range map[string]jwt.MapClaims{
+			"issuer":   with("iss", "https://evil.example.com"),
+			"audience": with("aud", "someone-else"),
+			"expired":  with("exp", time.Now().Add(-time.Minute).Unix()),
+		} {
+			token, _ := provider.SignIDToken(claims)
+			_, err := client.VerifyIDToken(ctx, token, "nonce-3")
+			assert.Error(t, err, name)
+		}
+
+		token, _ = provider.SignIDToken(with("aud", []interface{}{"other", "movie-system"}))
+		_, err = client.VerifyIDToken(ctx, token, "nonce-3")
+		assert.NoError(t, err, "audience may be a list")
+	})
+}
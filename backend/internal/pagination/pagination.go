@@ -0,0 +1,106 @@
+// Package pagination implements the keyset pagination shared by all list
+// endpoints. Clients pass limit and cursor query parameters and get a Page
+// back; next_cursor is opaque and only valid for the same sort order.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var (
+	ErrInvalidLimit  = errors.New("limit must be between 1 and 100")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// Params is the requested page: at most Limit items after Cursor.
+type Params struct {
+	Limit  int
+	Cursor string
+}
+
+// Page is the response envelope of every list endpoint. NextCursor is nil on
+// the last page; Total counts all items matching the filters.
+type Page[T any] struct {
+	Items      []T     `json:"items"`
+	NextCursor *string `json:"next_cursor"`
+	Total      int     `json:"total"`
+}
+
+// ParseParams reads limit and cursor from query.
+func ParseParams(query url.Values) (Params, error) {
+	params := Params{Limit: DefaultLimit, Cursor: query.Get("cursor")}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return params, ErrInvalidLimit
+		}
+		params.Limit = limit
+	}
+	return params, nil
+}
+
+// EncodeCursor turns the sort key of the last item on a page into a cursor.
+func EncodeCursor(key any) string {
+	data, _ := json.Marshal(key)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor reads a cursor made by EncodeCursor into key. An empty cursor
+// leaves key untouched.
+func DecodeCursor(cursor string, key any) error {
+	if cursor == "" {
+		return nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, key); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// NewPage builds a page from items fetched with a limit of params.Limit+1.
+// The extra item only signals that there is a next page and is dropped;
+// cursorOf returns the cursor pointing after a given item.
+func NewPage[T any](items []T, params Params, total int, cursorOf func(T) string) *Page[T] {
+	page := &Page[T]{Items: items, Total: total}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	if len(page.Items) > params.Limit {
+		page.Items = page.Items[:params.Limit]
+		next := cursorOf(page.Items[len(page.Items)-1])
+		page.NextCursor = &next
+	}
+	return page
+}
+
+// IDCursor is the cursor of lists ordered by id alone.
+type IDCursor struct {
+	ID int `json:"id"`
+}
+
+// AfterID returns the id encoded in params.Cursor, or 0 for the first page.
+func AfterID(params Params) (int, error) {
+	var cursor IDCursor
+	if err := DecodeCursor(params.Cursor, &cursor); err != nil {
+		return 0, err
+	}
+	return cursor.ID, nil
+}
+
+// IDCursorOf returns the cursor pointing after the item with the given id.
+func IDCursorOf(id uint) string {
+	return EncodeCursor(IDCursor{ID: int(id)})
+}
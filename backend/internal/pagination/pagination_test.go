@@ -0,0 +1,56 @@
+package pagination
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseParams(t *testing.T) {
+	params, err := ParseParams(url.Values{})
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultLimit, params.Limit)
+
+	params, err = ParseParams(url.Values{"limit": {"5"}, "cursor": {"abc"}})
+	assert.NoError(t, err)
+	assert.Equal(t, Params{Limit: 5, Cursor: "abc"}, params)
+
+	for _, limit := range []string{"0", "101", "ten"} {
+		_, err := ParseParams(url.Values{"limit": {limit}})
+		assert.ErrorIs(t, err, ErrInvalidLimit, limit)
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := IDCursorOf(42)
+	id, err := AfterID(Params{Cursor: cursor})
+	assert.NoError(t, err)
+	assert.Equal(t, 42, id)
+
+	id, err = AfterID(Params{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, id)
+
+	_, err = AfterID(Params{Cursor: "not a cursor!"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestNewPage(t *testing.T) {
+	cursorOf := func(n int) string { return IDCursorOf(uint(n)) }
+
+	page := NewPage([]int{1, 2, 3}, Params{Limit: 2}, 7, cursorOf)
+	assert.Equal(t, []int{1, 2}, page.Items)
+	assert.Equal(t, 7, page.Total)
+	if assert.NotNil(t, page.NextCursor) {
+		assert.Equal(t, IDCursorOf(2), *page.NextCursor)
+	}
+
+	page = NewPage([]int{1, 2}, Params{Limit: 2}, 2, cursorOf)
+	assert.Len(t, page.Items, 2)
+	assert.Nil(t, page.NextCursor)
+
+	page = NewPage[int](nil, Params{Limit: 2}, 0, cursorOf)
+	assert.NotNil(t, page.Items)
+	assert.Empty(t, page.Items)
+}
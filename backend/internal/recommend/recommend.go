@@ -0,0 +1,206 @@
+// Package recommend computes movie recommendations from booking history. It
+// blends three signals: how much the genres of a movie are the ones a user
+// books ("genre affinity"), how often its audience also booked the movies the user
+// did ("people who saw X also saw Y") and how many seats it sold lately.
+package recommend
+
+import (
+	"math"
+	"movie-system/internal/models"
+	"sort"
+)
+
+// Weights of the signals in the score of a recommendation, which is between
+// 0 and 1. Co-booking is the strongest signal once there is enough history.
+const (
+	SimilarityWeight = 0.5
+	AffinityWeight   = 0.35
+	PopularityWeight = 0.15
+)
+
+// Input is the booking history a model is computed from.
+type Input struct {
+	// Bookings holds the distinct movies each user has booked, by user id.
+	Bookings map[int][]int
+	// Genres holds the genres of each movie, by movie id.
+	Genres map[int][]int
+	// Popularity holds the seats sold lately for each movie, by movie id.
+	Popularity map[int]float64
+	// Candidates are the movies that may be recommended, usually the ones
+	// with upcoming showtimes.
+	Candidates []int
+}
+
+// Suggestion is a scored candidate movie. Reason is the
+// models.RecommendationReason of the strongest signal; SimilarTo is the
+// booked movie that led to a suggestion for similarity.
+type Suggestion struct {
+	MovieID   int
+	Score     float64
+	Reason    string
+	SimilarTo int
+}
+
+// Model holds the best suggestions for each user who booked anything, by user
+// id, and the most popular candidates for everyone else.
+type Model struct {
+	Users   map[int][]Suggestion
+	Popular []Suggestion
+}
+
+// Compute scores every candidate the user has not booked yet for every user
+// in the input and keeps the best limit of them, best first.
+func Compute(in Input, limit int) *Model {
+	viewers := make(map[int][]int)
+	for user, booked := range in.Bookings {
+		for _, movie := range booked {
+			viewers[movie] = append(viewers[movie], user)
+		}
+	}
+
+	maxPopularity := 0.0
+	for _, candidate := range in.Candidates {
+		maxPopularity = math.Max(maxPopularity, in.Popularity[candidate])
+	}
+	popularity := func(movie int) float64 {
+		if maxPopularity == 0 {
+			return 0
+		}
+		return in.Popularity[movie] / maxPopularity
+	}
+
+	// coBookings[c][m] counts the users who booked both candidate c and
+	// movie m
+	coBookings := make(map[int]map[int]int, len(in.Candidates))
+	for _, candidate := range in.Candidates {
+		counts := make(map[int]int)
+		for _, user := range viewers[candidate] {
+			for _, movie := range in.Bookings[user] {
+				if movie != candidate {
+					counts[movie]++
+				}
+			}
+		}
+		coBookings[candidate] = counts
+	}
+	similarity := func(candidate, movie int) float64 {
+		both := coBookings[candidate][movie]
+		if both == 0 {
+			return 0
+		}
+		return float64(both) / math.Sqrt(float64(len(viewers[candidate])*len(viewers[movie])))
+	}
+
+	model := &Model{Users: make(map[int][]Suggestion, len(in.Bookings))}
+	for _, candidate := range in.Candidates {
+		if score := popularity(candidate); score > 0 {
+			model.Popular = append(model.Popular, Suggestion{MovieID: candidate, Score: score, Reason: models.RecommendationReasonPopular})
+		}
+	}
+	model.Popular = best(model.Popular, limit)
+
+	for user, booked := range in.Bookings {
+		if len(booked) == 0 {
+			continue
+		}
+		seen := make(map[int]bool, len(booked))
+		for _, movie := range booked {
+			seen[movie] = true
+		}
+		sortedBooked := append([]int(nil), booked...)
+		sort.Ints(sortedBooked)
+
+		profile := genreProfile(in.Genres, booked)
+
+		var suggestions []Suggestion
+		for _, candidate := range in.Candidates {
+			if seen[candidate] {
+				continue
+			}
+
+			var suggestion Suggestion
+			similar := 0.0
+			for _, movie := range sortedBooked {
+				if s := similarity(candidate, movie); s > similar {
+					similar, suggestion.SimilarTo = s, movie
+				}
+			}
+			affinity := 0.0
+			for _, genre := range in.Genres[candidate] {
+				affinity += profile[genre] / float64(len(in.Genres[candidate]))
+			}
+
+			signals := []struct {
+				score  float64
+				reason string
+			}{
+				{SimilarityWeight * similar, models.RecommendationReasonSimilar},
+				{AffinityWeight * affinity, models.RecommendationReasonGenre},
+				{PopularityWeight * popularity(candidate), models.RecommendationReasonPopular},
+			}
+			strongest := 0.0
+			for _, signal := range signals {
+				suggestion.Score += signal.score
+				if signal.score > strongest {
+					strongest, suggestion.Reason = signal.score, signal.reason
+				}
+			}
+			if suggestion.Score == 0 {
+				continue
+			}
+			if suggestion.Reason != models.RecommendationReasonSimilar {
+				suggestion.SimilarTo = 0
+			}
+			suggestion.MovieID = candidate
+			suggestions = append(suggestions, suggestion)
+		}
+		if suggestions = best(suggestions, limit); len(suggestions) > 0 {
+			model.Users[user] = suggestions
+		}
+	}
+	return model
+}
+
+// Merge ranks the personal suggestions of a user before the popular ones,
+// leaving out excluded movies and movies already suggested.
+func Merge(personal, popular []Suggestion, exclude map[int]bool) []Suggestion {
+	merged := make([]Suggestion, 0, len(personal)+len(popular))
+	included := make(map[int]bool, len(personal)+len(popular))
+	for _, list := range [][]Suggestion{personal, popular} {
+		for _, suggestion := range list {
+			if exclude[suggestion.MovieID] || included[suggestion.MovieID] {
+				continue
+			}
+			included[suggestion.MovieID] = true
+			merged = append(merged, suggestion)
+		}
+	}
+	return merged
+}
+
+// genreProfile returns the share of each genre in the booked movies. A movie
+// counts once however many genres it has, so the shares add up to at most 1.
+func genreProfile(genres map[int][]int, booked []int) map[int]float64 {
+	profile := make(map[int]float64)
+	for _, movie := range booked {
+		for _, genre := range genres[movie] {
+			profile[genre] += 1 / float64(len(genres[movie])*len(booked))
+		}
+	}
+	return profile
+}
+
+// best sorts suggestions by score, then by movie id for a stable order, and
+// keeps the first limit of them.
+func best(suggestions []Suggestion, limit int) []Suggestion {
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].MovieID < suggestions[j].MovieID
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
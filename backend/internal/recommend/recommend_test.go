@@ -0,0 +1,86 @@
+package recommend
+
+import (
+	"movie-system/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Movies 1-3 are sci-fi (genre 10), 4 is a drama (genre 20) and 5 a sci-fi
+// drama; users 1 and 2 saw 1 and 2, user 3 saw 1 and 4 and user 4 saw 3.
+var input = Input{
+	Bookings: map[int][]int{
+		1: {1, 2},
+		2: {1, 2},
+		3: {1, 4},
+		4: {3},
+	},
+	Genres: map[int][]int{
+		1: {10},
+		2: {10},
+		3: {10},
+		4: {20},
+		5: {10, 20},
+	},
+	Popularity: map[int]float64{2: 10, 4: 40, 5: 20},
+	Candidates: []int{2, 3, 4, 5},
+}
+
+func TestComputeCoBooking(t *testing.T) {
+	model := Compute(input, 10)
+
+	suggestions := model.Users[3]
+	if !assert.NotEmpty(t, suggestions) {
+		return
+	}
+	assert.Equal(t, 2, suggestions[0].MovieID, "the users who saw 1 also saw 2")
+	assert.Equal(t, models.RecommendationReasonSimilar, suggestions[0].Reason)
+	assert.Equal(t, 1, suggestions[0].SimilarTo)
+	for _, suggestion := range suggestions {
+		assert.NotEqual(t, 4, suggestion.MovieID, "booked movies are never suggested")
+		assert.LessOrEqual(t, suggestion.Score, 1.0)
+	}
+}
+
+func TestComputeGenreAffinity(t *testing.T) {
+	model := Compute(input, 10)
+
+	// Nobody who saw 3 or 5 booked anything else, so only their genres link
+	// them to the sci-fi movies of user 1; 5 is only half sci-fi.
+	suggestions := model.Users[1]
+	if !assert.Len(t, suggestions, 3) {
+		return
+	}
+	assert.Equal(t, []int{4, 3, 5}, []int{suggestions[0].MovieID, suggestions[1].MovieID, suggestions[2].MovieID})
+	assert.Equal(t, models.RecommendationReasonSimilar, suggestions[0].Reason, "user 3 saw both 1 and 4")
+	for _, suggestion := range suggestions[1:] {
+		assert.Equal(t, models.RecommendationReasonGenre, suggestion.Reason)
+		assert.Zero(t, suggestion.SimilarTo)
+	}
+	assert.InDelta(t, AffinityWeight, suggestions[1].Score, 1e-9)
+}
+
+func TestComputePopularFallback(t *testing.T) {
+	model := Compute(input, 2)
+
+	assert.Equal(t, []Suggestion{
+		{MovieID: 4, Score: 1, Reason: models.RecommendationReasonPopular},
+		{MovieID: 5, Score: 0.5, Reason: models.RecommendationReasonPopular},
+	}, model.Popular, "limited to the best two, without unsold movies")
+	assert.NotContains(t, model.Users, 5, "users without bookings get no personal suggestions")
+	assert.Len(t, model.Users[1], 2)
+}
+
+func TestMerge(t *testing.T) {
+	personal := []Suggestion{{MovieID: 3, Score: 0.6}, {MovieID: 5, Score: 0.4}}
+	popular := []Suggestion{{MovieID: 4, Score: 1}, {MovieID: 5, Score: 0.5}, {MovieID: 2, Score: 0.2}}
+
+	merged := Merge(personal, popular, map[int]bool{2: true})
+	ids := make([]int, len(merged))
+	for i, suggestion := range merged {
+		ids[i] = suggestion.MovieID
+	}
+	assert.Equal(t, []int{3, 5, 4}, ids)
+	assert.Empty(t, Merge(nil, nil, nil))
+}
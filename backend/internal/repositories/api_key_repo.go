@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie-system/internal/models"
+	"movie-system/internal/pagination"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+const apiKeyColumns = `id, name, prefix, scopes, rate_limit, expires_at, last_used_at, revoked_at, COALESCE(created_by, ''), created_at`
+
+func scanAPIKey(row pgx.Row, key *models.APIKey) error {
+	return row.Scan(&key.ID, &key.Name, &key.Prefix, &key.Scopes, &key.RateLimit, &key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt, &key.CreatedBy, &key.CreatedAt)
+}
+
+type APIKeyRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewAPIKeyRepository(db *pgxpool.Pool) *APIKeyRepository {
+	return &APIKeyRepository{DB: db}
+}
+
+func (repo *APIKeyRepository) InsertAPIKey(ctx context.Context, key *models.APIKey, keyHash string) error {
+	err := repo.DB.QueryRow(ctx, `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, rate_limit, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`,
+		key.Name, key.Prefix, keyHash, key.Scopes, key.RateLimit, key.ExpiresAt, key.CreatedBy,
+	).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error inserting api key: %w", err)
+	}
+	return nil
+}
+
+func (repo *APIKeyRepository) GetAPIKeys(ctx context.Context, params pagination.Params) (*pagination.Page[models.APIKey], error) {
+	afterID, err := pagination.AfterID(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int
+	if err := repo.DB.QueryRow(ctx, "SELECT COUNT(*) FROM api_keys").Scan(&total); err != nil {
+		return nil, fmt.Errorf("error counting api keys: %w", err)
+	}
+
+	rows, err := repo.DB.Query(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2`, afterID, params.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		var key models.APIKey
+		if err := scanAPIKey(rows, &key); err != nil {
+			return nil, fmt.Errorf("error scanning api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return pagination.NewPage(keys, params, total, func(key models.APIKey) string {
+		return pagination.IDCursorOf(key.ID)
+	}), nil
+}
+
+// GetActiveAPIKeyByHash returns the key with the given hash if it has been
+// neither revoked nor expired.
+func (repo *APIKeyRepository) GetActiveAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := scanAPIKey(repo.DB.QueryRow(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE key_hash = $1
+		AND revoked_at IS NULL
+		AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)`, keyHash), &key)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("error fetching api key: %w", err)
+	}
+	return &key, nil
+}
+
+// TouchAPIKey records that a key was used. To avoid a write on every request
+// the timestamp is only refreshed once a minute.
+func (repo *APIKeyRepository) TouchAPIKey(ctx context.Context, id uint) error {
+	_, err := repo.DB.Exec(ctx, `
+		UPDATE api_keys
+		SET last_used_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute')`, id)
+	if err != nil {
+		return fmt.Errorf("error updating api key usage: %w", err)
+	}
+	return nil
+}
+
+func (repo *APIKeyRepository) RevokeAPIKey(ctx context.Context, id int) error {
+	tag, err := repo.DB.Exec(ctx, `
+		UPDATE api_keys
+		SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("error revoking api key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
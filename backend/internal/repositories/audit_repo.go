@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie-system/internal/models"
+	"movie-system/internal/pagination"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrAuditEntryNotFound = errors.New("audit entry not found")
+
+const auditColumns = "id, actor, action, entity_type, entity_id, before, after, changes, created_at"
+
+func scanAuditEntry(row pgx.Row, entry *models.AuditEntry) error {
+	return row.Scan(&entry.ID, &entry.Actor, &entry.Action, &entry.EntityType, &entry.EntityID,
+		&entry.Before, &entry.After, &entry.Changes, &entry.CreatedAt)
+}
+
+// AuditRepository stores the audit trail of admin changes to the catalogue.
+// Entries are never updated or deleted.
+type AuditRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewAuditRepository(db *pgxpool.Pool) *AuditRepository {
+	return &AuditRepository{DB: db}
+}
+
+func (repo *AuditRepository) InsertEntry(ctx context.Context, entry *models.AuditEntry) error {
+	err := repo.DB.QueryRow(ctx, `
+		INSERT INTO audit_log (actor, action, entity_type, entity_id, before, after, changes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`,
+		entry.Actor, entry.Action, entry.EntityType, entry.EntityID, entry.Before, entry.After, entry.Changes,
+	).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error inserting audit entry: %w", err)
+	}
+	return nil
+}
+
+func (repo *AuditRepository) GetEntry(ctx context.Context, id int) (*models.AuditEntry, error) {
+	var entry models.AuditEntry
+	err := scanAuditEntry(repo.DB.QueryRow(ctx, "SELECT "+auditColumns+" FROM audit_log WHERE id = $1", id), &entry)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAuditEntryNotFound
+		}
+		return nil, fmt.Errorf("error fetching audit entry: %w", err)
+	}
+	return &entry, nil
+}
+
+// ListEntries returns audit entries matching filter, newest first.
+func (repo *AuditRepository) ListEntries(ctx context.Context, filter models.AuditFilter, params pagination.Params) (*pagination.Page[models.AuditEntry], error) {
+	beforeID, err := pagination.AfterID(params)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := []string{"TRUE"}
+	var args []any
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", fmt.Sprintf("$%d", len(args))))
+	}
+
+	if filter.EntityType != "" {
+		addCondition("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		addCondition("entity_id = ?", filter.EntityID)
+	}
+	if filter.Actor != "" {
+		addCondition("actor = ?", filter.Actor)
+	}
+	if filter.From != nil {
+		addCondition("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("created_at < ?", *filter.To)
+	}
+
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	if err := repo.DB.QueryRow(ctx, "SELECT COUNT(*) FROM audit_log WHERE "+where, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("error counting audit entries: %w", err)
+	}
+
+	args = append(args, beforeID, params.Limit+1)
+	rows, err := repo.DB.Query(ctx, fmt.Sprintf(`
+		SELECT `+auditColumns+`
+		FROM audit_log
+		WHERE `+where+` AND ($%d = 0 OR id < $%[1]d)
+		ORDER BY id DESC
+		LIMIT $%d`, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var entry models.AuditEntry
+		if err := scanAuditEntry(rows, &entry); err != nil {
+			return nil, fmt.Errorf("error scanning audit entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return pagination.NewPage(entries, params, total, func(entry models.AuditEntry) string {
+		return pagination.IDCursorOf(entry.ID)
+	}), nil
+}
@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie-system/internal/models"
+	"movie-system/internal/pagination"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrAuditoriumNotFound = errors.New("auditorium not found")
+	ErrAuditoriumExists   = errors.New("the cinema already has an auditorium with this name")
+	ErrAuditoriumInUse    = errors.New("the auditorium has showtimes or schedules")
+)
+
+const auditoriumColumns = "id, cinema_id, name, ad_minutes, cleaning_minutes, created_at"
+
+func scanAuditorium(row pgx.Row, auditorium *models.Auditorium) error {
+	return row.Scan(&auditorium.ID, &auditorium.CinemaID, &auditorium.Name, &auditorium.AdMinutes, &auditorium.CleaningMinutes, &auditorium.CreatedAt)
+}
+
+type AuditoriumRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewAuditoriumRepository(db *pgxpool.Pool) *AuditoriumRepository {
+	return &AuditoriumRepository{DB: db}
+}
+
+// ListAuditoriums returns a page of the auditoriums of a cinema, or of all
+// cinemas if cinemaID is 0.
+func (repo *AuditoriumRepository) ListAuditoriums(ctx context.Context, cinemaID int, params pagination.Params) (*pagination.Page[models.Auditorium], error) {
+	afterID, err := pagination.AfterID(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int
+	err = repo.DB.QueryRow(ctx, "SELECT COUNT(*) FROM auditoriums WHERE $1 = 0 OR cinema_id = $1", cinemaID).Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("error counting auditoriums: %w", err)
+	}
+
+	rows, err := repo.DB.Query(ctx, `
+		SELECT `+auditoriumColumns+`
+		FROM auditoriums
+		WHERE ($1 = 0 OR cinema_id = $1) AND id > $2
+		ORDER BY id
+		LIMIT $3`, cinemaID, afterID, params.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching auditoriums: %w", err)
+	}
+	defer rows.Close()
+
+	var auditoriums []models.Auditorium
+	for rows.Next() {
+		var auditorium models.Auditorium
+		if err := scanAuditorium(rows, &auditorium); err != nil {
+			return nil, fmt.Errorf("error scanning auditorium: %w", err)
+		}
+		auditoriums = append(auditoriums, auditorium)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return pagination.NewPage(auditoriums, params, total, func(auditorium models.Auditorium) string {
+		return pagination.IDCursorOf(auditorium.ID)
+	}), nil
+}
+
+func (repo *AuditoriumRepository) GetAuditoriumByID(ctx context.Context, id int) (*models.Auditorium, error) {
+	var auditorium models.Auditorium
+	err := scanAuditorium(repo.DB.QueryRow(ctx, "SELECT "+auditoriumColumns+" FROM auditoriums WHERE id = $1", id), &auditorium)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAuditoriumNotFound
+		}
+		return nil, fmt.Errorf("error fetching auditorium: %w", err)
+	}
+	return &auditorium, nil
+}
+
+func (repo *AuditoriumRepository) InsertAuditorium(ctx context.Context, auditorium *models.Auditorium) error {
+	err := scanAuditorium(repo.DB.QueryRow(ctx, `
+		INSERT INTO auditoriums (cinema_id, name, ad_minutes, cleaning_minutes)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+auditoriumColumns, auditorium.CinemaID, auditorium.Name, auditorium.AdMinutes, auditorium.CleaningMinutes), auditorium)
+	if err != nil {
+		return auditoriumWriteError(err, "error inserting auditorium")
+	}
+	return nil
+}
+
+// UpdateAuditorium renames an auditorium or changes its buffers; it stays in
+// its cinema. The upcoming showtimes in it are rescheduled with the new
+// buffers, and the change fails with a *ShowtimeConflictError if any of them
+// would then overlap.
+func (repo *AuditoriumRepository) UpdateAuditorium(ctx context.Context, id int, auditorium *models.Auditorium) (*models.Auditorium, error) {
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var updated models.Auditorium
+	err = scanAuditorium(tx.QueryRow(ctx, `
+		UPDATE auditoriums
+		SET name = $1, ad_minutes = $2, cleaning_minutes = $3
+		WHERE id = $4
+		RETURNING `+auditoriumColumns, auditorium.Name, auditorium.AdMinutes, auditorium.CleaningMinutes, id), &updated)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAuditoriumNotFound
+		}
+		return nil, auditoriumWriteError(err, "error updating auditorium")
+	}
+
+	if err := rescheduleShowtimes(ctx, tx, "s.auditorium_id = $1", id); err != nil {
+		return nil, err
+	}
+	return &updated, tx.Commit(ctx)
+}
+
+// DeleteAuditorium removes an auditorium no showtime, archived or not, and
+// no schedule is in.
+func (repo *AuditoriumRepository) DeleteAuditorium(ctx context.Context, id int) error {
+	tag, err := repo.DB.Exec(ctx, "DELETE FROM auditoriums WHERE id = $1", id)
+	if err != nil {
+		return auditoriumWriteError(err, "error deleting auditorium")
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrAuditoriumNotFound
+	}
+	return nil
+}
+
+func auditoriumWriteError(err error, message string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505":
+			return ErrAuditoriumExists
+		case "23503":
+			if pgErr.ConstraintName == "auditoriums_cinema_id_fkey" {
+				return ErrCinemaNotFound
+			}
+			return ErrAuditoriumInUse
+		}
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie-system/internal/models"
+	"movie-system/internal/pagination"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrCinemaNotFound = errors.New("cinema not found")
+	ErrCinemaExists   = errors.New("a cinema with this name already exists")
+	ErrCinemaInUse    = errors.New("the cinema has auditoriums, showtimes, schedules or admins")
+)
+
+const cinemaColumns = "id, name, address, timezone, seat_price, created_at"
+
+func scanCinema(row pgx.Row, cinema *models.Cinema) error {
+	return row.Scan(&cinema.ID, &cinema.Name, &cinema.Address, &cinema.TimeZone, &cinema.SeatPrice, &cinema.CreatedAt)
+}
+
+type CinemaRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewCinemaRepository(db *pgxpool.Pool) *CinemaRepository {
+	return &CinemaRepository{DB: db}
+}
+
+func (repo *CinemaRepository) ListCinemas(ctx context.Context, params pagination.Params) (*pagination.Page[models.Cinema], error) {
+	afterID, err := pagination.AfterID(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int
+	if err := repo.DB.QueryRow(ctx, "SELECT COUNT(*) FROM cinemas").Scan(&total); err != nil {
+		return nil, fmt.Errorf("error counting cinemas: %w", err)
+	}
+
+	rows, err := repo.DB.Query(ctx, `
+		SELECT `+cinemaColumns+`
+		FROM cinemas
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2`, afterID, params.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching cinemas: %w", err)
+	}
+	defer rows.Close()
+
+	var cinemas []models.Cinema
+	for rows.Next() {
+		var cinema models.Cinema
+		if err := scanCinema(rows, &cinema); err != nil {
+			return nil, fmt.Errorf("error scanning cinema: %w", err)
+		}
+		cinemas = append(cinemas, cinema)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return pagination.NewPage(cinemas, params, total, func(cinema models.Cinema) string {
+		return pagination.IDCursorOf(cinema.ID)
+	}), nil
+}
+
+func (repo *CinemaRepository) GetCinemaByID(ctx context.Context, id int) (*models.Cinema, error) {
+	var cinema models.Cinema
+	err := scanCinema(repo.DB.QueryRow(ctx, "SELECT "+cinemaColumns+" FROM cinemas WHERE id = $1", id), &cinema)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCinemaNotFound
+		}
+		return nil, fmt.Errorf("error fetching cinema: %w", err)
+	}
+	return &cinema, nil
+}
+
+func (repo *CinemaRepository) InsertCinema(ctx context.Context, cinema *models.Cinema) error {
+	err := scanCinema(repo.DB.QueryRow(ctx, `
+		INSERT INTO cinemas (name, address, timezone, seat_price)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+cinemaColumns, cinema.Name, cinema.Address, cinema.TimeZone, cinema.SeatPrice), cinema)
+	if err != nil {
+		return cinemaWriteError(err, "error inserting cinema")
+	}
+	return nil
+}
+
+// UpdateCinema changes a cinema. Showtimes keep their instants when the time
+// zone changes, so they are shown at other local times afterwards; booked
+// seats keep the price they were booked at.
+func (repo *CinemaRepository) UpdateCinema(ctx context.Context, id int, cinema *models.Cinema) (*models.Cinema, error) {
+	var updated models.Cinema
+	err := scanCinema(repo.DB.QueryRow(ctx, `
+		UPDATE cinemas
+		SET name = $1, address = $2, timezone = $3, seat_price = $4
+		WHERE id = $5
+		RETURNING `+cinemaColumns, cinema.Name, cinema.Address, cinema.TimeZone, cinema.SeatPrice, id), &updated)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCinemaNotFound
+		}
+		return nil, cinemaWriteError(err, "error updating cinema")
+	}
+	return &updated, nil
+}
+
+// DeleteCinema removes a cinema that nothing belongs to.
+func (repo *CinemaRepository) DeleteCinema(ctx context.Context, id int) error {
+	tag, err := repo.DB.Exec(ctx, "DELETE FROM cinemas WHERE id = $1", id)
+	if err != nil {
+		return cinemaWriteError(err, "error deleting cinema")
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrCinemaNotFound
+	}
+	return nil
+}
+
+func cinemaWriteError(err error, message string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505":
+			return ErrCinemaExists
+		case "23503":
+			return ErrCinemaInUse
+		}
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie-system/internal/models"
+	"movie-system/internal/pagination"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrGenreExists = errors.New("a genre with this name already exists")
+
+// GenreRepository manages the genre taxonomy. Genre names are unique by
+// slug, so "Sci-Fi" and "sci fi" cannot both exist.
+type GenreRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewGenreRepository(db *pgxpool.Pool) *GenreRepository {
+	return &GenreRepository{DB: db}
+}
+
+func (repo *GenreRepository) ListGenres(ctx context.Context, params pagination.Params) (*pagination.Page[models.Genre], error) {
+	afterID, err := pagination.AfterID(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int
+	if err := repo.DB.QueryRow(ctx, "SELECT COUNT(*) FROM genres").Scan(&total); err != nil {
+		return nil, fmt.Errorf("error counting genres: %w", err)
+	}
+
+	rows, err := repo.DB.Query(ctx, `
+		SELECT id, name, slug
+		FROM genres
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2`, afterID, params.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching genres: %w", err)
+	}
+	defer rows.Close()
+
+	var genres []models.Genre
+	for rows.Next() {
+		var genre models.Genre
+		if err := rows.Scan(&genre.ID, &genre.Name, &genre.Slug); err != nil {
+			return nil, fmt.Errorf("error scanning genre: %w", err)
+		}
+		genres = append(genres, genre)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return pagination.NewPage(genres, params, total, func(genre models.Genre) string {
+		return pagination.IDCursorOf(genre.ID)
+	}), nil
+}
+
+func (repo *GenreRepository) InsertGenre(ctx context.Context, genre *models.Genre) error {
+	genre.Slug = models.Slugify(genre.Name)
+	err := repo.DB.QueryRow(ctx, `
+		INSERT INTO genres (name, slug)
+		VALUES ($1, $2)
+		RETURNING id`, genre.Name, genre.Slug).Scan(&genre.ID)
+	if err != nil {
+		return genreWriteError(err, "error inserting genre")
+	}
+	return nil
+}
+
+func (repo *GenreRepository) GetGenreByID(ctx context.Context, id int) (*models.Genre, error) {
+	var genre models.Genre
+	err := repo.DB.QueryRow(ctx, "SELECT id, name, slug FROM genres WHERE id = $1", id).Scan(&genre.ID, &genre.Name, &genre.Slug)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrGenreNotFound
+		}
+		return nil, fmt.Errorf("error fetching genre: %w", err)
+	}
+	return &genre, nil
+}
+
+func (repo *GenreRepository) GetGenreBySlug(ctx context.Context, slug string) (*models.Genre, error) {
+	var genre models.Genre
+	err := repo.DB.QueryRow(ctx, "SELECT id, name, slug FROM genres WHERE slug = $1", slug).Scan(&genre.ID, &genre.Name, &genre.Slug)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrGenreNotFound
+		}
+		return nil, fmt.Errorf("error fetching genre: %w", err)
+	}
+	return &genre, nil
+}
+
+// UpdateGenre renames a genre, which also changes its slug, and refreshes the
+// search documents of the movies in it.
+func (repo *GenreRepository) UpdateGenre(ctx context.Context, id int, name string) (*models.Genre, error) {
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var genre models.Genre
+	err = tx.QueryRow(ctx, `
+		UPDATE genres
+		SET name = $1, slug = $2
+		WHERE id = $3
+		RETURNING id, name, slug`, name, models.Slugify(name), id).Scan(&genre.ID, &genre.Name, &genre.Slug)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrGenreNotFound
+		}
+		return nil, genreWriteError(err, "error updating genre")
+	}
+
+	if err := refreshSearchDocuments(ctx, tx, "m.id IN (SELECT movie_id FROM movie_genres WHERE genre_id = $1)", id); err != nil {
+		return nil, err
+	}
+	return &genre, tx.Commit(ctx)
+}
+
+// DeleteGenre removes a genre from the taxonomy and from all movies.
+func (repo *GenreRepository) DeleteGenre(ctx context.Context, id int) error {
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, "DELETE FROM movie_genres WHERE genre_id = $1 RETURNING movie_id", id)
+	if err != nil {
+		return fmt.Errorf("error unlinking genre: %w", err)
+	}
+	movieIDs, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return fmt.Errorf("error unlinking genre: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, "DELETE FROM genres WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error deleting genre: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrGenreNotFound
+	}
+
+	if err := refreshSearchDocuments(ctx, tx, "m.id = ANY($1)", movieIDs); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func genreWriteError(err error, message string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrGenreExists
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrIdentityNotFound = errors.New("identity not found")
+	ErrInvalidState     = errors.New("login state is invalid or expired")
+)
+
+// IdentityRepository links users to accounts at external OpenID Connect
+// providers and keeps the short-lived state of logins in progress.
+type IdentityRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewIdentityRepository(db *pgxpool.Pool) *IdentityRepository {
+	return &IdentityRepository{DB: db}
+}
+
+func (repo *IdentityRepository) GetUserIDByIdentity(ctx context.Context, provider, subject string) (int, error) {
+	var userID int
+	err := repo.DB.QueryRow(ctx, `
+		SELECT user_id
+		FROM user_identities
+		WHERE provider = $1 AND subject = $2`, provider, subject).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrIdentityNotFound
+		}
+		return 0, fmt.Errorf("error fetching identity: %w", err)
+	}
+	return userID, nil
+}
+
+func (repo *IdentityRepository) LinkIdentity(ctx context.Context, userID int, provider, subject, email string) error {
+	_, err := repo.DB.Exec(ctx, `
+		INSERT INTO user_identities (user_id, provider, subject, email)
+		VALUES ($1, $2, $3, NULLIF($4, ''))`, userID, provider, subject, email)
+	if err != nil {
+		return fmt.Errorf("error linking identity: %w", err)
+	}
+	return nil
+}
+
+func (repo *IdentityRepository) SaveState(ctx context.Context, stateHash, provider, nonce, verifier string, ttl time.Duration) error {
+	_, err := repo.DB.Exec(ctx, `
+		INSERT INTO oidc_states (state_hash, provider, nonce, code_verifier, expires_at)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP + $5 * INTERVAL '1 second')`,
+		stateHash, provider, nonce, verifier, int(ttl.Seconds()))
+	if err != nil {
+		return fmt.Errorf("error saving login state: %w", err)
+	}
+	return nil
+}
+
+// ConsumeState deletes the state and returns its nonce and PKCE verifier. A
+// state can only be used once and only for the provider it was created for.
+func (repo *IdentityRepository) ConsumeState(ctx context.Context, stateHash, provider string) (string, string, error) {
+	var nonce, verifier string
+	err := repo.DB.QueryRow(ctx, `
+		DELETE FROM oidc_states
+		WHERE state_hash = $1
+		AND provider = $2
+		AND expires_at > CURRENT_TIMESTAMP
+		RETURNING nonce, code_verifier`, stateHash, provider).Scan(&nonce, &verifier)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", "", ErrInvalidState
+		}
+		return "", "", fmt.Errorf("error consuming login state: %w", err)
+	}
+
+	// Opportunistically clean up abandoned logins.
+	if _, err := repo.DB.Exec(ctx, "DELETE FROM oidc_states WHERE expires_at <= CURRENT_TIMESTAMP"); err != nil {
+		return "", "", fmt.Errorf("error deleting expired login states: %w", err)
+	}
+	return nonce, verifier, nil
+}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// eachInSavepoint calls fn for 0 <= i < n, each time in a savepoint of tx
+// that is rolled back if fn fails. It returns the error of every call, and
+// an error of its own only if the transaction itself broke.
+func eachInSavepoint(ctx context.Context, tx pgx.Tx, n int, fn func(tx pgx.Tx, i int) error) ([]error, error) {
+	errs := make([]error, n)
+	for i := range n {
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error creating savepoint: %w", err)
+		}
+		if errs[i] = fn(savepoint, i); errs[i] != nil {
+			err = savepoint.Rollback(ctx)
+		} else {
+			err = savepoint.Commit(ctx)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error releasing savepoint: %w", err)
+		}
+	}
+	return errs, nil
+}
+
+func anyError(errs []error) bool {
+	for _, err := range errs {
+		if err != nil {
+			return true
+		}
+	}
+	return false
+}
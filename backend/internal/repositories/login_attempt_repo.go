@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"movie-system/internal/models"
+	"movie-system/internal/pagination"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	LoginResultSuccess     = "success"
+	LoginResultInvalid     = "invalid_credentials"
+	LoginResultInvalidCode = "invalid_mfa_code"
+	LoginResultMFAPending  = "mfa_pending"
+	LoginResultDisabled    = "disabled"
+	LoginResultLocked      = "locked"
+)
+
+// LoginAttemptRepository is the audit trail of login attempts. It is also
+// used to count recent failures when deciding whether to throttle a login.
+type LoginAttemptRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewLoginAttemptRepository(db *pgxpool.Pool) *LoginAttemptRepository {
+	return &LoginAttemptRepository{DB: db}
+}
+
+func (repo *LoginAttemptRepository) RecordAttempt(ctx context.Context, username, ip string, success bool, result string) error {
+	_, err := repo.DB.Exec(ctx, `
+		INSERT INTO login_attempts (username, ip, success, result)
+		VALUES ($1, $2, $3, $4)`, username, ip, success, result)
+	if err != nil {
+		return fmt.Errorf("error recording login attempt: %w", err)
+	}
+	return nil
+}
+
+// recentFailures counts wrong passwords and codes for column = value within
+// window, and reports how long ago the latest one happened. When
+// sinceSuccess is set only failures after the last successful login for
+// column = value count. Attempts rejected because of a lockout are not
+// counted so that retrying while locked does not extend the lockout.
+func (repo *LoginAttemptRepository) recentFailures(ctx context.Context, column, value string, window time.Duration, sinceSuccess bool) (int, time.Duration, error) {
+	query := `
+		SELECT COUNT(*), COALESCE(EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP - MAX(created_at))), 0)
+		FROM login_attempts
+		WHERE ` + column + ` = $1
+		AND result = ANY($2)
+		AND created_at > CURRENT_TIMESTAMP - $3 * INTERVAL '1 second'`
+	if sinceSuccess {
+		query += `
+		AND created_at > COALESCE((
+			SELECT MAX(created_at) FROM login_attempts WHERE ` + column + ` = $1 AND success = TRUE
+		), '-infinity')`
+	}
+
+	var count int
+	var secondsSince float64
+	err := repo.DB.QueryRow(ctx, query, value, []string{LoginResultInvalid, LoginResultInvalidCode}, int(window.Seconds())).Scan(&count, &secondsSince)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error counting login failures: %w", err)
+	}
+	return count, time.Duration(secondsSince * float64(time.Second)), nil
+}
+
+// RecentFailuresForUsername counts the failures for username since its last
+// successful login: the owner getting in proves the guessing was not theirs.
+func (repo *LoginAttemptRepository) RecentFailuresForUsername(ctx context.Context, username string, window time.Duration) (int, time.Duration, error) {
+	return repo.recentFailures(ctx, "username", username, window, true)
+}
+
+// RecentFailuresForIP counts all the failures from ip within window. An
+// attacker logging into an account of their own in between does not reset
+// the count.
+func (repo *LoginAttemptRepository) RecentFailuresForIP(ctx context.Context, ip string, window time.Duration) (int, time.Duration, error) {
+	return repo.recentFailures(ctx, "ip", ip, window, false)
+}
+
+// GetAttempts returns login attempts, newest first. When username is not
+// empty only attempts for that username are returned.
+func (repo *LoginAttemptRepository) GetAttempts(ctx context.Context, username string, params pagination.Params) (*pagination.Page[models.LoginAttempt], error) {
+	beforeID, err := pagination.AfterID(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int
+	err = repo.DB.QueryRow(ctx, `
+		SELECT COUNT(*)
+		FROM login_attempts
+		WHERE $1 = '' OR username = $1`, username).Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("error counting login attempts: %w", err)
+	}
+
+	rows, err := repo.DB.Query(ctx, `
+		SELECT id, username, ip, success, result, created_at
+		FROM login_attempts
+		WHERE ($1 = '' OR username = $1)
+		AND ($2 = 0 OR id < $2)
+		ORDER BY id DESC
+		LIMIT $3`, username, beforeID, params.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching login attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []models.LoginAttempt
+	for rows.Next() {
+		var attempt models.LoginAttempt
+		if err := rows.Scan(&attempt.ID, &attempt.Username, &attempt.IP, &attempt.Success, &attempt.Result, &attempt.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning login attempt: %w", err)
+		}
+		attempts = append(attempts, attempt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return pagination.NewPage(attempts, params, total, func(attempt models.LoginAttempt) string {
+		return pagination.IDCursorOf(attempt.ID)
+	}), nil
+}
@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie-system/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrMediaNotFound = errors.New("media not found")
+
+const mediaColumns = `id, movie_id, kind, content_type, width, height, size_bytes, key_prefix, created_at`
+
+func scanMedia(row pgx.Row, media *models.Media) error {
+	return row.Scan(&media.ID, &media.MovieID, &media.Kind, &media.ContentType, &media.Width, &media.Height,
+		&media.SizeBytes, &media.KeyPrefix, &media.CreatedAt)
+}
+
+// MediaRepository keeps track of the images uploaded for movies. The image
+// files are kept in a storage.BlobStore.
+type MediaRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewMediaRepository(db *pgxpool.Pool) *MediaRepository {
+	return &MediaRepository{DB: db}
+}
+
+func (repo *MediaRepository) InsertMedia(ctx context.Context, media *models.Media) error {
+	err := repo.DB.QueryRow(ctx, `
+		INSERT INTO movie_media (movie_id, kind, content_type, width, height, size_bytes, key_prefix)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`,
+		media.MovieID, media.Kind, media.ContentType, media.Width, media.Height, media.SizeBytes, media.KeyPrefix).
+		Scan(&media.ID, &media.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return ErrMovieNotFound
+		}
+		return fmt.Errorf("error inserting media: %w", err)
+	}
+	return nil
+}
+
+func (repo *MediaRepository) GetMedia(ctx context.Context, id int) (*models.Media, error) {
+	var media models.Media
+	err := scanMedia(repo.DB.QueryRow(ctx, `SELECT `+mediaColumns+` FROM movie_media WHERE id = $1`, id), &media)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMediaNotFound
+		}
+		return nil, fmt.Errorf("error fetching media: %w", err)
+	}
+	return &media, nil
+}
+
+// ListMedia returns the images of a movie, newest first.
+func (repo *MediaRepository) ListMedia(ctx context.Context, movieID int) ([]models.Media, error) {
+	rows, err := repo.DB.Query(ctx, `
+		SELECT `+mediaColumns+`
+		FROM movie_media
+		WHERE movie_id = $1
+		ORDER BY id DESC`, movieID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching media: %w", err)
+	}
+	defer rows.Close()
+
+	media := []models.Media{}
+	for rows.Next() {
+		var item models.Media
+		if err := scanMedia(rows, &item); err != nil {
+			return nil, fmt.Errorf("error scanning media: %w", err)
+		}
+		media = append(media, item)
+	}
+	return media, rows.Err()
+}
+
+func (repo *MediaRepository) DeleteMedia(ctx context.Context, id int) error {
+	tag, err := repo.DB.Exec(ctx, "DELETE FROM movie_media WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error deleting media: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrMediaNotFound
+	}
+	return nil
+}
+
+// SetPoster points the poster of a movie to url.
+func (repo *MediaRepository) SetPoster(ctx context.Context, movieID int, url string) error {
+	_, err := repo.DB.Exec(ctx, "UPDATE movies SET poster_image = $1 WHERE id = $2", url, movieID)
+	if err != nil {
+		return fmt.Errorf("error setting poster: %w", err)
+	}
+	return nil
+}
+
+// ClearPoster removes the poster of a movie if it still points to url.
+func (repo *MediaRepository) ClearPoster(ctx context.Context, movieID int, url string) error {
+	_, err := repo.DB.Exec(ctx, "UPDATE movies SET poster_image = '' WHERE id = $1 AND poster_image = $2", movieID, url)
+	if err != nil {
+		return fmt.Errorf("error clearing poster: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// MFARepository stores TOTP secrets, recovery codes and the roles for which
+// two-factor authentication is mandatory.
+type MFARepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewMFARepository(db *pgxpool.Pool) *MFARepository {
+	return &MFARepository{DB: db}
+}
+
+// GetTOTP returns the stored secret, whether it has been confirmed and the
+// last time step a code was accepted for.
+func (repo *MFARepository) GetTOTP(ctx context.Context, userID int) (string, bool, int64, error) {
+	var secret string
+	var enabled bool
+	var lastStep int64
+	err := repo.DB.QueryRow(ctx, `
+		SELECT COALESCE(totp_secret, ''), totp_enabled, totp_last_step
+		FROM users
+		WHERE id = $1`, userID).Scan(&secret, &enabled, &lastStep)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", false, 0, ErrUserNotFound
+		}
+		return "", false, 0, fmt.Errorf("error fetching totp settings: %w", err)
+	}
+	return secret, enabled, lastStep, nil
+}
+
+// SetPendingSecret stores a secret that still has to be confirmed with a
+// valid code before it is enforced at login.
+func (repo *MFARepository) SetPendingSecret(ctx context.Context, userID int, secret string) error {
+	_, err := repo.DB.Exec(ctx, `
+		UPDATE users
+		SET totp_secret = $1, totp_enabled = FALSE, totp_last_step = 0, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2`, secret, userID)
+	if err != nil {
+		return fmt.Errorf("error storing totp secret: %w", err)
+	}
+	return nil
+}
+
+func (repo *MFARepository) EnableTOTP(ctx context.Context, userID int) error {
+	_, err := repo.DB.Exec(ctx, `
+		UPDATE users
+		SET totp_enabled = TRUE, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND totp_secret IS NOT NULL`, userID)
+	if err != nil {
+		return fmt.Errorf("error enabling totp: %w", err)
+	}
+	return nil
+}
+
+func (repo *MFARepository) DisableTOTP(ctx context.Context, userID int) error {
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE users
+		SET totp_secret = NULL, totp_enabled = FALSE, totp_last_step = 0, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("error disabling totp: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, "DELETE FROM user_recovery_codes WHERE user_id = $1", userID)
+	if err != nil {
+		return fmt.Errorf("error deleting recovery codes: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// AdvanceLastStep records that a code for step was used. It returns false if
+// a code for the same or a later step was already accepted, which means the
+// code is being replayed.
+func (repo *MFARepository) AdvanceLastStep(ctx context.Context, userID int, step int64) (bool, error) {
+	tag, err := repo.DB.Exec(ctx, `
+		UPDATE users
+		SET totp_last_step = $1
+		WHERE id = $2 AND totp_last_step < $1`, step, userID)
+	if err != nil {
+		return false, fmt.Errorf("error updating totp step: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ReplaceRecoveryCodes discards all existing recovery codes of a user and
+// stores the given hashes instead.
+func (repo *MFARepository) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, "DELETE FROM user_recovery_codes WHERE user_id = $1", userID)
+	if err != nil {
+		return fmt.Errorf("error deleting recovery codes: %w", err)
+	}
+
+	for _, hash := range codeHashes {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO user_recovery_codes (user_id, code_hash)
+			VALUES ($1, $2)`, userID, hash)
+		if err != nil {
+			return fmt.Errorf("error inserting recovery code: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ConsumeRecoveryCode marks an unused recovery code as used. It returns false
+// if the code does not exist or was used before.
+func (repo *MFARepository) ConsumeRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	tag, err := repo.DB.Exec(ctx, `
+		UPDATE user_recovery_codes
+		SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("error consuming recovery code: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (repo *MFARepository) CountRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	var count int
+	err := repo.DB.QueryRow(ctx, `
+		SELECT COUNT(*)
+		FROM user_recovery_codes
+		WHERE user_id = $1 AND used_at IS NULL`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting recovery codes: %w", err)
+	}
+	return count, nil
+}
+
+func (repo *MFARepository) GetRequiredRoles(ctx context.Context) ([]string, error) {
+	rows, err := repo.DB.Query(ctx, "SELECT role FROM mfa_required_roles ORDER BY role")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching mfa roles: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []string{}
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, fmt.Errorf("error scanning mfa role: %w", err)
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+func (repo *MFARepository) IsRequiredForRole(ctx context.Context, role string) (bool, error) {
+	var required bool
+	err := repo.DB.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM mfa_required_roles WHERE role = $1)`, role).Scan(&required)
+	if err != nil {
+		return false, fmt.Errorf("error checking mfa role: %w", err)
+	}
+	return required, nil
+}
+
+func (repo *MFARepository) SetRequiredRoles(ctx context.Context, roles []string) error {
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "DELETE FROM mfa_required_roles"); err != nil {
+		return fmt.Errorf("error clearing mfa roles: %w", err)
+	}
+
+	for _, role := range roles {
+		if _, err := tx.Exec(ctx, "INSERT INTO mfa_required_roles (role) VALUES ($1)", role); err != nil {
+			return fmt.Errorf("error inserting mfa role: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
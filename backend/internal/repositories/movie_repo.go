@@ -2,14 +2,48 @@ package repositories
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
-	"movie-system/config"
 	"movie-system/internal/models"
+	"movie-system/internal/pagination"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var (
+	ErrInvalidFilter  = errors.New("invalid filter")
+	ErrMovieNotFound  = errors.New("movie not found")
+	ErrPersonNotFound = errors.New("person not found")
+	ErrGenreNotFound  = errors.New("genre not found")
+	// ErrExternalIDExists is returned when an external id is already used
+	// by another movie or showtime.
+	ErrExternalIDExists = errors.New("external_id is already in use")
+	ErrMovieExists      = errors.New("a movie with this title already exists")
+	// ErrNotArchived is returned when purging something that was not
+	// archived first.
+	ErrNotArchived = errors.New("only archived items can be purged")
+)
+
+const movieColumns = `id, COALESCE(external_id, ''), title, description, poster_image, release_date, runtime_minutes,
+	COALESCE(certification, ''), COALESCE(original_language, ''), subtitles, locked_fields, archived_at`
+
+// scanMovie scans movieColumns followed by any extra columns into dest.
+func scanMovie(row pgx.Row, movie *models.Movie, extra ...any) error {
+	dest := []any{&movie.ID, &movie.ExternalID, &movie.Title, &movie.Description, &movie.PosterImage,
+		&movie.ReleaseDate, &movie.RuntimeMinutes, &movie.Certification, &movie.OriginalLanguage, &movie.Subtitles, &movie.LockedFields, &movie.ArchivedAt}
+	return row.Scan(append(dest, extra...)...)
+}
+
+// execer is implemented by both the pool and transactions.
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
 type MovieRepository struct {
 	DB *pgxpool.Pool
 }
@@ -19,69 +53,809 @@ func NewMovieRepository(db *pgxpool.Pool) *MovieRepository {
 }
 
 func (repo *MovieRepository) InsertMovie(ctx context.Context, movie *models.Movie) error {
-	_, err := repo.DB.Exec(ctx, `
-		INSERT INTO movies (title, description, genre, poster_image)
-		VALUES ($1, $2, $3, $4)`, movie.Title, movie.Description, movie.Genre, movie.PosterImage)
-	return err
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := insertMovie(ctx, tx, movie); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	return repo.attachDetails(ctx, movie)
+}
+
+func insertMovie(ctx context.Context, tx pgx.Tx, movie *models.Movie) error {
+	if movie.Subtitles == nil {
+		movie.Subtitles = []string{}
+	}
+	if movie.LockedFields == nil {
+		movie.LockedFields = []string{}
+	}
+	err := tx.QueryRow(ctx, `
+		INSERT INTO movies (external_id, title, description, poster_image, release_date, runtime_minutes,
+			certification, original_language, subtitles, locked_fields)
+		VALUES (NULLIF($1, ''), $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), $9, $10)
+		RETURNING id`,
+		movie.ExternalID, movie.Title, movie.Description, movie.PosterImage, movie.ReleaseDate, movie.RuntimeMinutes,
+		movie.Certification, movie.OriginalLanguage, movie.Subtitles, movie.LockedFields).
+		Scan(&movie.ID)
+	if err != nil {
+		return movieWriteError(err, "error inserting movie")
+	}
+
+	if err := replaceGenres(ctx, tx, int(movie.ID), movie.Genres); err != nil {
+		return err
+	}
+	if err := replaceCredits(ctx, tx, int(movie.ID), movie.Credits); err != nil {
+		return err
+	}
+	return refreshSearchDocuments(ctx, tx, "m.id = $1", movie.ID)
 }
 
-func (repo *MovieRepository) GetMovies(ctx context.Context, genre string) ([]models.Movie, error) {
-	var rows pgx.Rows
-	var err error
+// movieSortKeys maps the sort options to the expression movies are ordered
+// by. Movies without a release date sort as the oldest.
+var movieSortKeys = map[string]string{
+	models.MovieSortTitle:       "title",
+	models.MovieSortReleaseDate: "COALESCE(release_date, DATE '0001-01-01')",
+	models.MovieSortPopularity:  "popularity",
+}
 
-	if genre != "" {
-		rows, err = repo.DB.Query(ctx, `
-		SELECT id, title, description, genre, poster_image
-		FROM movies
-		WHERE genre ILIKE $1`, "%"+genre+"%")
-	} else {
-		rows, err = config.DB.Query(ctx, `
-		SELECT id, title, description, genre, poster_image
-		FROM movies`)
+// movieCursor is the position after the last movie of a page: the value of
+// the sort key and the id that breaks ties.
+type movieCursor struct {
+	Sort  string `json:"s"`
+	Value any    `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// ListMovies returns one page of movies matching filter. Popularity is the
+// number of seats reserved for the movie.
+func (repo *MovieRepository) ListMovies(ctx context.Context, filter models.MovieFilter, params pagination.Params) (*pagination.Page[models.Movie], error) {
+	sort := filter.Sort
+	if sort == "" {
+		sort = models.MovieSortTitle
+	}
+	descending := strings.HasPrefix(sort, "-")
+	sortKey, ok := movieSortKeys[strings.TrimPrefix(sort, "-")]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown sort %q", ErrInvalidFilter, sort)
+	}
+
+	conditions := []string{"archived_at IS NULL"}
+	if filter.Archived {
+		conditions[0] = "archived_at IS NOT NULL"
+	}
+	var args []any
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", fmt.Sprintf("$%d", len(args))))
 	}
 
+	if len(filter.Genres) > 0 {
+		genres := make([]string, len(filter.Genres))
+		for i, genre := range filter.Genres {
+			genres[i] = strings.ToLower(strings.TrimSpace(genre))
+		}
+		addCondition(`EXISTS (
+			SELECT 1
+			FROM movie_genres mg
+			JOIN genres g ON g.id = mg.genre_id
+			WHERE mg.movie_id = m.id AND (g.id::text = ANY(?) OR g.slug = ANY(?)))`, genres)
+	}
+	if filter.Certification != "" {
+		addCondition("lower(certification) = lower(?)", filter.Certification)
+	}
+	if filter.Language != "" {
+		addCondition("lower(original_language) = lower(?)", filter.Language)
+	}
+	switch filter.Status {
+	case "":
+	case models.MovieStatusNowShowing:
+		conditions = append(conditions, `(release_date IS NULL OR release_date <= CURRENT_DATE)
+			AND EXISTS (SELECT 1 FROM showtimes s WHERE s.movie_id = m.id AND s.archived_at IS NULL
+				AND s.start_time >= CURRENT_TIMESTAMP)`)
+	case models.MovieStatusComingSoon:
+		conditions = append(conditions, "release_date > CURRENT_DATE")
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidFilter, filter.Status)
+	}
+
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	if err := repo.DB.QueryRow(ctx, `SELECT COUNT(*) FROM movies m WHERE `+where, args...).Scan(&total); err != nil {
+		log.Printf("error counting movies: %v", err)
+		return nil, fmt.Errorf("error counting movies: %w", err)
+	}
+
+	keyset := "TRUE"
+	if params.Cursor != "" {
+		var cursor movieCursor
+		if err := pagination.DecodeCursor(params.Cursor, &cursor); err != nil || cursor.Sort != sort {
+			return nil, pagination.ErrInvalidCursor
+		}
+
+		// JSON numbers decode as float64, so the value is converted back to
+		// the type of the sort key.
+		var value any
+		switch strings.TrimPrefix(sort, "-") {
+		case models.MovieSortTitle:
+			value, ok = cursor.Value.(string)
+		case models.MovieSortReleaseDate:
+			var date string
+			if date, ok = cursor.Value.(string); ok {
+				var err error
+				value, err = time.Parse("2006-01-02", date)
+				ok = err == nil
+			}
+		case models.MovieSortPopularity:
+			var popularity float64
+			popularity, ok = cursor.Value.(float64)
+			value = int64(popularity)
+		}
+		if !ok {
+			return nil, pagination.ErrInvalidCursor
+		}
+
+		operator := ">"
+		if descending {
+			operator = "<"
+		}
+		args = append(args, value, cursor.ID)
+		keyset = fmt.Sprintf("(%s, id) %s ($%d, $%d)", sortKey, operator, len(args)-1, len(args))
+	}
+
+	direction := "ASC"
+	if descending {
+		direction = "DESC"
+	}
+	args = append(args, params.Limit+1)
+
+	rows, err := repo.DB.Query(ctx, `
+		WITH filtered AS (
+			SELECT m.*, COALESCE(p.seats, 0) AS popularity
+			FROM movies m
+			LEFT JOIN (
+				SELECT movie_id, SUM(COALESCE(array_length(seats, 1), 0)) AS seats
+				FROM reservations
+				GROUP BY movie_id
+			) p ON p.movie_id = m.id
+			WHERE `+where+`
+		)
+		SELECT `+movieColumns+`, popularity
+		FROM filtered
+		WHERE `+keyset+`
+		ORDER BY `+sortKey+` `+direction+`, id `+direction+`
+		LIMIT $`+fmt.Sprint(len(args)), args...)
 	if err != nil {
 		log.Printf("error fetching movies: %v", err)
-		return nil, err
+		return nil, fmt.Errorf("error fetching movies: %w", err)
 	}
 	defer rows.Close()
 
 	var movies []models.Movie
+	popularity := make(map[uint]int64)
 	for rows.Next() {
 		var movie models.Movie
-		if err := rows.Scan(&movie.ID, &movie.Title, &movie.Description, &movie.Genre, &movie.PosterImage); err != nil {
-			return nil, err
+		var moviePopularity int64
+		if err := scanMovie(rows, &movie, &moviePopularity); err != nil {
+			return nil, fmt.Errorf("error scanning movie: %w", err)
 		}
+		popularity[movie.ID] = moviePopularity
 		movies = append(movies, movie)
 	}
-	return movies, nil
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	details := make([]*models.Movie, len(movies))
+	for i := range movies {
+		details[i] = &movies[i]
+	}
+	if err := repo.attachDetails(ctx, details...); err != nil {
+		return nil, err
+	}
+
+	return pagination.NewPage(movies, params, total, func(movie models.Movie) string {
+		cursor := movieCursor{Sort: sort, ID: int(movie.ID)}
+		switch strings.TrimPrefix(sort, "-") {
+		case models.MovieSortTitle:
+			cursor.Value = movie.Title
+		case models.MovieSortReleaseDate:
+			date := "0001-01-01"
+			if movie.ReleaseDate != nil {
+				date = movie.ReleaseDate.Format("2006-01-02")
+			}
+			cursor.Value = date
+		case models.MovieSortPopularity:
+			cursor.Value = popularity[movie.ID]
+		}
+		return pagination.EncodeCursor(cursor)
+	}), nil
 }
 
+// UpdateMovie replaces the fields of a movie. Its genres and credits are only
+// replaced when movie.Genres and movie.Credits are not nil.
 func (repo *MovieRepository) UpdateMovie(ctx context.Context, id int, movie *models.Movie) (*models.Movie, error) {
-	_, err := repo.DB.Exec(ctx, `
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := updateMovie(ctx, tx, id, movie); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return repo.GetMovieByID(ctx, id)
+}
+
+// updateMovie is UpdateMovie within tx. An empty external id and nil locked
+// fields keep the current ones. Upcoming showtimes of the movie are
+// rescheduled for its new runtime.
+func updateMovie(ctx context.Context, tx pgx.Tx, id int, movie *models.Movie) error {
+	if movie.Subtitles == nil {
+		movie.Subtitles = []string{}
+	}
+	tag, err := tx.Exec(ctx, `
 		UPDATE movies
-		SET title = $1, description = $2, genre = $3, poster_image = $4
-		WHERE id = $5`,
-		movie.Title, movie.Description, movie.Genre, movie.PosterImage, id)
+		SET title = $1, description = $2, poster_image = $3, release_date = $4,
+			runtime_minutes = $5, certification = NULLIF($6, ''), original_language = NULLIF($7, ''), subtitles = $8,
+			external_id = COALESCE(NULLIF($10, ''), external_id), locked_fields = COALESCE($11, locked_fields)
+		WHERE id = $9`,
+		movie.Title, movie.Description, movie.PosterImage, movie.ReleaseDate,
+		movie.RuntimeMinutes, movie.Certification, movie.OriginalLanguage, movie.Subtitles, id, movie.ExternalID, movie.LockedFields)
 	if err != nil {
+		return movieWriteError(err, "error updating movie")
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrMovieNotFound
+	}
+
+	if movie.Genres != nil {
+		if err := replaceGenres(ctx, tx, id, movie.Genres); err != nil {
+			return err
+		}
+	}
+	if movie.Credits != nil {
+		if err := replaceCredits(ctx, tx, id, movie.Credits); err != nil {
+			return err
+		}
+	}
+	if err := rescheduleShowtimes(ctx, tx, "s.movie_id = $1", id); err != nil {
+		return err
+	}
+	return refreshSearchDocuments(ctx, tx, "m.id = $1", id)
+}
+
+// GetMovieByExternalID returns the movie imported under externalID.
+func (repo *MovieRepository) GetMovieByExternalID(ctx context.Context, externalID string) (*models.Movie, error) {
+	var movie models.Movie
+	err := scanMovie(repo.DB.QueryRow(ctx, `
+		SELECT `+movieColumns+`
+		FROM movies
+		WHERE external_id = $1`, externalID), &movie)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMovieNotFound
+		}
+		return nil, err
+	}
+
+	if err := repo.attachDetails(ctx, &movie); err != nil {
+		return nil, err
+	}
+	return &movie, nil
+}
+
+// GetMovieByTitle returns the movie with exactly this title.
+func (repo *MovieRepository) GetMovieByTitle(ctx context.Context, title string) (*models.Movie, error) {
+	var movie models.Movie
+	err := scanMovie(repo.DB.QueryRow(ctx, `
+		SELECT `+movieColumns+`
+		FROM movies
+		WHERE title = $1`, title), &movie)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMovieNotFound
+		}
+		return nil, err
+	}
+
+	if err := repo.attachDetails(ctx, &movie); err != nil {
 		return nil, err
 	}
+	return &movie, nil
+}
 
-	var updatedMovie models.Movie
-	err = repo.DB.QueryRow(ctx, `
-		SELECT id, title, description, genre, poster_image
+// ExportMovies returns every movie that is not archived, with its genres and
+// credits, ordered by id.
+func (repo *MovieRepository) ExportMovies(ctx context.Context) ([]models.Movie, error) {
+	rows, err := repo.DB.Query(ctx, `
+		SELECT `+movieColumns+`
 		FROM movies
-		WHERE id = $1`, id).
-		Scan(&updatedMovie.ID, &updatedMovie.Title, &updatedMovie.Description, &updatedMovie.Genre, &updatedMovie.PosterImage)
+		WHERE archived_at IS NULL
+		ORDER BY id`)
 	if err != nil {
+		return nil, fmt.Errorf("error fetching movies: %w", err)
+	}
+	defer rows.Close()
+
+	movies := []models.Movie{}
+	for rows.Next() {
+		var movie models.Movie
+		if err := scanMovie(rows, &movie); err != nil {
+			return nil, fmt.Errorf("error scanning movie: %w", err)
+		}
+		movies = append(movies, movie)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	pointers := make([]*models.Movie, len(movies))
+	for i := range movies {
+		pointers[i] = &movies[i]
+	}
+	if err := repo.attachDetails(ctx, pointers...); err != nil {
 		return nil, err
 	}
+	return movies, nil
+}
 
-	return &updatedMovie, nil
+// ImportMovies creates or updates movies keyed by their external id, in one
+// transaction. Movies without an external id update the movie with their id,
+// which has to exist. Each movie gets its own savepoint, so a failing row does not
+// abort the others and the returned slice holds one error (or nil) per
+// movie. Nothing is committed for a dry run, nor for an atomic import with
+// a failed row; the returned bool reports whether the import was committed.
+func (repo *MovieRepository) ImportMovies(ctx context.Context, movies []models.Movie, opts models.ImportOptions) ([]error, bool, error) {
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, false, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	errs, err := eachInSavepoint(ctx, tx, len(movies), func(tx pgx.Tx, i int) error {
+		movie := &movies[i]
+		if movie.ExternalID == "" {
+			return updateMovie(ctx, tx, int(movie.ID), movie)
+		}
+		var id int
+		err := tx.QueryRow(ctx, "SELECT id FROM movies WHERE external_id = $1 FOR UPDATE", movie.ExternalID).Scan(&id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return insertMovie(ctx, tx, movie)
+		}
+		if err != nil {
+			return fmt.Errorf("error fetching movie: %w", err)
+		}
+		movie.ID = uint(id)
+		return updateMovie(ctx, tx, id, movie)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if opts.DryRun || (opts.Atomic && anyError(errs)) {
+		return errs, false, nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("error committing import: %w", err)
+	}
+	return errs, true, nil
 }
 
+func (repo *MovieRepository) GetMovieByID(ctx context.Context, id int) (*models.Movie, error) {
+	var movie models.Movie
+	err := scanMovie(repo.DB.QueryRow(ctx, `
+		SELECT `+movieColumns+`
+		FROM movies
+		WHERE id = $1`, id), &movie)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrMovieNotFound
+		}
+		return nil, err
+	}
+
+	if err := repo.attachDetails(ctx, &movie); err != nil {
+		return nil, err
+	}
+	return &movie, nil
+}
+
+// GetMoviesByIDs returns the given movies, archived or not, by id. Ids of
+// movies that do not exist are left out.
+func (repo *MovieRepository) GetMoviesByIDs(ctx context.Context, ids []int) (map[uint]*models.Movie, error) {
+	rows, err := repo.DB.Query(ctx, `
+		SELECT `+movieColumns+`
+		FROM movies
+		WHERE id = ANY($1)`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching movies: %w", err)
+	}
+	defer rows.Close()
+
+	var details []*models.Movie
+	for rows.Next() {
+		var movie models.Movie
+		if err := scanMovie(rows, &movie); err != nil {
+			return nil, fmt.Errorf("error scanning movie: %w", err)
+		}
+		details = append(details, &movie)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	if err := repo.attachDetails(ctx, details...); err != nil {
+		return nil, err
+	}
+	movies := make(map[uint]*models.Movie, len(details))
+	for _, movie := range details {
+		movies[movie.ID] = movie
+	}
+	return movies, nil
+}
+
+func movieWriteError(err error, message string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		if pgErr.ConstraintName == "movies_title_key" {
+			return ErrMovieExists
+		}
+		return ErrExternalIDExists
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+// replaceGenres sets the genres of a movie to exactly genres.
+func replaceGenres(ctx context.Context, tx pgx.Tx, movieID int, genres []models.Genre) error {
+	if _, err := tx.Exec(ctx, "DELETE FROM movie_genres WHERE movie_id = $1", movieID); err != nil {
+		return fmt.Errorf("error deleting genres: %w", err)
+	}
+
+	for _, genre := range genres {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO movie_genres (movie_id, genre_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING`, movieID, genre.ID)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+				return fmt.Errorf("%w: %d", ErrGenreNotFound, genre.ID)
+			}
+			return fmt.Errorf("error inserting genre: %w", err)
+		}
+	}
+	return nil
+}
+
+// replaceCredits sets the credits of a movie to exactly credits.
+func replaceCredits(ctx context.Context, tx pgx.Tx, movieID int, credits []models.Credit) error {
+	if _, err := tx.Exec(ctx, "DELETE FROM movie_credits WHERE movie_id = $1", movieID); err != nil {
+		return fmt.Errorf("error deleting credits: %w", err)
+	}
+
+	for _, credit := range credits {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO movie_credits (movie_id, person_id, role, character, position)
+			VALUES ($1, $2, $3, NULLIF($4, ''), $5)`,
+			movieID, credit.PersonID, credit.Role, credit.Character, credit.Position)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+				return fmt.Errorf("%w: %d", ErrPersonNotFound, credit.PersonID)
+			}
+			return fmt.Errorf("error inserting credit: %w", err)
+		}
+	}
+	return nil
+}
+
+// loadCredits returns the credits of the given movies, grouped by movie id
+// and ordered by role and position.
+func (repo *MovieRepository) loadCredits(ctx context.Context, ids []int) (map[uint][]models.Credit, error) {
+	credits := make(map[uint][]models.Credit, len(ids))
+	rows, err := repo.DB.Query(ctx, `
+		SELECT mc.movie_id, p.id, p.name, mc.role, COALESCE(mc.character, ''), mc.position
+		FROM movie_credits mc
+		JOIN people p ON p.id = mc.person_id
+		WHERE mc.movie_id = ANY($1)
+		ORDER BY mc.movie_id, mc.role, mc.position, p.name`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching credits: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var movieID uint
+		var credit models.Credit
+		if err := rows.Scan(&movieID, &credit.PersonID, &credit.Name, &credit.Role, &credit.Character, &credit.Position); err != nil {
+			return nil, fmt.Errorf("error scanning credit: %w", err)
+		}
+		credits[movieID] = append(credits[movieID], credit)
+	}
+	return credits, rows.Err()
+}
+
+// loadGenres returns the genres of the given movies, grouped by movie id and
+// ordered by name.
+func (repo *MovieRepository) loadGenres(ctx context.Context, ids []int) (map[uint][]models.Genre, error) {
+	genres := make(map[uint][]models.Genre, len(ids))
+	rows, err := repo.DB.Query(ctx, `
+		SELECT mg.movie_id, g.id, g.name, g.slug
+		FROM movie_genres mg
+		JOIN genres g ON g.id = mg.genre_id
+		WHERE mg.movie_id = ANY($1)
+		ORDER BY mg.movie_id, g.name`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching genres: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var movieID uint
+		var genre models.Genre
+		if err := rows.Scan(&movieID, &genre.ID, &genre.Name, &genre.Slug); err != nil {
+			return nil, fmt.Errorf("error scanning genre: %w", err)
+		}
+		genres[movieID] = append(genres[movieID], genre)
+	}
+	return genres, rows.Err()
+}
+
+type movieRating struct {
+	average float64
+	count   int
+}
+
+// loadRatings returns the average rating and the number of reviews of the
+// given movies by movie id, counting the reviews that are not hidden.
+func (repo *MovieRepository) loadRatings(ctx context.Context, ids []int) (map[uint]movieRating, error) {
+	ratings := make(map[uint]movieRating, len(ids))
+	rows, err := repo.DB.Query(ctx, `
+		SELECT movie_id, ROUND(AVG(rating), 2)::float8, COUNT(*)
+		FROM reviews
+		WHERE movie_id = ANY($1) AND status <> $2
+		GROUP BY movie_id`, ids, models.ReviewStatusHidden)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching ratings: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var movieID uint
+		var rating movieRating
+		if err := rows.Scan(&movieID, &rating.average, &rating.count); err != nil {
+			return nil, fmt.Errorf("error scanning rating: %w", err)
+		}
+		ratings[movieID] = rating
+	}
+	return ratings, rows.Err()
+}
+
+// attachDetails fills in the genres, credits and ratings of movies in place.
+// Every movie gets non-nil slices.
+func (repo *MovieRepository) attachDetails(ctx context.Context, movies ...*models.Movie) error {
+	if len(movies) == 0 {
+		return nil
+	}
+	ids := make([]int, len(movies))
+	for i, movie := range movies {
+		ids[i] = int(movie.ID)
+	}
+	genres, err := repo.loadGenres(ctx, ids)
+	if err != nil {
+		return err
+	}
+	credits, err := repo.loadCredits(ctx, ids)
+	if err != nil {
+		return err
+	}
+	ratings, err := repo.loadRatings(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, movie := range movies {
+		movie.AverageRating = ratings[movie.ID].average
+		movie.ReviewCount = ratings[movie.ID].count
+		movie.Genres = genres[movie.ID]
+		if movie.Genres == nil {
+			movie.Genres = []models.Genre{}
+		}
+		movie.Credits = credits[movie.ID]
+		if movie.Credits == nil {
+			movie.Credits = []models.Credit{}
+		}
+	}
+	return nil
+}
+
+// ArchiveMovie hides a movie from listings and stops new bookings for it.
+// Its showtimes and reservations are kept.
+func (repo *MovieRepository) ArchiveMovie(ctx context.Context, id int) error {
+	tag, err := repo.DB.Exec(ctx, "UPDATE movies SET archived_at = COALESCE(archived_at, NOW()) WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error archiving movie: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrMovieNotFound
+	}
+	return nil
+}
+
+func (repo *MovieRepository) RestoreMovie(ctx context.Context, id int) error {
+	tag, err := repo.DB.Exec(ctx, "UPDATE movies SET archived_at = NULL WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error restoring movie: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrMovieNotFound
+	}
+	return nil
+}
+
+// PurgeMovie deletes an archived movie for good, together with its
+// showtimes and reservations.
+func (repo *MovieRepository) PurgeMovie(ctx context.Context, id int) error {
+	var archived bool
+	err := repo.DB.QueryRow(ctx, "SELECT archived_at IS NOT NULL FROM movies WHERE id = $1", id).Scan(&archived)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrMovieNotFound
+		}
+		return fmt.Errorf("error fetching movie: %w", err)
+	}
+	if !archived {
+		return ErrNotArchived
+	}
+
+	if _, err := repo.DB.Exec(ctx, "DELETE FROM movies WHERE id = $1 AND archived_at IS NOT NULL", id); err != nil {
+		return fmt.Errorf("error purging movie: %w", err)
+	}
+	return nil
+}
+
+// searchDocumentUpdate rebuilds the full-text and trigram search columns
+// from the title, the names of the people credited, the genres and the
+// description, in every language they are translated into. Translations are
+// indexed without stemming, as their language can be anything. A WHERE
+// condition on m has to be appended.
+const searchDocumentUpdate = `
+	UPDATE movies m
+	SET search_vector =
+			setweight(to_tsvector('english', COALESCE(m.title, '')), 'A') ||
+			setweight(to_tsvector('simple', COALESCE(t.titles, '')), 'A') ||
+			setweight(to_tsvector('english', COALESCE(c.names, '')), 'B') ||
+			setweight(to_tsvector('english', COALESCE(g.names, '')), 'B') ||
+			setweight(to_tsvector('simple', COALESCE(g.translated, '')), 'B') ||
+			setweight(to_tsvector('english', COALESCE(m.description, '')), 'C') ||
+			setweight(to_tsvector('simple', COALESCE(t.descriptions, '')), 'C'),
+		search_text = lower(concat_ws(' ', m.title, t.titles, g.names, g.translated, c.names))
+	FROM (
+		SELECT mv.id, string_agg(p.name, ' ') AS names
+		FROM movies mv
+		LEFT JOIN movie_credits mc ON mc.movie_id = mv.id
+		LEFT JOIN people p ON p.id = mc.person_id
+		GROUP BY mv.id
+	) c, (
+		SELECT mv.id, string_agg(gn.name, ' ') AS names, string_agg(gt.names, ' ') AS translated
+		FROM movies mv
+		LEFT JOIN movie_genres mg ON mg.movie_id = mv.id
+		LEFT JOIN genres gn ON gn.id = mg.genre_id
+		LEFT JOIN (
+			SELECT genre_id, string_agg(name, ' ') AS names
+			FROM genre_translations
+			GROUP BY genre_id
+		) gt ON gt.genre_id = gn.id
+		GROUP BY mv.id
+	) g, (
+		SELECT mv.id, string_agg(mt.title, ' ') AS titles, string_agg(mt.description, ' ') AS descriptions
+		FROM movies mv
+		LEFT JOIN movie_translations mt ON mt.movie_id = mv.id
+		GROUP BY mv.id
+	) t
+	WHERE c.id = m.id AND g.id = m.id AND t.id = m.id AND `
+
+func refreshSearchDocuments(ctx context.Context, db execer, condition string, args ...any) error {
+	if _, err := db.Exec(ctx, searchDocumentUpdate+condition, args...); err != nil {
+		return fmt.Errorf("error refreshing search documents: %w", err)
+	}
+	return nil
+}
+
+// RefreshSearchDocuments rebuilds the search columns of a movie, or of all
+// movies when id is 0. It has to run whenever anything that is searched
+// changes.
+func (repo *MovieRepository) RefreshSearchDocuments(ctx context.Context, id int) error {
+	return refreshSearchDocuments(ctx, repo.DB, "($1 = 0 OR m.id = $1)", id)
+}
+
+// SearchMovies ranks movies against a free-text query. Full-text matches on
+// title, cast and crew, genres and description are combined with trigram
+// similarity so that partial words and typos still find the movie. Any
+// language a movie is translated into matches. Snippets come from the
+// description in the first of locales the movie is translated into, or else
+// the default one, and mark the matched words with <mark> tags.
+func (repo *MovieRepository) SearchMovies(ctx context.Context, query string, locales []string, params pagination.Params) (*pagination.Page[models.MovieSearchResult], error) {
+	// Relevance changes with every write, so search pages by position rather
+	// than by key.
+	var cursor searchCursor
+	if err := pagination.DecodeCursor(params.Cursor, &cursor); err != nil || cursor.Offset < 0 {
+		return nil, pagination.ErrInvalidCursor
+	}
+
+	const matches = `archived_at IS NULL AND
+		(search_vector @@ (websearch_to_tsquery('english', $1) || websearch_to_tsquery('simple', $1)) OR lower($1) <% search_text)`
+
+	var total int
+	if err := repo.DB.QueryRow(ctx, `SELECT COUNT(*) FROM movies WHERE `+matches, query).Scan(&total); err != nil {
+		return nil, fmt.Errorf("error counting search results: %w", err)
+	}
+
+	if locales == nil {
+		locales = []string{}
+	}
+	rows, err := repo.DB.Query(ctx, `
+		SELECT `+movieColumns+`,
+			ts_rank(search_vector, websearch_to_tsquery('english', $1) || websearch_to_tsquery('simple', $1)) +
+				word_similarity(lower($1), search_text) AS rank,
+			ts_headline(CASE WHEN tr.localized IS NULL THEN 'english' ELSE 'simple' END::regconfig,
+				COALESCE(tr.localized, description, ''),
+				websearch_to_tsquery('english', $1) || websearch_to_tsquery('simple', $1),
+				'StartSel=<mark>, StopSel=</mark>, MaxWords=25, MinWords=10, MaxFragments=2')
+		FROM movies
+		LEFT JOIN LATERAL (
+			SELECT NULLIF(mt.description, '') AS localized
+			FROM movie_translations mt
+			WHERE mt.movie_id = movies.id AND mt.locale = ANY($4)
+			ORDER BY array_position($4, mt.locale)
+			LIMIT 1
+		) tr ON true
+		WHERE `+matches+`
+		ORDER BY rank DESC, id
+		LIMIT $2 OFFSET $3`, query, params.Limit+1, cursor.Offset, locales)
+	if err != nil {
+		log.Printf("error searching movies: %v", err)
+		return nil, fmt.Errorf("error searching movies: %w", err)
+	}
+	defer rows.Close()
+
+	var results []models.MovieSearchResult
+	for rows.Next() {
+		var result models.MovieSearchResult
+		if err := scanMovie(rows, &result.Movie, &result.Rank, &result.Snippet); err != nil {
+			return nil, fmt.Errorf("error scanning search result: %w", err)
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	movies := make([]*models.Movie, len(results))
+	for i := range results {
+		movies[i] = &results[i].Movie
+	}
+	if err := repo.attachDetails(ctx, movies...); err != nil {
+		return nil, err
+	}
+
+	next := searchCursor{Offset: cursor.Offset + params.Limit}
+	return pagination.NewPage(results, params, total, func(models.MovieSearchResult) string {
+		return pagination.EncodeCursor(next)
+	}), nil
+}
 
-func (repo *MovieRepository) DeleteMovie(ctx context.Context, id int) error {
-	_, err := repo.DB.Exec(ctx, "DELETE FROM movies WHERE id = $1", id)
-	return err
+// searchCursor is the position of the next page of search results.
+type searchCursor struct {
+	Offset int `json:"offset"`
 }
@@ -0,0 +1,331 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"movie-system/internal/models"
+	"movie-system/internal/pagination"
+	"movie-system/test"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMovieRepository(t *testing.T) {
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewMovieRepository(db)
+	genres := NewGenreRepository(db)
+	ctx := context.Background()
+
+	var sciFi, action models.Genre
+	insertMovies := func(t *testing.T) {
+		err := test.ClearTestDB(db)
+		assert.NoError(t, err)
+
+		sciFi = models.Genre{Name: "Sci-Fi"}
+		action = models.Genre{Name: "Action"}
+		assert.NoError(t, genres.InsertGenre(ctx, &sciFi))
+		assert.NoError(t, genres.InsertGenre(ctx, &action))
+
+		for _, movie := range []models.Movie{
+			{Title: "Inception", Description: "A thief who steals corporate secrets through dream-sharing technology.", Genres: []models.Genre{sciFi, action}},
+			{Title: "The Dark Knight", Description: "When the menace known as the Joker emerges, Batman must confront chaos.", Genres: []models.Genre{action}},
+			{Title: "Interstellar", Description: "Explorers travel through a wormhole in space.", Genres: []models.Genre{sciFi}},
+		} {
+			err := repo.InsertMovie(ctx, &movie)
+			assert.NoError(t, err)
+			assert.NotZero(t, movie.ID)
+		}
+	}
+
+	t.Run("ListMoviesPages", func(t *testing.T) {
+		insertMovies(t)
+
+		page, err := repo.ListMovies(ctx, models.MovieFilter{}, pagination.Params{Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, page.Total)
+		assert.Equal(t, "Inception", page.Items[0].Title)
+		assert.Equal(t, "Interstellar", page.Items[1].Title)
+		if assert.NotNil(t, page.NextCursor) {
+			page, err = repo.ListMovies(ctx, models.MovieFilter{}, pagination.Params{Limit: 2, Cursor: *page.NextCursor})
+			assert.NoError(t, err)
+			assert.Len(t, page.Items, 1)
+			assert.Equal(t, "The Dark Knight", page.Items[0].Title)
+			assert.Nil(t, page.NextCursor)
+		}
+
+		page, err = repo.ListMovies(ctx, models.MovieFilter{Genres: []string{"sci-fi"}, Sort: "-title"}, pagination.Params{Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, page.Total)
+		assert.Equal(t, "Interstellar", page.Items[0].Title)
+		assert.Equal(t, []models.Genre{sciFi}, page.Items[0].Genres)
+
+		page, err = repo.ListMovies(ctx, models.MovieFilter{Genres: []string{"sci"}}, pagination.Params{Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, page.Total)
+
+		page, err = repo.ListMovies(ctx, models.MovieFilter{Genres: []string{fmt.Sprint(action.ID)}}, pagination.Params{Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, page.Total)
+
+		_, err = repo.ListMovies(ctx, models.MovieFilter{Sort: "budget"}, pagination.Params{Limit: 10})
+		assert.ErrorIs(t, err, ErrInvalidFilter)
+	})
+
+	t.Run("SearchMovies", func(t *testing.T) {
+		insertMovies(t)
+
+		results, err := repo.SearchMovies(ctx, "incepton", nil, pagination.Params{Limit: 10})
+		assert.NoError(t, err)
+		if assert.NotEmpty(t, results.Items) {
+			assert.Equal(t, "Inception", results.Items[0].Title)
+		}
+
+		results, err = repo.SearchMovies(ctx, "dreams", nil, pagination.Params{Limit: 10})
+		assert.NoError(t, err)
+		if assert.Len(t, results.Items, 1) {
+			assert.Contains(t, results.Items[0].Snippet, "<mark>dream")
+		}
+
+		movie := results.Items[0].Movie
+		movie.Description = "A heist inside the subconscious."
+		_, err = repo.UpdateMovie(ctx, int(movie.ID), &movie)
+		assert.NoError(t, err)
+
+		results, err = repo.SearchMovies(ctx, "subconscious", nil, pagination.Params{Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, results.Items, 1)
+	})
+
+	t.Run("Credits", func(t *testing.T) {
+		insertMovies(t)
+		people := NewPersonRepository(db)
+
+		leo := models.Person{Name: "Leonardo DiCaprio"}
+		nolan := models.Person{Name: "Christopher Nolan"}
+		assert.NoError(t, people.InsertPerson(ctx, &leo))
+		assert.NoError(t, people.InsertPerson(ctx, &nolan))
+
+		movie := models.Movie{
+			Title:          "Shutter Island",
+			RuntimeMinutes: 138,
+			Certification:  "R",
+			Subtitles:      []string{"en", "de"},
+			Credits: []models.Credit{
+				{PersonID: leo.ID, Role: models.CreditRoleCast, Character: "Teddy Daniels"},
+				{PersonID: nolan.ID, Role: models.CreditRoleDirector},
+			},
+		}
+		assert.NoError(t, repo.InsertMovie(ctx, &movie))
+
+		saved, err := repo.GetMovieByID(ctx, int(movie.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, 138, saved.RuntimeMinutes)
+		assert.Equal(t, []string{"en", "de"}, saved.Subtitles)
+		if assert.Len(t, saved.Credits, 2) {
+			assert.Equal(t, "Leonardo DiCaprio", saved.Credits[0].Name)
+			assert.Equal(t, "Teddy Daniels", saved.Credits[0].Character)
+		}
+
+		results, err := repo.SearchMovies(ctx, "dicaprio", nil, pagination.Params{Limit: 10})
+		assert.NoError(t, err)
+		if assert.Len(t, results.Items, 1) {
+			assert.Equal(t, "Shutter Island", results.Items[0].Title)
+		}
+
+		_, err = people.UpdatePerson(ctx, int(leo.ID), "Leo DiCaprio")
+		assert.NoError(t, err)
+		results, err = repo.SearchMovies(ctx, "leo", nil, pagination.Params{Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, results.Items, 1)
+
+		assert.NoError(t, people.DeletePerson(ctx, int(nolan.ID)))
+		saved, err = repo.GetMovieByID(ctx, int(movie.ID))
+		assert.NoError(t, err)
+		assert.Len(t, saved.Credits, 1)
+
+		movie.Credits = []models.Credit{{PersonID: 9999, Role: models.CreditRoleCast}}
+		_, err = repo.UpdateMovie(ctx, int(movie.ID), &movie)
+		assert.ErrorIs(t, err, ErrPersonNotFound)
+	})
+
+	t.Run("Genres", func(t *testing.T) {
+		insertMovies(t)
+
+		assert.Equal(t, "sci-fi", sciFi.Slug)
+		err := genres.InsertGenre(ctx, &models.Genre{Name: "sci fi"})
+		assert.ErrorIs(t, err, ErrGenreExists)
+
+		_, err = genres.UpdateGenre(ctx, int(action.ID), "Action & Adventure")
+		assert.NoError(t, err)
+		page, err := repo.ListMovies(ctx, models.MovieFilter{Genres: []string{"action-adventure"}}, pagination.Params{Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, page.Total)
+
+		results, err := repo.SearchMovies(ctx, "adventure", nil, pagination.Params{Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, results.Items, 2)
+
+		assert.NoError(t, genres.DeleteGenre(ctx, int(action.ID)))
+		movie, err := repo.GetMovieByID(ctx, int(page.Items[0].ID))
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Genre{sciFi}, movie.Genres)
+
+		movie.Genres = []models.Genre{{ID: 9999}}
+		_, err = repo.UpdateMovie(ctx, int(movie.ID), movie)
+		assert.ErrorIs(t, err, ErrGenreNotFound)
+	})
+
+	t.Run("Archive", func(t *testing.T) {
+		insertMovies(t)
+
+		page, err := repo.ListMovies(ctx, models.MovieFilter{}, pagination.Params{Limit: 10})
+		assert.NoError(t, err)
+		inception := page.Items[0]
+
+		assert.ErrorIs(t, repo.PurgeMovie(ctx, int(inception.ID)), ErrNotArchived)
+		assert.NoError(t, repo.ArchiveMovie(ctx, int(inception.ID)))
+
+		page, err = repo.ListMovies(ctx, models.MovieFilter{}, pagination.Params{Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, page.Total)
+		results, err := repo.SearchMovies(ctx, "inception", nil, pagination.Params{Limit: 10})
+		assert.NoError(t, err)
+		assert.Empty(t, results.Items)
+
+		page, err = repo.ListMovies(ctx, models.MovieFilter{Archived: true}, pagination.Params{Limit: 10})
+		assert.NoError(t, err)
+		if assert.Len(t, page.Items, 1) {
+			assert.Equal(t, "Inception", page.Items[0].Title)
+			assert.NotNil(t, page.Items[0].ArchivedAt)
+		}
+
+		assert.NoError(t, repo.RestoreMovie(ctx, int(inception.ID)))
+		page, err = repo.ListMovies(ctx, models.MovieFilter{}, pagination.Params{Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, page.Total)
+
+		assert.NoError(t, repo.ArchiveMovie(ctx, int(inception.ID)))
+		assert.NoError(t, repo.PurgeMovie(ctx, int(inception.ID)))
+		_, err = repo.GetMovieByID(ctx, int(inception.ID))
+		assert.ErrorIs(t, err, ErrMovieNotFound)
+	})
+
+	t.Run("Import", func(t *testing.T) {
+		insertMovies(t)
+
+		movies := func() []models.Movie {
+			return []models.Movie{
+				{ExternalID: "tt1375666", Title: "Inception (2010)", Genres: []models.Genre{sciFi}},
+				{ExternalID: "tt0133093", Title: "The Matrix"},
+				{ExternalID: "tt0816692", Title: "The Dark Knight"},
+			}
+		}
+		inception, err := repo.SearchMovies(ctx, "inception", nil, pagination.Params{Limit: 1})
+		assert.NoError(t, err)
+		inceptionID := int(inception.Items[0].ID)
+		_, err = repo.UpdateMovie(ctx, inceptionID, &models.Movie{ExternalID: "tt1375666", Title: "Inception"})
+		assert.NoError(t, err)
+
+		// The third row clashes with an existing title; in a dry run nothing
+		// is saved either way
+		errs, committed, err := repo.ImportMovies(ctx, movies(), models.ImportOptions{DryRun: true})
+		assert.NoError(t, err)
+		assert.False(t, committed)
+		assert.NoError(t, errs[0])
+		assert.NoError(t, errs[1])
+		assert.ErrorIs(t, errs[2], ErrMovieExists)
+		_, err = repo.GetMovieByExternalID(ctx, "tt0133093")
+		assert.ErrorIs(t, err, ErrMovieNotFound)
+
+		_, committed, err = repo.ImportMovies(ctx, movies(), models.ImportOptions{Atomic: true})
+		assert.NoError(t, err)
+		assert.False(t, committed)
+		_, err = repo.GetMovieByExternalID(ctx, "tt0133093")
+		assert.ErrorIs(t, err, ErrMovieNotFound)
+
+		// Without atomic the valid rows are saved
+		imported := movies()
+		errs, committed, err = repo.ImportMovies(ctx, imported, models.ImportOptions{})
+		assert.NoError(t, err)
+		assert.True(t, committed)
+		assert.Error(t, errs[2])
+		assert.Equal(t, uint(inceptionID), imported[0].ID)
+
+		movie, err := repo.GetMovieByID(ctx, inceptionID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Inception (2010)", movie.Title)
+		assert.Equal(t, []models.Genre{sciFi}, movie.Genres)
+		matrix, err := repo.GetMovieByExternalID(ctx, "tt0133093")
+		assert.NoError(t, err)
+		assert.Equal(t, "The Matrix", matrix.Title)
+
+		exported, err := repo.ExportMovies(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, exported, 4)
+	})
+
+	t.Run("Translations", func(t *testing.T) {
+		insertMovies(t)
+		translations := NewTranslationRepository(db)
+
+		results, err := repo.SearchMovies(ctx, "inception", nil, pagination.Params{Limit: 1})
+		assert.NoError(t, err)
+		inceptionID := int(results.Items[0].ID)
+		assert.NoError(t, translations.SetMovieTranslation(ctx, inceptionID, &models.MovieTranslation{
+			Locale: "ru", Title: "Начало", Description: "Вор, который крадет секреты через сны.",
+		}))
+		assert.NoError(t, translations.SetGenreTranslation(ctx, int(sciFi.ID), &models.GenreTranslation{Locale: "ru", Name: "Фантастика"}))
+
+		// Translations are searched in every language, and the snippet comes
+		// from the preferred one
+		results, err = repo.SearchMovies(ctx, "начало", []string{"ru"}, pagination.Params{Limit: 10})
+		assert.NoError(t, err)
+		if assert.Len(t, results.Items, 1) {
+			assert.Equal(t, uint(inceptionID), results.Items[0].ID)
+			assert.Contains(t, results.Items[0].Snippet, "Вор")
+		}
+		results, err = repo.SearchMovies(ctx, "фантастика", nil, pagination.Params{Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, results.Items, 2)
+
+		preferred, err := translations.PreferredMovieTranslations(ctx, []int{inceptionID}, []string{"de", "ru"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Начало", preferred[uint(inceptionID)].Title)
+
+		assert.NoError(t, translations.DeleteMovieTranslation(ctx, inceptionID, "ru"))
+		assert.ErrorIs(t, translations.DeleteMovieTranslation(ctx, inceptionID, "ru"), ErrTranslationNotFound)
+		results, err = repo.SearchMovies(ctx, "начало", nil, pagination.Params{Limit: 10})
+		assert.NoError(t, err)
+		assert.Empty(t, results.Items)
+
+		err = translations.SetMovieTranslation(ctx, 0, &models.MovieTranslation{Locale: "ru", Title: "Ничто"})
+		assert.ErrorIs(t, err, ErrMovieNotFound)
+	})
+
+	t.Run("LockedFields", func(t *testing.T) {
+		insertMovies(t)
+
+		movie := &models.Movie{Title: "Heat"}
+		assert.NoError(t, repo.InsertMovie(ctx, movie))
+		assert.Equal(t, []string{}, movie.LockedFields)
+
+		_, err := repo.UpdateMovie(ctx, int(movie.ID), &models.Movie{Title: "Heat", LockedFields: []string{"description"}})
+		assert.NoError(t, err)
+		// Nil keeps the current locks
+		updated, err := repo.UpdateMovie(ctx, int(movie.ID), &models.Movie{Title: "Heat", Description: "Our synopsis."})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"description"}, updated.LockedFields)
+
+		found, err := repo.GetMovieByTitle(ctx, "Heat")
+		assert.NoError(t, err)
+		assert.Equal(t, movie.ID, found.ID)
+		_, err = repo.GetMovieByTitle(ctx, "heat")
+		assert.ErrorIs(t, err, ErrMovieNotFound)
+	})
+}
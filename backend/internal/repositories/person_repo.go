@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie-system/internal/models"
+	"movie-system/internal/pagination"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PersonRepository manages the people that can be credited on movies.
+type PersonRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewPersonRepository(db *pgxpool.Pool) *PersonRepository {
+	return &PersonRepository{DB: db}
+}
+
+// ListPeople returns a page of people ordered by id, optionally only those
+// whose name contains search.
+func (repo *PersonRepository) ListPeople(ctx context.Context, search string, params pagination.Params) (*pagination.Page[models.Person], error) {
+	afterID, err := pagination.AfterID(params)
+	if err != nil {
+		return nil, err
+	}
+
+	const matches = `($1 = '' OR name ILIKE '%' || $1 || '%')`
+
+	var total int
+	if err := repo.DB.QueryRow(ctx, `SELECT COUNT(*) FROM people WHERE `+matches, search).Scan(&total); err != nil {
+		return nil, fmt.Errorf("error counting people: %w", err)
+	}
+
+	rows, err := repo.DB.Query(ctx, `
+		SELECT id, name, created_at
+		FROM people
+		WHERE `+matches+` AND id > $2
+		ORDER BY id
+		LIMIT $3`, search, afterID, params.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching people: %w", err)
+	}
+	defer rows.Close()
+
+	var people []models.Person
+	for rows.Next() {
+		var person models.Person
+		if err := rows.Scan(&person.ID, &person.Name, &person.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning person: %w", err)
+		}
+		people = append(people, person)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return pagination.NewPage(people, params, total, func(person models.Person) string {
+		return pagination.IDCursorOf(person.ID)
+	}), nil
+}
+
+func (repo *PersonRepository) InsertPerson(ctx context.Context, person *models.Person) error {
+	err := repo.DB.QueryRow(ctx, `
+		INSERT INTO people (name)
+		VALUES ($1)
+		RETURNING id, created_at`, person.Name).Scan(&person.ID, &person.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error inserting person: %w", err)
+	}
+	return nil
+}
+
+func (repo *PersonRepository) GetPersonByID(ctx context.Context, id int) (*models.Person, error) {
+	var person models.Person
+	err := repo.DB.QueryRow(ctx, "SELECT id, name, created_at FROM people WHERE id = $1", id).Scan(&person.ID, &person.Name, &person.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPersonNotFound
+		}
+		return nil, fmt.Errorf("error fetching person: %w", err)
+	}
+	return &person, nil
+}
+
+// FindPersonByName returns the oldest person with exactly this name. Names
+// are not unique, so this is only meant for matching imported credits.
+func (repo *PersonRepository) FindPersonByName(ctx context.Context, name string) (*models.Person, error) {
+	var person models.Person
+	err := repo.DB.QueryRow(ctx, "SELECT id, name, created_at FROM people WHERE name = $1 ORDER BY id LIMIT 1", name).Scan(&person.ID, &person.Name, &person.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPersonNotFound
+		}
+		return nil, fmt.Errorf("error fetching person: %w", err)
+	}
+	return &person, nil
+}
+
+// UpdatePerson renames a person and refreshes the search documents of the
+// movies they are credited on.
+func (repo *PersonRepository) UpdatePerson(ctx context.Context, id int, name string) (*models.Person, error) {
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var person models.Person
+	err = tx.QueryRow(ctx, `
+		UPDATE people
+		SET name = $1
+		WHERE id = $2
+		RETURNING id, name, created_at`, name, id).Scan(&person.ID, &person.Name, &person.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPersonNotFound
+		}
+		return nil, fmt.Errorf("error updating person: %w", err)
+	}
+
+	if err := refreshSearchDocuments(ctx, tx, "m.id IN (SELECT movie_id FROM movie_credits WHERE person_id = $1)", id); err != nil {
+		return nil, err
+	}
+	return &person, tx.Commit(ctx)
+}
+
+// DeletePerson removes a person together with their credits.
+func (repo *PersonRepository) DeletePerson(ctx context.Context, id int) error {
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, "DELETE FROM movie_credits WHERE person_id = $1 RETURNING movie_id", id)
+	if err != nil {
+		return fmt.Errorf("error deleting credits: %w", err)
+	}
+	movieIDs, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return fmt.Errorf("error deleting credits: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, "DELETE FROM people WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error deleting person: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrPersonNotFound
+	}
+
+	if err := refreshSearchDocuments(ctx, tx, "m.id = ANY($1)", movieIDs); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,199 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie-system/internal/models"
+	"movie-system/internal/site"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PrivacyRepository collects and erases the personal data of a user across
+// all tables for data subject requests.
+type PrivacyRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewPrivacyRepository(db *pgxpool.Pool) *PrivacyRepository {
+	return &PrivacyRepository{DB: db}
+}
+
+func (repo *PrivacyRepository) GetReservations(ctx context.Context, userID int) ([]models.ReservationExport, error) {
+	rows, err := repo.DB.Query(ctx, `
+		SELECT r.id, COALESCE(m.title, ''), s.start_time, COALESCE(c.timezone, ''), r.seats, r.seat_price,
+			COALESCE(array_length(r.seats, 1), 0) * r.seat_price, r.created_at
+		FROM reservations r
+		LEFT JOIN movies m ON r.movie_id = m.id
+		LEFT JOIN showtimes s ON r.showtime_id = s.id
+		LEFT JOIN cinemas c ON s.cinema_id = c.id
+		WHERE r.user_id = $1
+		ORDER BY r.created_at`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching reservations: %w", err)
+	}
+	defer rows.Close()
+
+	reservations := []models.ReservationExport{}
+	for rows.Next() {
+		var reservation models.ReservationExport
+		var zone string
+		if err := rows.Scan(&reservation.ID, &reservation.MovieTitle, &reservation.ShowtimeStart, &zone, &reservation.Seats, &reservation.SeatPrice, &reservation.Total, &reservation.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning reservation: %w", err)
+		}
+		reservation.ShowtimeStart = site.Local(reservation.ShowtimeStart, zone)
+		reservations = append(reservations, reservation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+	return reservations, nil
+}
+
+func (repo *PrivacyRepository) GetLinkedIdentities(ctx context.Context, userID int) ([]models.LinkedIdentityExport, error) {
+	rows, err := repo.DB.Query(ctx, `
+		SELECT provider, subject, COALESCE(email, ''), created_at
+		FROM user_identities
+		WHERE user_id = $1
+		ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching identities: %w", err)
+	}
+	defer rows.Close()
+
+	identities := []models.LinkedIdentityExport{}
+	for rows.Next() {
+		var identity models.LinkedIdentityExport
+		if err := rows.Scan(&identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning identity: %w", err)
+		}
+		identities = append(identities, identity)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+	return identities, nil
+}
+
+func (repo *PrivacyRepository) GetLoginHistory(ctx context.Context, username string) ([]models.LoginAttempt, error) {
+	rows, err := repo.DB.Query(ctx, `
+		SELECT id, username, ip, success, result, created_at
+		FROM login_attempts
+		WHERE username = $1
+		ORDER BY created_at`, username)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching login attempts: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := []models.LoginAttempt{}
+	for rows.Next() {
+		var attempt models.LoginAttempt
+		if err := rows.Scan(&attempt.ID, &attempt.Username, &attempt.IP, &attempt.Success, &attempt.Result, &attempt.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning login attempt: %w", err)
+		}
+		attempts = append(attempts, attempt)
+	}
+	return attempts, rows.Err()
+}
+
+// GetReviews returns the reviews written by a user, hidden ones included.
+func (repo *PrivacyRepository) GetReviews(ctx context.Context, userID int) ([]models.Review, error) {
+	return NewReviewRepository(repo.DB).ListUserReviews(ctx, userID)
+}
+
+// GetAuditEntries returns the audit log entries of the changes username made,
+// oldest first.
+func (repo *PrivacyRepository) GetAuditEntries(ctx context.Context, username string) ([]models.AuditEntry, error) {
+	rows, err := repo.DB.Query(ctx, `
+		SELECT `+auditColumns+`
+		FROM audit_log
+		WHERE actor = $1
+		ORDER BY id`, username)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		if err := scanAuditEntry(rows, &entry); err != nil {
+			return nil, fmt.Errorf("error scanning audit entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+	return entries, nil
+}
+
+// EraseUser anonymizes the account in place. The users row is kept so that
+// reservations still point at a (now anonymous) customer and revenue reports
+// stay correct; everything that identifies the person is removed.
+func (repo *PrivacyRepository) EraseUser(ctx context.Context, userID int) error {
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var username, anonymous string
+	err = tx.QueryRow(ctx, `
+		SELECT username, 'deleted-user-' || id
+		FROM users
+		WHERE id = $1 AND erased_at IS NULL
+		FOR UPDATE`, userID).Scan(&username, &anonymous)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("error fetching user: %w", err)
+	}
+
+	// '!' is not a valid bcrypt hash, so no password can ever match it.
+	_, err = tx.Exec(ctx, `
+		UPDATE users
+		SET username = $2, email = NULL, email_verified = FALSE, password_hash = '!',
+			disabled = TRUE, totp_secret = NULL, totp_enabled = FALSE,
+			display_name = NULL, phone = NULL, marketing_consent = FALSE,
+			erased_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`, userID, anonymous)
+	if err != nil {
+		return fmt.Errorf("error anonymizing user: %w", err)
+	}
+
+	for _, table := range []string{"user_tokens", "user_recovery_codes", "user_identities", "reviews", "review_reports", "recommendations"} {
+		if _, err := tx.Exec(ctx, "DELETE FROM "+table+" WHERE user_id = $1", userID); err != nil {
+			return fmt.Errorf("error deleting from %s: %w", table, err)
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE login_attempts
+		SET username = $2, ip = ''
+		WHERE username = $1`, username, anonymous)
+	if err != nil {
+		return fmt.Errorf("error anonymizing login attempts: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE api_keys SET created_by = $2 WHERE created_by = $1", username, anonymous)
+	if err != nil {
+		return fmt.Errorf("error anonymizing api keys: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE audit_log SET actor = $2 WHERE actor = $1", username, anonymous)
+	if err != nil {
+		return fmt.Errorf("error anonymizing audit log: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE reviews SET moderated_by = $2 WHERE moderated_by = $1", username, anonymous)
+	if err != nil {
+		return fmt.Errorf("error anonymizing review moderation: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,200 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"movie-system/internal/models"
+	"movie-system/internal/recommend"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RecommendationRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewRecommendationRepository(db *pgxpool.Pool) *RecommendationRepository {
+	return &RecommendationRepository{DB: db}
+}
+
+// LoadInput reads the booking history of the users who have not been erased,
+// the genres of all movies, the seats sold since popularSince and the movies
+// with upcoming bookable showtimes.
+func (repo *RecommendationRepository) LoadInput(ctx context.Context, popularSince time.Time) (*recommend.Input, error) {
+	in := &recommend.Input{
+		Bookings:   make(map[int][]int),
+		Genres:     make(map[int][]int),
+		Popularity: make(map[int]float64),
+	}
+
+	err := repo.scanPairs(ctx, `
+		SELECT DISTINCT r.user_id, r.movie_id
+		FROM reservations r
+		JOIN users u ON u.id = r.user_id
+		WHERE u.erased_at IS NULL AND r.movie_id IS NOT NULL`, nil, func(user, movie int) {
+		in.Bookings[user] = append(in.Bookings[user], movie)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching bookings: %w", err)
+	}
+
+	err = repo.scanPairs(ctx, "SELECT movie_id, genre_id FROM movie_genres", nil, func(movie, genre int) {
+		in.Genres[movie] = append(in.Genres[movie], genre)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching genres: %w", err)
+	}
+
+	err = repo.scanPairs(ctx, `
+		SELECT movie_id, SUM(COALESCE(array_length(seats, 1), 0))::int
+		FROM reservations
+		WHERE movie_id IS NOT NULL AND created_at >= $1
+		GROUP BY movie_id`, []any{popularSince}, func(movie, seats int) {
+		in.Popularity[movie] = float64(seats)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching popularity: %w", err)
+	}
+
+	err = repo.scanPairs(ctx, `
+		SELECT DISTINCT s.movie_id, 0
+		FROM showtimes s
+		WHERE `+bookableShowtime+` AND s.start_time > NOW() AND s.capacity > `+bookedSeats+`
+		ORDER BY s.movie_id`, nil, func(movie, _ int) {
+		in.Candidates = append(in.Candidates, movie)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching candidates: %w", err)
+	}
+	return in, nil
+}
+
+// scanPairs calls fn with the two integer columns of each row of query.
+func (repo *RecommendationRepository) scanPairs(ctx context.Context, query string, args []any, fn func(a, b int)) error {
+	rows, err := repo.DB.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a, b int
+		if err := rows.Scan(&a, &b); err != nil {
+			return err
+		}
+		fn(a, b)
+	}
+	return rows.Err()
+}
+
+// Store replaces the stored recommendations with model. Suggestions for users
+// or movies deleted while the model was computed are dropped.
+func (repo *RecommendationRepository) Store(ctx context.Context, model *recommend.Model) error {
+	var users, movies, similarTo []int
+	var scores []float64
+	var reasons []string
+	for user, suggestions := range model.Users {
+		for _, suggestion := range suggestions {
+			users = append(users, user)
+			movies = append(movies, suggestion.MovieID)
+			scores = append(scores, suggestion.Score)
+			reasons = append(reasons, suggestion.Reason)
+			similarTo = append(similarTo, suggestion.SimilarTo)
+		}
+	}
+	var popularMovies []int
+	var popularScores []float64
+	for _, suggestion := range model.Popular {
+		popularMovies = append(popularMovies, suggestion.MovieID)
+		popularScores = append(popularScores, suggestion.Score)
+	}
+
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "DELETE FROM recommendations"); err != nil {
+		return fmt.Errorf("error clearing recommendations: %w", err)
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO recommendations (user_id, movie_id, score, reason, similar_to)
+		SELECT t.user_id, t.movie_id, t.score, t.reason, s.id
+		FROM unnest($1::int[], $2::int[], $3::float8[], $4::text[], $5::int[]) AS t(user_id, movie_id, score, reason, similar_to)
+		JOIN users u ON u.id = t.user_id
+		JOIN movies m ON m.id = t.movie_id
+		LEFT JOIN movies s ON s.id = t.similar_to`,
+		users, movies, scores, reasons, similarTo)
+	if err != nil {
+		return fmt.Errorf("error storing recommendations: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, "DELETE FROM popular_movies"); err != nil {
+		return fmt.Errorf("error clearing popular movies: %w", err)
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO popular_movies (movie_id, score)
+		SELECT t.movie_id, t.score
+		FROM unnest($1::int[], $2::float8[]) AS t(movie_id, score)
+		JOIN movies m ON m.id = t.movie_id`,
+		popularMovies, popularScores)
+	if err != nil {
+		return fmt.Errorf("error storing popular movies: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetForUser returns the stored suggestions for a user, best first.
+func (repo *RecommendationRepository) GetForUser(ctx context.Context, userID int) ([]recommend.Suggestion, error) {
+	return repo.getSuggestions(ctx, `
+		SELECT movie_id, score, reason, COALESCE(similar_to, 0)
+		FROM recommendations
+		WHERE user_id = $1
+		ORDER BY score DESC, movie_id`, userID)
+}
+
+// GetPopular returns the stored popular movies, most popular first.
+func (repo *RecommendationRepository) GetPopular(ctx context.Context) ([]recommend.Suggestion, error) {
+	return repo.getSuggestions(ctx, `
+		SELECT movie_id, score, $1::text, 0
+		FROM popular_movies
+		ORDER BY score DESC, movie_id`, models.RecommendationReasonPopular)
+}
+
+func (repo *RecommendationRepository) getSuggestions(ctx context.Context, query string, args ...any) ([]recommend.Suggestion, error) {
+	rows, err := repo.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching recommendations: %w", err)
+	}
+	defer rows.Close()
+
+	var suggestions []recommend.Suggestion
+	for rows.Next() {
+		var suggestion recommend.Suggestion
+		if err := rows.Scan(&suggestion.MovieID, &suggestion.Score, &suggestion.Reason, &suggestion.SimilarTo); err != nil {
+			return nil, fmt.Errorf("error scanning recommendation: %w", err)
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions, rows.Err()
+}
+
+// BookedMovies returns the ids of the movies a user has reservations for,
+// including ones made since the recommendations were computed.
+func (repo *RecommendationRepository) BookedMovies(ctx context.Context, userID int) (map[int]bool, error) {
+	booked := make(map[int]bool)
+	err := repo.scanPairs(ctx, `
+		SELECT DISTINCT movie_id, 0
+		FROM reservations
+		WHERE user_id = $1 AND movie_id IS NOT NULL`, []any{userID}, func(movie, _ int) {
+		booked[movie] = true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching booked movies: %w", err)
+	}
+	return booked, nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"movie-system/internal/models"
+	"movie-system/internal/pagination"
 	"strings"
 	"time"
 
@@ -13,6 +14,10 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrShowtimeUnavailable is returned when booking a showtime that is archived,
+// belongs to an archived movie or has already started.
+var ErrShowtimeUnavailable = errors.New("showtime is no longer available for booking")
+
 type ReservationRepository struct {
 	DB *pgxpool.Pool
 }
@@ -42,6 +47,28 @@ func (r *ReservationRepository) ReserveSeat(ctx context.Context, reservation *mo
 		}
 	}()
 
+	// Locking the showtime keeps it from being archived halfway through. The
+	// seats cost what the cinema charges now, whatever it charges later, and
+	// are for the movie the showtime plays, whatever the client claims.
+	var bookable bool
+	err = tx.QueryRow(ctx, `
+		SELECT `+bookableShowtime+` AND s.start_time > NOW(), s.movie_id, c.seat_price
+		FROM showtimes s
+		JOIN cinemas c ON c.id = s.cinema_id
+		WHERE s.id = $1
+		FOR UPDATE OF s`, reservation.ShowtimeID).Scan(&bookable, &reservation.MovieID, &reservation.SeatPrice)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = ErrShowtimeNotFound
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("error checking showtime: %w", err)
+	}
+	if !bookable {
+		err = ErrShowtimeUnavailable
+		return err
+	}
+
 	checkSeatsQuery := `
 		SELECT COUNT(*)
 		FROM reservations
@@ -58,12 +85,12 @@ func (r *ReservationRepository) ReserveSeat(ctx context.Context, reservation *mo
 	}
 
 	insertReservationQuery := `
-		INSERT INTO reservations (user_id, movie_id, showtime_id, seats)
-		VALUES ($1, $2, $3, $4) RETURNING id;
+		INSERT INTO reservations (user_id, movie_id, showtime_id, seats, seat_price)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id;
 	`
 
 	var reservationID int
-	err = tx.QueryRow(ctx, insertReservationQuery, reservation.UserID, reservation.MovieID, reservation.ShowtimeID, seatsArray).Scan(&reservationID)
+	err = tx.QueryRow(ctx, insertReservationQuery, reservation.UserID, reservation.MovieID, reservation.ShowtimeID, seatsArray, reservation.SeatPrice).Scan(&reservationID)
 	if err != nil {
 		return fmt.Errorf("error creating reservation: %w", err)
 	}
@@ -155,54 +182,49 @@ func (r *ReservationRepository) CancelReservation(ctx context.Context, reservati
 	return nil
 }
 
-func (repo *ReservationRepository) GetReservations(ctx context.Context, id int) ([]models.Reservation, error) {
-	var rows pgx.Rows
-	var err error
+// GetReservations returns a page of the reservations of a user.
+func (repo *ReservationRepository) GetReservations(ctx context.Context, id int, params pagination.Params) (*pagination.Page[models.Reservation], error) {
+	return repo.listReservations(ctx, id, 0, params)
+}
 
-	query := `
-		SELECT id, user_id, movie_id, showtime_id, seats, created_at
-		FROM reservations
-		WHERE user_id = $1
-	`
+// GetAllReservations returns a page of the reservations of all users, for
+// the showtimes of one cinema unless cinemaID is 0.
+func (repo *ReservationRepository) GetAllReservations(ctx context.Context, cinemaID int, params pagination.Params) (*pagination.Page[models.Reservation], error) {
+	return repo.listReservations(ctx, 0, cinemaID, params)
+}
 
-	rows, err = repo.DB.Query(ctx, query, id)
+// inCinema limits reservations to the showtimes of the cinema in the
+// parameter it names, unless that is 0.
+func inCinema(param string) string {
+	return "(" + param + " = 0 OR showtime_id IN (SELECT id FROM showtimes WHERE cinema_id = " + param + "))"
+}
+
+// listReservations pages through reservations ordered by id, limited to one
+// user unless userID is 0 and to one cinema unless cinemaID is 0.
+func (repo *ReservationRepository) listReservations(ctx context.Context, userID, cinemaID int, params pagination.Params) (*pagination.Page[models.Reservation], error) {
+	afterID, err := pagination.AfterID(params)
 	if err != nil {
-		log.Printf("error fetching reservations: %v", err)
 		return nil, err
 	}
-	defer rows.Close()
-
-	var reservations []models.Reservation
-	for rows.Next() {
-		var reservation models.Reservation
-		if err := rows.Scan(&reservation.ID, &reservation.UserID, &reservation.MovieID, &reservation.ShowtimeID, &reservation.Seats, &reservation.CreatedAt); err != nil {
-			log.Printf("error scanning reservations: %v", err)
-			return nil, err
-		}
-		reservations = append(reservations, reservation)
-	}
 
-	if err := rows.Err(); err != nil {
-		log.Printf("error during rows iteration: %v", err)
-		return nil, err
+	var total int
+	err = repo.DB.QueryRow(ctx, `
+		SELECT COUNT(*)
+		FROM reservations
+		WHERE ($1 = 0 OR user_id = $1) AND `+inCinema("$2"), userID, cinemaID).Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("error counting reservations: %w", err)
 	}
 
-	return reservations, err
-}
-
-func (repo *ReservationRepository) GetAllReservations(ctx context.Context) ([]models.Reservation, error) {
-	var rows pgx.Rows
-	var err error
-
-	query := `
-		SELECT id, user_id, movie_id, showtime_id, seats, created_at
+	rows, err := repo.DB.Query(ctx, `
+		SELECT id, COALESCE(user_id, 0), movie_id, showtime_id, seats, seat_price, created_at
 		FROM reservations
-	`
-
-	rows, err = repo.DB.Query(ctx, query)
+		WHERE ($1 = 0 OR user_id = $1) AND id > $2 AND `+inCinema("$4")+`
+		ORDER BY id
+		LIMIT $3`, userID, afterID, params.Limit+1, cinemaID)
 	if err != nil {
 		log.Printf("error fetching reservations: %v", err)
-		return nil, err
+		return nil, fmt.Errorf("error fetching reservations: %w", err)
 	}
 	defer rows.Close()
 
@@ -215,25 +237,29 @@ func (repo *ReservationRepository) GetAllReservations(ctx context.Context) ([]mo
 			&reservation.MovieID,
 			&reservation.ShowtimeID,
 			&reservation.Seats,
+			&reservation.SeatPrice,
 			&reservation.CreatedAt,
 		)
 		if err != nil {
 			log.Printf("error scanning reservations: %v", err)
-			return nil, err
+			return nil, fmt.Errorf("error scanning reservation: %w", err)
 		}
 		reservations = append(reservations, reservation)
 	}
 
 	if err := rows.Err(); err != nil {
 		log.Printf("error during rows iteration: %v", err)
-		return nil, err
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
 	}
 
-	return reservations, nil
+	return pagination.NewPage(reservations, params, total, func(reservation models.Reservation) string {
+		return pagination.IDCursorOf(reservation.ID)
+	}), nil
 }
 
-// GetReservationsPerMovie returns the count of reservations for each movie
-func (r *ReservationRepository) GetReservationsPerMovie(ctx context.Context, movieID int) ([]models.MovieReservationCount, error) {
+// GetReservationsPerMovie returns the count of reservations for each movie,
+// in one cinema unless cinemaID is 0
+func (r *ReservationRepository) GetReservationsPerMovie(ctx context.Context, movieID, cinemaID int) ([]models.MovieReservationCount, error) {
 	query := `
 		SELECT 
 			m.id,
@@ -241,12 +267,12 @@ func (r *ReservationRepository) GetReservationsPerMovie(ctx context.Context, mov
 			COUNT(r.id) as reservation_count,
 			COALESCE(SUM(array_length(r.seats, 1)), 0) as total_seats
 		FROM movies m
-		LEFT JOIN reservations r ON m.id = r.movie_id
+		LEFT JOIN reservations r ON m.id = r.movie_id AND ` + inCinema("$2") + `
 		WHERE m.id = $1
 		GROUP BY m.id, m.title
 		ORDER BY reservation_count DESC`
 
-	rows, err := r.DB.Query(ctx, query, movieID)
+	rows, err := r.DB.Query(ctx, query, movieID, cinemaID)
 	if err != nil {
 		return nil, fmt.Errorf("error querying reservations per movie: %v", err)
 	}
@@ -269,19 +295,21 @@ func (r *ReservationRepository) GetReservationsPerMovie(ctx context.Context, mov
 	return results, nil
 }
 
-// GetTotalRevenue returns the total revenue and seat count across all movies
-func (r *ReservationRepository) GetTotalRevenue(ctx context.Context) (int, map[string]int, int, error) {
+// GetTotalRevenue returns the total revenue and seat count across all movies,
+// in one cinema unless cinemaID is 0. Seats count at the price they were
+// booked at.
+func (r *ReservationRepository) GetTotalRevenue(ctx context.Context, cinemaID int) (int, map[string]int, int, error) {
 	query := `
 		SELECT 
 			m.title,
 			COALESCE(SUM(array_length(r.seats, 1)), 0) as seats_reserved,
-			COALESCE(SUM(array_length(r.seats, 1)), 0) * 5 as revenue
+			COALESCE(SUM(array_length(r.seats, 1) * r.seat_price), 0) as revenue
 		FROM movies m
-		LEFT JOIN reservations r ON m.id = r.movie_id
+		LEFT JOIN reservations r ON m.id = r.movie_id AND ` + inCinema("$1") + `
 		GROUP BY m.id, m.title
 		ORDER BY revenue DESC`
 
-	rows, err := r.DB.Query(ctx, query)
+	rows, err := r.DB.Query(ctx, query, cinemaID)
 	if err != nil {
 		return 0, nil, 0, fmt.Errorf("error querying total revenue: %v", err)
 	}
@@ -0,0 +1,306 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie-system/internal/models"
+	"movie-system/internal/pagination"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrReviewNotFound = errors.New("review not found")
+	ErrReviewExists   = errors.New("movie has already been reviewed by this user")
+)
+
+type ReviewRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewReviewRepository(db *pgxpool.Pool) *ReviewRepository {
+	return &ReviewRepository{DB: db}
+}
+
+// reviewColumns are the columns scanned by scanReview, selected from reviews r
+// joined with the users u who wrote them.
+const reviewColumns = `r.id, r.movie_id, r.user_id, COALESCE(NULLIF(u.display_name, ''), u.username), r.rating, r.body, r.status,
+	(SELECT COUNT(*) FROM review_reports rr WHERE rr.review_id = r.id), COALESCE(r.moderated_by, ''), r.moderated_at,
+	r.created_at, r.updated_at`
+
+func scanReview(row pgx.Row, review *models.Review) error {
+	return row.Scan(&review.ID, &review.MovieID, &review.UserID, &review.Author, &review.Rating, &review.Body, &review.Status,
+		&review.ReportCount, &review.ModeratedBy, &review.ModeratedAt, &review.CreatedAt, &review.UpdatedAt)
+}
+
+// HasAttended reports whether a user holds a reservation for a showtime of
+// the movie that has already started.
+func (repo *ReviewRepository) HasAttended(ctx context.Context, userID, movieID int) (bool, error) {
+	var attended bool
+	err := repo.DB.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM reservations r
+			JOIN showtimes s ON s.id = r.showtime_id
+			WHERE r.user_id = $1 AND s.movie_id = $2 AND s.start_time <= NOW()
+		)`, userID, movieID).Scan(&attended)
+	if err != nil {
+		return false, fmt.Errorf("error checking attendance: %w", err)
+	}
+	return attended, nil
+}
+
+// InsertReview stores a new published review and fills in its id.
+func (repo *ReviewRepository) InsertReview(ctx context.Context, review *models.Review) error {
+	err := repo.DB.QueryRow(ctx, `
+		INSERT INTO reviews (movie_id, user_id, rating, body)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`,
+		review.MovieID, review.UserID, review.Rating, review.Body).Scan(&review.ID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23505":
+				return ErrReviewExists
+			case "23503":
+				return ErrMovieNotFound
+			}
+		}
+		return fmt.Errorf("error creating review: %w", err)
+	}
+	return nil
+}
+
+func (repo *ReviewRepository) GetReview(ctx context.Context, id int) (*models.Review, error) {
+	var review models.Review
+	err := scanReview(repo.DB.QueryRow(ctx, `
+		SELECT `+reviewColumns+`
+		FROM reviews r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.id = $1`, id), &review)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrReviewNotFound
+		}
+		return nil, fmt.Errorf("error fetching review: %w", err)
+	}
+	return &review, nil
+}
+
+// UpdateReview replaces the rating and text of a review. Its moderation
+// status is kept.
+func (repo *ReviewRepository) UpdateReview(ctx context.Context, id, rating int, body string) error {
+	tag, err := repo.DB.Exec(ctx, `
+		UPDATE reviews
+		SET rating = $2, body = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`, id, rating, body)
+	if err != nil {
+		return fmt.Errorf("error updating review: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrReviewNotFound
+	}
+	return nil
+}
+
+func (repo *ReviewRepository) DeleteReview(ctx context.Context, id int) error {
+	tag, err := repo.DB.Exec(ctx, "DELETE FROM reviews WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error deleting review: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrReviewNotFound
+	}
+	return nil
+}
+
+// ListMovieReviews returns a page of the reviews of a movie that are not
+// hidden, newest first.
+func (repo *ReviewRepository) ListMovieReviews(ctx context.Context, movieID int, params pagination.Params) (*pagination.Page[models.Review], error) {
+	var exists bool
+	if err := repo.DB.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM movies WHERE id = $1)", movieID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("error fetching movie: %w", err)
+	}
+	if !exists {
+		return nil, ErrMovieNotFound
+	}
+	return repo.listReviews(ctx, "r.movie_id = $1 AND r.status <> $2", []any{movieID, models.ReviewStatusHidden}, params)
+}
+
+// ListReviewsByStatus returns a page of the reviews in a moderation status,
+// newest first, with the reports made about them.
+func (repo *ReviewRepository) ListReviewsByStatus(ctx context.Context, status string, params pagination.Params) (*pagination.Page[models.Review], error) {
+	page, err := repo.listReviews(ctx, "r.status = $1", []any{status}, params)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, len(page.Items))
+	for i, review := range page.Items {
+		ids[i] = int(review.ID)
+	}
+	rows, err := repo.DB.Query(ctx, `
+		SELECT review_id, user_id, reason, created_at
+		FROM review_reports
+		WHERE review_id = ANY($1)
+		ORDER BY review_id, created_at`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching review reports: %w", err)
+	}
+	defer rows.Close()
+
+	reports := make(map[uint][]models.ReviewReport)
+	for rows.Next() {
+		var reviewID uint
+		var report models.ReviewReport
+		if err := rows.Scan(&reviewID, &report.UserID, &report.Reason, &report.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning review report: %w", err)
+		}
+		reports[reviewID] = append(reports[reviewID], report)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+	for i := range page.Items {
+		page.Items[i].Reports = reports[page.Items[i].ID]
+	}
+	return page, nil
+}
+
+// ListReviewsByUser returns a page of the reviews written by a user, newest
+// first, whatever their status.
+func (repo *ReviewRepository) ListReviewsByUser(ctx context.Context, userID int, params pagination.Params) (*pagination.Page[models.Review], error) {
+	return repo.listReviews(ctx, "r.user_id = $1", []any{userID}, params)
+}
+
+// ListUserReviews returns all reviews written by a user, newest first,
+// whatever their status.
+func (repo *ReviewRepository) ListUserReviews(ctx context.Context, userID int) ([]models.Review, error) {
+	rows, err := repo.DB.Query(ctx, `
+		SELECT `+reviewColumns+`
+		FROM reviews r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.user_id = $1
+		ORDER BY r.id DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching reviews: %w", err)
+	}
+	defer rows.Close()
+
+	reviews := []models.Review{}
+	for rows.Next() {
+		var review models.Review
+		if err := scanReview(rows, &review); err != nil {
+			return nil, fmt.Errorf("error scanning review: %w", err)
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
+
+// listReviews pages through the reviews matching condition, whose
+// placeholders are numbered from $1 and bound to args, newest first.
+func (repo *ReviewRepository) listReviews(ctx context.Context, condition string, args []any, params pagination.Params) (*pagination.Page[models.Review], error) {
+	beforeID, err := pagination.AfterID(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int
+	err = repo.DB.QueryRow(ctx, "SELECT COUNT(*) FROM reviews r WHERE "+condition, args...).Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("error counting reviews: %w", err)
+	}
+
+	n := len(args)
+	rows, err := repo.DB.Query(ctx, fmt.Sprintf(`
+		SELECT `+reviewColumns+`
+		FROM reviews r
+		JOIN users u ON u.id = r.user_id
+		WHERE `+condition+` AND ($%d = 0 OR r.id < $%[1]d)
+		ORDER BY r.id DESC
+		LIMIT $%d`, n+1, n+2), append(args, beforeID, params.Limit+1)...)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching reviews: %w", err)
+	}
+	defer rows.Close()
+
+	var reviews []models.Review
+	for rows.Next() {
+		var review models.Review
+		if err := scanReview(rows, &review); err != nil {
+			return nil, fmt.Errorf("error scanning review: %w", err)
+		}
+		reviews = append(reviews, review)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return pagination.NewPage(reviews, params, total, func(review models.Review) string {
+		return pagination.IDCursorOf(review.ID)
+	}), nil
+}
+
+// ReportReview records that a user reported a review and puts a published
+// review in the moderation queue. Reporting the same review twice is a no-op.
+func (repo *ReviewRepository) ReportReview(ctx context.Context, id, userID int, reason string) error {
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO review_reports (review_id, user_id, reason)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (review_id, user_id) DO NOTHING`, id, userID, reason)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return ErrReviewNotFound
+		}
+		return fmt.Errorf("error reporting review: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE reviews
+		SET status = $2
+		WHERE id = $1 AND status = $3`, id, models.ReviewStatusReported, models.ReviewStatusPublished)
+	if err != nil {
+		return fmt.Errorf("error updating review status: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// ModerateReview sets the status of a review on behalf of a moderator.
+// Approving a review, that is publishing it, dismisses the reports about it.
+func (repo *ReviewRepository) ModerateReview(ctx context.Context, id int, status, actor string) error {
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE reviews
+		SET status = $2, moderated_by = $3, moderated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`, id, status, actor)
+	if err != nil {
+		return fmt.Errorf("error moderating review: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrReviewNotFound
+	}
+
+	if status == models.ReviewStatusPublished {
+		if _, err := tx.Exec(ctx, "DELETE FROM review_reports WHERE review_id = $1", id); err != nil {
+			return fmt.Errorf("error dismissing review reports: %w", err)
+		}
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie-system/internal/models"
+	"movie-system/internal/site"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	ErrShowtimeConflict = errors.New("showtime overlaps another showtime in the same auditorium")
+	ErrRuntimeUnknown   = errors.New("the movie has no runtime; set one before scheduling it in an auditorium")
+)
+
+// ShowtimeConflictError names the showtime that a showtime would overlap in
+// its auditorium. It matches ErrShowtimeConflict.
+type ShowtimeConflictError struct {
+	ShowtimeID uint
+	MovieTitle string
+	Auditorium string
+	StartTime  time.Time
+	EndsAt     time.Time
+	// TimeZone is the zone of the cinema the times are shown in.
+	TimeZone string
+}
+
+func (e *ShowtimeConflictError) Error() string {
+	return fmt.Sprintf("auditorium %q is taken by showtime %d (%s) from %s until %s",
+		e.Auditorium, e.ShowtimeID, e.MovieTitle, site.Local(e.StartTime, e.TimeZone).Format("2006-01-02 15:04"), site.Local(e.EndsAt, e.TimeZone).Format("2006-01-02 15:04"))
+}
+
+func (e *ShowtimeConflictError) Is(target error) bool {
+	return target == ErrShowtimeConflict
+}
+
+// scheduleShowtime sets showtime.EndsAt from the runtime of its movie and the
+// buffers of its auditorium, and checks that no other showtime but the one
+// with id takes the auditorium in the meantime. Showtimes without an
+// auditorium are not checked. The auditorium stays locked until tx ends, so
+// that showtimes are scheduled in it one at a time; the showtimes_no_overlap
+// constraint catches any other writer.
+//
+// The showtime is placed in the cinema of its auditorium, which must match
+// showtime.CinemaID if that is set, and its start time is localized to the
+// time zone of the cinema.
+func scheduleShowtime(ctx context.Context, tx pgx.Tx, id int, showtime *models.Showtime) error {
+	showtime.EndsAt = nil
+
+	var buffers int
+	if showtime.AuditoriumID != 0 {
+		var cinemaID uint
+		err := tx.QueryRow(ctx, "SELECT cinema_id, ad_minutes + cleaning_minutes FROM auditoriums WHERE id = $1 FOR UPDATE",
+			showtime.AuditoriumID).Scan(&cinemaID, &buffers)
+		if errors.Is(err, pgx.ErrNoRows) || err == nil && showtime.CinemaID != 0 && showtime.CinemaID != cinemaID {
+			return fmt.Errorf("%w: %d", ErrAuditoriumNotFound, showtime.AuditoriumID)
+		}
+		if err != nil {
+			return fmt.Errorf("error fetching auditorium: %w", err)
+		}
+		showtime.CinemaID = cinemaID
+	}
+
+	err := tx.QueryRow(ctx, "SELECT timezone FROM cinemas WHERE id = $1", showtime.CinemaID).Scan(&showtime.TimeZone)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: %d", ErrCinemaNotFound, showtime.CinemaID)
+	}
+	if err != nil {
+		return fmt.Errorf("error fetching cinema: %w", err)
+	}
+	showtime.StartTime = site.Local(showtime.StartTime, showtime.TimeZone)
+	if showtime.AuditoriumID == 0 {
+		return nil
+	}
+
+	var runtime int
+	err = tx.QueryRow(ctx, "SELECT runtime_minutes FROM movies WHERE id = $1", showtime.MovieID).Scan(&runtime)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("%w: %d", ErrMovieNotFound, showtime.MovieID)
+	}
+	if err != nil {
+		return fmt.Errorf("error fetching movie: %w", err)
+	}
+	if runtime == 0 {
+		return ErrRuntimeUnknown
+	}
+
+	endsAt := showtime.StartTime.Add(time.Duration(runtime+buffers) * time.Minute)
+	if err := checkShowtimeSlot(ctx, tx, id, showtime.AuditoriumID, showtime.StartTime, endsAt); err != nil {
+		return err
+	}
+	showtime.EndsAt = &endsAt
+	return nil
+}
+
+// checkShowtimeSlot returns a *ShowtimeConflictError for the earliest active
+// showtime other than the one with id that takes the auditorium between
+// start and end.
+func checkShowtimeSlot(ctx context.Context, tx pgx.Tx, id int, auditoriumID uint, start, end time.Time) error {
+	var conflict ShowtimeConflictError
+	err := tx.QueryRow(ctx, `
+		SELECT s.id, m.title, a.name, s.start_time, s.ends_at, c.timezone
+		FROM showtimes s
+		JOIN movies m ON m.id = s.movie_id
+		JOIN auditoriums a ON a.id = s.auditorium_id
+		JOIN cinemas c ON c.id = s.cinema_id
+		WHERE s.auditorium_id = $1 AND s.archived_at IS NULL AND s.id <> $2
+			AND tstzrange(s.start_time, s.ends_at) && tstzrange($3, $4)
+		ORDER BY s.start_time, s.id
+		LIMIT 1`, auditoriumID, id, start, end).Scan(&conflict.ShowtimeID, &conflict.MovieTitle, &conflict.Auditorium, &conflict.StartTime, &conflict.EndsAt, &conflict.TimeZone)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error checking auditorium schedule: %w", err)
+	}
+	return &conflict
+}
+
+// rescheduleShowtimes recomputes when the active showtimes matching condition
+// end, after the runtime of their movie or the buffers of their auditorium
+// changed. Showtimes that are over are left alone. A showtime that would now
+// overlap the next one fails the whole change.
+func rescheduleShowtimes(ctx context.Context, tx pgx.Tx, condition string, args ...any) error {
+	rows, err := tx.Query(ctx, `
+		SELECT s.id, s.movie_id, s.cinema_id, s.auditorium_id, s.start_time
+		FROM showtimes s
+		WHERE s.archived_at IS NULL AND s.auditorium_id IS NOT NULL AND s.ends_at > NOW() AND `+condition+`
+		ORDER BY s.start_time, s.id
+		FOR UPDATE`, args...)
+	if err != nil {
+		return fmt.Errorf("error fetching showtimes: %w", err)
+	}
+	showtimes, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Showtime, error) {
+		var showtime models.Showtime
+		err := row.Scan(&showtime.ID, &showtime.MovieID, &showtime.CinemaID, &showtime.AuditoriumID, &showtime.StartTime)
+		return showtime, err
+	})
+	if err != nil {
+		return fmt.Errorf("error scanning showtime: %w", err)
+	}
+
+	for _, showtime := range showtimes {
+		if err := scheduleShowtime(ctx, tx, int(showtime.ID), &showtime); err != nil {
+			return fmt.Errorf("showtime %d: %w", showtime.ID, err)
+		}
+		if _, err := tx.Exec(ctx, "UPDATE showtimes SET ends_at = $1 WHERE id = $2", showtime.EndsAt, showtime.ID); err != nil {
+			return showtimeWriteError(err, "error rescheduling showtime")
+		}
+	}
+	return nil
+}
+
+// showtimeWriteError reports a violation of showtimes_no_overlap, which the
+// checks above only miss when another writer got in between, as
+// ErrShowtimeConflict.
+func showtimeWriteError(err error, message string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23P01" {
+		return ErrShowtimeConflict
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
@@ -0,0 +1,425 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie-system/internal/models"
+	"movie-system/internal/pagination"
+	"movie-system/internal/site"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrScheduleNotFound  = errors.New("schedule not found")
+	ErrScheduleCancelled = errors.New("the schedule has been cancelled")
+)
+
+const scheduleColumns = `id, movie_id, cinema_id, COALESCE(auditorium_id, 0), capacity, start_date::text, times, rrule, exdates::text[],
+	cancelled_at, created_at, updated_at`
+
+func scanSchedule(row pgx.Row, schedule *models.Schedule) error {
+	return row.Scan(&schedule.ID, &schedule.MovieID, &schedule.CinemaID, &schedule.AuditoriumID, &schedule.Capacity, &schedule.StartDate,
+		&schedule.Times, &schedule.RRule, &schedule.ExDates, &schedule.CancelledAt, &schedule.CreatedAt, &schedule.UpdatedAt)
+}
+
+// ScheduleRepository stores recurring schedules and keeps their occurrences,
+// ordinary showtimes with a schedule_id, in line with them. Occurrences that
+// have begun, are booked or were archived by hand are never touched.
+type ScheduleRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewScheduleRepository(db *pgxpool.Pool) *ScheduleRepository {
+	return &ScheduleRepository{DB: db}
+}
+
+// ListSchedules returns a page of schedules, newest first, of one cinema
+// unless cinemaID is 0.
+func (repo *ScheduleRepository) ListSchedules(ctx context.Context, cinemaID int, params pagination.Params) (*pagination.Page[models.Schedule], error) {
+	beforeID, err := pagination.AfterID(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int
+	err = repo.DB.QueryRow(ctx, "SELECT COUNT(*) FROM schedules WHERE $1 = 0 OR cinema_id = $1", cinemaID).Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("error counting schedules: %w", err)
+	}
+
+	rows, err := repo.DB.Query(ctx, `
+		SELECT `+scheduleColumns+`
+		FROM schedules
+		WHERE ($1 = 0 OR id < $1) AND ($3 = 0 OR cinema_id = $3)
+		ORDER BY id DESC
+		LIMIT $2`, beforeID, params.Limit+1, cinemaID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []models.Schedule
+	for rows.Next() {
+		var schedule models.Schedule
+		if err := scanSchedule(rows, &schedule); err != nil {
+			return nil, fmt.Errorf("error scanning schedule: %w", err)
+		}
+		schedules = append(schedules, schedule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return pagination.NewPage(schedules, params, total, func(schedule models.Schedule) string {
+		return pagination.IDCursorOf(schedule.ID)
+	}), nil
+}
+
+// GetSchedule returns a schedule with all its occurrences, archived ones
+// included, in order of start time.
+func (repo *ScheduleRepository) GetSchedule(ctx context.Context, id int) (*models.Schedule, error) {
+	var schedule models.Schedule
+	err := scanSchedule(repo.DB.QueryRow(ctx, "SELECT "+scheduleColumns+" FROM schedules WHERE id = $1", id), &schedule)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrScheduleNotFound
+		}
+		return nil, fmt.Errorf("error fetching schedule: %w", err)
+	}
+
+	rows, err := repo.DB.Query(ctx, `
+		SELECT `+showtimeColumns+`
+		FROM showtimes s
+		WHERE s.schedule_id = $1
+		ORDER BY s.start_time, s.id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching showtimes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var showtime models.Showtime
+		if err := scanShowtime(rows, &showtime); err != nil {
+			return nil, fmt.Errorf("error scanning showtime: %w", err)
+		}
+		schedule.Showtimes = append(schedule.Showtimes, showtime)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+	return &schedule, nil
+}
+
+// ScheduleZone places a schedule in the cinema of its auditorium, which must
+// be schedule.CinemaID if that is set, and returns the time zone of the
+// cinema its dates and times are local to.
+func (repo *ScheduleRepository) ScheduleZone(ctx context.Context, schedule *models.Schedule) (*time.Location, error) {
+	if schedule.AuditoriumID != 0 {
+		var cinemaID uint
+		err := repo.DB.QueryRow(ctx, "SELECT cinema_id FROM auditoriums WHERE id = $1", schedule.AuditoriumID).Scan(&cinemaID)
+		if errors.Is(err, pgx.ErrNoRows) || err == nil && schedule.CinemaID != 0 && schedule.CinemaID != cinemaID {
+			return nil, fmt.Errorf("%w: %d", ErrAuditoriumNotFound, schedule.AuditoriumID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error fetching auditorium: %w", err)
+		}
+		schedule.CinemaID = cinemaID
+	}
+
+	var zone string
+	err := repo.DB.QueryRow(ctx, "SELECT timezone FROM cinemas WHERE id = $1", schedule.CinemaID).Scan(&zone)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %d", ErrCinemaNotFound, schedule.CinemaID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching cinema: %w", err)
+	}
+	return site.Zone(zone)
+}
+
+// CreateSchedule stores a schedule and creates a showtime at each of starts
+// that is still to come. Nothing is saved on a dry run or if any showtime
+// fails, for instance because its auditorium is taken.
+func (repo *ScheduleRepository) CreateSchedule(ctx context.Context, schedule *models.Schedule, starts []time.Time, dryRun bool) (*models.ScheduleReport, error) {
+	report, err := repo.save(ctx, starts, dryRun, func(tx pgx.Tx) (*models.Schedule, error) {
+		var created models.Schedule
+		err := scanSchedule(tx.QueryRow(ctx, `
+			INSERT INTO schedules (movie_id, auditorium_id, capacity, start_date, times, rrule, exdates, cinema_id)
+			VALUES ($1, NULLIF($2, 0), $3, $4::date, $5, $6, $7::date[], $8)
+			RETURNING `+scheduleColumns,
+			schedule.MovieID, schedule.AuditoriumID, schedule.Capacity, schedule.StartDate, schedule.Times, schedule.RRule, schedule.ExDates, schedule.CinemaID), &created)
+		if err != nil {
+			return nil, scheduleWriteError(err, "error inserting schedule")
+		}
+		return &created, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !report.Committed {
+		report.Schedule.ID = 0
+	}
+	return report, nil
+}
+
+// UpdateSchedule replaces a schedule and brings its remaining occurrences in
+// line: upcoming showtimes that are neither booked nor archived are moved to
+// the new movie, auditorium and capacity if the new rule still yields them,
+// and deleted otherwise, and the missing ones are created.
+func (repo *ScheduleRepository) UpdateSchedule(ctx context.Context, id int, schedule *models.Schedule, starts []time.Time, dryRun bool) (*models.ScheduleReport, error) {
+	return repo.save(ctx, starts, dryRun, func(tx pgx.Tx) (*models.Schedule, error) {
+		if err := lockSchedule(ctx, tx, id); err != nil {
+			return nil, err
+		}
+		var updated models.Schedule
+		err := scanSchedule(tx.QueryRow(ctx, `
+			UPDATE schedules
+			SET movie_id = $1, auditorium_id = NULLIF($2, 0), capacity = $3, start_date = $4::date, times = $5,
+				rrule = $6, exdates = $7::date[], cinema_id = $9, updated_at = NOW()
+			WHERE id = $8
+			RETURNING `+scheduleColumns,
+			schedule.MovieID, schedule.AuditoriumID, schedule.Capacity, schedule.StartDate, schedule.Times, schedule.RRule, schedule.ExDates, id,
+			schedule.CinemaID), &updated)
+		if err != nil {
+			return nil, scheduleWriteError(err, "error updating schedule")
+		}
+		return &updated, nil
+	})
+}
+
+// CancelSchedule deletes the upcoming occurrences of a schedule that are
+// neither booked nor archived and marks it cancelled.
+func (repo *ScheduleRepository) CancelSchedule(ctx context.Context, id int, dryRun bool) (*models.ScheduleReport, error) {
+	return repo.save(ctx, nil, dryRun, func(tx pgx.Tx) (*models.Schedule, error) {
+		if err := lockSchedule(ctx, tx, id); err != nil {
+			return nil, err
+		}
+		var cancelled models.Schedule
+		err := scanSchedule(tx.QueryRow(ctx, `
+			UPDATE schedules
+			SET cancelled_at = NOW(), updated_at = NOW()
+			WHERE id = $1
+			RETURNING `+scheduleColumns, id), &cancelled)
+		if err != nil {
+			return nil, fmt.Errorf("error cancelling schedule: %w", err)
+		}
+		return &cancelled, nil
+	})
+}
+
+// lockSchedule locks a schedule that has not been cancelled.
+func lockSchedule(ctx context.Context, tx pgx.Tx, id int) error {
+	var cancelled bool
+	err := tx.QueryRow(ctx, "SELECT cancelled_at IS NOT NULL FROM schedules WHERE id = $1 FOR UPDATE", id).Scan(&cancelled)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrScheduleNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error fetching schedule: %w", err)
+	}
+	if cancelled {
+		return ErrScheduleCancelled
+	}
+	return nil
+}
+
+// occurrence is a showtime of a schedule. keep is the reason it must not be
+// changed, if any.
+type occurrence struct {
+	id    int
+	start time.Time
+	keep  string
+}
+
+// save writes the schedule row with write and then makes the occurrences of
+// the schedule match starts, all in one transaction.
+func (repo *ScheduleRepository) save(ctx context.Context, starts []time.Time, dryRun bool, write func(tx pgx.Tx) (*models.Schedule, error)) (*models.ScheduleReport, error) {
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	schedule, err := write(tx)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := lockOccurrences(ctx, tx, int(schedule.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ScheduleReport{Schedule: *schedule, DryRun: dryRun, Occurrences: []models.ScheduleOccurrence{}}
+	wanted := make(map[int64]bool, len(starts))
+	for _, start := range starts {
+		wanted[start.Unix()] = true
+	}
+	kept := make(map[int64]bool)
+	reusable := make(map[int64]int)
+	for _, o := range existing {
+		switch {
+		case o.keep != "":
+			kept[o.start.Unix()] = true
+			addOccurrence(report, models.ScheduleOccurrence{StartTime: o.start, ShowtimeID: uint(o.id), Action: models.ScheduleActionKeep, Reason: o.keep})
+		case wanted[o.start.Unix()]:
+			reusable[o.start.Unix()] = o.id
+		default:
+			if _, err := tx.Exec(ctx, "DELETE FROM showtimes WHERE id = $1", o.id); err != nil {
+				return nil, fmt.Errorf("error deleting showtime: %w", err)
+			}
+			addOccurrence(report, models.ScheduleOccurrence{StartTime: o.start, ShowtimeID: uint(o.id), Action: models.ScheduleActionDelete})
+		}
+	}
+
+	// Occurrences that would already have begun are not created
+	now := time.Now()
+	var pending []models.Showtime
+	for _, start := range starts {
+		if start.After(now) && !kept[start.Unix()] {
+			pending = append(pending, models.Showtime{
+				ID:           uint(reusable[start.Unix()]),
+				ScheduleID:   schedule.ID,
+				MovieID:      schedule.MovieID,
+				CinemaID:     schedule.CinemaID,
+				AuditoriumID: schedule.AuditoriumID,
+				StartTime:    start,
+				Capacity:     schedule.Capacity,
+			})
+		}
+	}
+	errs, err := eachInSavepoint(ctx, tx, len(pending), func(tx pgx.Tx, i int) error {
+		return saveOccurrence(ctx, tx, &pending[i])
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, showtime := range pending {
+		result := models.ScheduleOccurrence{StartTime: showtime.StartTime, ShowtimeID: showtime.ID, Action: models.ScheduleActionCreate}
+		if _, ok := reusable[showtime.StartTime.Unix()]; ok {
+			result.Action = models.ScheduleActionUpdate
+		}
+		if errs[i] != nil {
+			result.Action = models.ScheduleActionFail
+			result.Error = errs[i].Error()
+		}
+		addOccurrence(report, result)
+	}
+	sort.SliceStable(report.Occurrences, func(i, j int) bool {
+		return report.Occurrences[i].StartTime.Before(report.Occurrences[j].StartTime)
+	})
+
+	if dryRun || report.Failed > 0 {
+		for i := range report.Occurrences {
+			if report.Occurrences[i].Action == models.ScheduleActionCreate {
+				report.Occurrences[i].ShowtimeID = 0
+			}
+		}
+		return report, nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing schedule: %w", err)
+	}
+	report.Committed = true
+	return report, nil
+}
+
+// lockOccurrences returns the showtimes of a schedule and why they must be
+// kept as they are, if they must.
+func lockOccurrences(ctx context.Context, tx pgx.Tx, scheduleID int) ([]occurrence, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT s.id, s.start_time, c.timezone, s.archived_at IS NOT NULL,
+			EXISTS (SELECT 1 FROM reservations r WHERE r.showtime_id = s.id)
+		FROM showtimes s
+		JOIN cinemas c ON c.id = s.cinema_id
+		WHERE s.schedule_id = $1
+		ORDER BY s.start_time, s.id
+		FOR UPDATE OF s`, scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching showtimes: %w", err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	var occurrences []occurrence
+	for rows.Next() {
+		var o occurrence
+		var zone string
+		var archived, booked bool
+		if err := rows.Scan(&o.id, &o.start, &zone, &archived, &booked); err != nil {
+			return nil, fmt.Errorf("error scanning showtime: %w", err)
+		}
+		o.start = site.Local(o.start, zone)
+		switch {
+		case !o.start.After(now):
+			o.keep = models.ScheduleKeepStarted
+		case booked:
+			o.keep = models.ScheduleKeepBooked
+		case archived:
+			o.keep = models.ScheduleKeepArchived
+		}
+		occurrences = append(occurrences, o)
+	}
+	return occurrences, rows.Err()
+}
+
+// saveOccurrence creates a showtime of a schedule, or updates it if it has an
+// id, after checking its auditorium is free.
+func saveOccurrence(ctx context.Context, tx pgx.Tx, showtime *models.Showtime) error {
+	if err := scheduleShowtime(ctx, tx, int(showtime.ID), showtime); err != nil {
+		return err
+	}
+	if showtime.ID != 0 {
+		_, err := tx.Exec(ctx, `
+			UPDATE showtimes
+			SET movie_id = $1, auditorium_id = NULLIF($2, 0), ends_at = $3, capacity = $4, cinema_id = $6
+			WHERE id = $5`, showtime.MovieID, showtime.AuditoriumID, showtime.EndsAt, showtime.Capacity, showtime.ID, showtime.CinemaID)
+		if err != nil {
+			return showtimeWriteError(err, "error updating showtime")
+		}
+		return nil
+	}
+	err := tx.QueryRow(ctx, `
+		INSERT INTO showtimes (schedule_id, movie_id, cinema_id, auditorium_id, start_time, ends_at, capacity, reserved)
+		VALUES ($1, $2, $3, NULLIF($4, 0), $5, $6, $7, 0)
+		RETURNING id`, showtime.ScheduleID, showtime.MovieID, showtime.CinemaID, showtime.AuditoriumID, showtime.StartTime, showtime.EndsAt, showtime.Capacity).Scan(&showtime.ID)
+	if err != nil {
+		return showtimeWriteError(err, "error inserting showtime")
+	}
+	return nil
+}
+
+func addOccurrence(report *models.ScheduleReport, result models.ScheduleOccurrence) {
+	switch result.Action {
+	case models.ScheduleActionCreate:
+		report.Created++
+	case models.ScheduleActionUpdate:
+		report.Updated++
+	case models.ScheduleActionKeep:
+		report.Kept++
+	case models.ScheduleActionDelete:
+		report.Deleted++
+	case models.ScheduleActionFail:
+		report.Failed++
+	}
+	report.Occurrences = append(report.Occurrences, result)
+}
+
+func scheduleWriteError(err error, message string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		switch pgErr.ConstraintName {
+		case "schedules_auditorium_id_fkey", "schedules_auditorium_cinema_fkey":
+			return ErrAuditoriumNotFound
+		case "schedules_cinema_id_fkey":
+			return ErrCinemaNotFound
+		}
+		return ErrMovieNotFound
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
@@ -2,13 +2,34 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"movie-system/internal/models"
+	"movie-system/internal/pagination"
+	"movie-system/internal/site"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var (
+	ErrShowtimeNotFound      = errors.New("showtime not found")
+	ErrCapacityBelowReserved = errors.New("capacity is below the number of seats already reserved")
+)
+
+// bookableShowtime is true for showtimes that are neither archived
+// themselves nor belong to an archived movie.
+const bookableShowtime = `s.archived_at IS NULL AND NOT EXISTS (
+	SELECT 1 FROM movies m WHERE m.id = s.movie_id AND m.archived_at IS NOT NULL)`
+
+// bookedSeats counts the seats booked for the showtime s from the
+// reservations, which unlike showtimes.reserved cannot drift.
+const bookedSeats = `(SELECT COALESCE(SUM(cardinality(r.seats)), 0) FROM reservations r WHERE r.showtime_id = s.id)`
+
 type ShowtimeRepository struct {
 	DB *pgxpool.Pool
 }
@@ -17,20 +38,65 @@ func NewShowtimeRepository(db *pgxpool.Pool) *ShowtimeRepository {
 	return &ShowtimeRepository{DB: db}
 }
 
+// showtimeColumns are the columns scanShowtime reads, from a table aliased s.
+const showtimeColumns = `s.id, COALESCE(s.external_id, ''), s.movie_id, COALESCE(s.schedule_id, 0), s.cinema_id, COALESCE(s.auditorium_id, 0),
+	s.start_time, (SELECT timezone FROM cinemas WHERE id = s.cinema_id), s.ends_at, s.capacity, s.reserved,
+	GREATEST(s.capacity - ` + bookedSeats + `, 0), s.archived_at`
+
+// scanShowtime reads a showtime with its times in the local time of its
+// cinema.
+func scanShowtime(row pgx.Row, showtime *models.Showtime) error {
+	err := row.Scan(&showtime.ID, &showtime.ExternalID, &showtime.MovieID, &showtime.ScheduleID, &showtime.CinemaID, &showtime.AuditoriumID,
+		&showtime.StartTime, &showtime.TimeZone, &showtime.EndsAt, &showtime.Capacity, &showtime.Reserved,
+		&showtime.Available, &showtime.ArchivedAt)
+	if err != nil {
+		return err
+	}
+	localizeShowtime(showtime)
+	return nil
+}
+
+func localizeShowtime(showtime *models.Showtime) {
+	showtime.StartTime = site.Local(showtime.StartTime, showtime.TimeZone)
+	for _, t := range []*time.Time{showtime.EndsAt, showtime.ArchivedAt} {
+		if t != nil {
+			*t = site.Local(*t, showtime.TimeZone)
+		}
+	}
+}
+
+// InsertShowtime schedules a showtime. It fails with a *ShowtimeConflictError
+// if its auditorium is taken at the time.
 func (repo *ShowtimeRepository) InsertShowtime(ctx context.Context, showtime *models.Showtime) error {
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := scheduleShowtime(ctx, tx, 0, showtime); err != nil {
+		return err
+	}
 	query := `
-		INSERT INTO showtimes (movie_id, start_time, capacity, reserved)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO showtimes (external_id, movie_id, cinema_id, auditorium_id, start_time, ends_at, capacity, reserved)
+		VALUES (NULLIF($1, ''), $2, $3, NULLIF($4, 0), $5, $6, $7, $8)
 		RETURNING id
 	`
-	err := repo.DB.QueryRow(ctx, query,
+	err = tx.QueryRow(ctx, query,
+		showtime.ExternalID,
 		showtime.MovieID,
+		showtime.CinemaID,
+		showtime.AuditoriumID,
 		showtime.StartTime,
+		showtime.EndsAt,
 		showtime.Capacity,
 		showtime.Reserved,
 	).Scan(&showtime.ID)
 	if err != nil {
 		log.Printf("error inserting showtime: %v", err)
+		return showtimeWriteError(err, "error inserting showtime")
+	}
+	if err := tx.Commit(ctx); err != nil {
 		return err
 	}
 	log.Printf("inserted showtime with id: %d", showtime.ID)
@@ -39,10 +105,16 @@ func (repo *ShowtimeRepository) InsertShowtime(ctx context.Context, showtime *mo
 
 
 
-func (repo *ShowtimeRepository) GetShowtimes(ctx context.Context) ([]models.Showtime, error) {
+// GetShowtimes returns all showtimes the filter matches in order of start
+// time.
+func (repo *ShowtimeRepository) GetShowtimes(ctx context.Context, filter models.ShowtimeFilter) ([]models.Showtime, error) {
+	where, args := showtimeConditions(filter)
 	rows, err := repo.DB.Query(ctx, `
-		SELECT id, movie_id, start_time, capacity, reserved 
-		FROM showtimes`)
+		SELECT `+showtimeColumns+`
+		FROM showtimes s
+		JOIN cinemas c ON c.id = s.cinema_id
+		WHERE `+where+`
+		ORDER BY s.start_time, s.id`, args...)
 	if err != nil {
 		log.Printf("error fetching showtimes: %v", err)
 		return nil, fmt.Errorf("error fetching showtimes: %w", err)
@@ -52,13 +124,7 @@ func (repo *ShowtimeRepository) GetShowtimes(ctx context.Context) ([]models.Show
 	var showtimes []models.Showtime
 	for rows.Next() {
 		var showtime models.Showtime
-		if err := rows.Scan(
-			&showtime.ID,
-			&showtime.MovieID,
-			&showtime.StartTime,
-			&showtime.Capacity,
-			&showtime.Reserved,
-		); err != nil {
+		if err := scanShowtime(rows, &showtime); err != nil {
 			log.Printf("error scanning showtime: %v", err)
 			return nil, fmt.Errorf("error scanning showtime: %w", err)
 		}
@@ -73,18 +139,380 @@ func (repo *ShowtimeRepository) GetShowtimes(ctx context.Context) ([]models.Show
 	return showtimes, nil
 }
 
+// ListShowtimes returns a page of the showtimes the filter matches ordered by
+// start time.
+func (repo *ShowtimeRepository) ListShowtimes(ctx context.Context, filter models.ShowtimeFilter, params pagination.Params) (*pagination.Page[models.Showtime], error) {
+	var cursor showtimeCursor
+	if err := pagination.DecodeCursor(params.Cursor, &cursor); err != nil {
+		return nil, err
+	}
+
+	where, args := showtimeConditions(filter)
+
+	var total int
+	err := repo.DB.QueryRow(ctx, "SELECT COUNT(*) FROM showtimes s JOIN cinemas c ON c.id = s.cinema_id WHERE "+where, args...).Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("error counting showtimes: %w", err)
+	}
+
+	args = append(args, cursor.StartTime, cursor.ID, params.Limit+1)
+	rows, err := repo.DB.Query(ctx, fmt.Sprintf(`
+		SELECT `+showtimeColumns+`
+		FROM showtimes s
+		JOIN cinemas c ON c.id = s.cinema_id
+		WHERE `+where+` AND ($%d::timestamptz IS NULL OR (s.start_time, s.id) > ($%[1]d, $%d))
+		ORDER BY s.start_time, s.id
+		LIMIT $%d`, len(args)-2, len(args)-1, len(args)), args...)
+	if err != nil {
+		log.Printf("error fetching showtimes: %v", err)
+		return nil, fmt.Errorf("error fetching showtimes: %w", err)
+	}
+	defer rows.Close()
+
+	var showtimes []models.Showtime
+	for rows.Next() {
+		var showtime models.Showtime
+		if err := scanShowtime(rows, &showtime); err != nil {
+			return nil, fmt.Errorf("error scanning showtime: %w", err)
+		}
+		showtimes = append(showtimes, showtime)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return pagination.NewPage(showtimes, params, total, func(showtime models.Showtime) string {
+		return pagination.EncodeCursor(showtimeCursor{StartTime: &showtime.StartTime, ID: int(showtime.ID)})
+	}), nil
+}
+
+// showtimeConditions turns a filter into the WHERE clause of a query on
+// showtimes s joined with their cinemas c, and its arguments. Days are the
+// local days of each cinema.
+func showtimeConditions(filter models.ShowtimeFilter) (string, []any) {
+	conditions := []string{bookableShowtime, "s.start_time > NOW()"}
+	if filter.Archived {
+		conditions = []string{"s.archived_at IS NOT NULL"}
+	}
+	var args []any
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", fmt.Sprintf("$%d", len(args))))
+	}
+
+	if filter.MovieID != 0 {
+		addCondition("s.movie_id = ?", filter.MovieID)
+	}
+	if filter.CinemaID != 0 {
+		addCondition("s.cinema_id = ?", filter.CinemaID)
+	}
+	if filter.AuditoriumID != 0 {
+		addCondition("s.auditorium_id = ?", filter.AuditoriumID)
+	}
+	if filter.From != nil {
+		addCondition("(s.start_time AT TIME ZONE c.timezone)::date >= ?::date", filter.From.Format(time.DateOnly))
+	}
+	if filter.To != nil {
+		addCondition("(s.start_time AT TIME ZONE c.timezone)::date <= ?::date", filter.To.Format(time.DateOnly))
+	}
+	if filter.Days != 0 {
+		addCondition("(s.start_time AT TIME ZONE c.timezone)::date < (NOW() AT TIME ZONE c.timezone)::date + ?::int", filter.Days)
+	}
+	if filter.MinAvailable != 0 {
+		addCondition("s.capacity - "+bookedSeats+" >= ?", filter.MinAvailable)
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// GetMovieShowtimes returns the showtimes of a bookable movie the filter
+// matches, grouped by their local date.
+func (repo *ShowtimeRepository) GetMovieShowtimes(ctx context.Context, movieID int, filter models.ShowtimeFilter) (*models.MovieShowtimes, error) {
+	var exists bool
+	err := repo.DB.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM movies WHERE id = $1 AND archived_at IS NULL)", movieID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching movie: %w", err)
+	}
+	if !exists {
+		return nil, ErrMovieNotFound
+	}
+
+	filter.MovieID = movieID
+	showtimes, err := repo.GetShowtimes(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &models.MovieShowtimes{MovieID: uint(movieID), Days: showtimeDays(showtimes)}, nil
+}
+
+// showtimeDays groups showtimes in order of start time by their local date.
+// Cinemas in different time zones may be a day apart, so the days are sorted
+// afterwards.
+func showtimeDays(showtimes []models.Showtime) []models.ShowtimeDay {
+	days := []models.ShowtimeDay{}
+	index := make(map[string]int)
+	for _, showtime := range showtimes {
+		date := showtime.StartTime.Format(time.DateOnly)
+		i, ok := index[date]
+		if !ok {
+			i = len(days)
+			index[date] = i
+			days = append(days, models.ShowtimeDay{Date: date})
+		}
+		days[i].Showtimes = append(days[i].Showtimes, showtime)
+	}
+	sort.SliceStable(days, func(i, j int) bool {
+		return days[i].Date < days[j].Date
+	})
+	return days
+}
+
+// showtimeCursor is the position after the last showtime of a page.
+type showtimeCursor struct {
+	StartTime *time.Time `json:"start_time"`
+	ID        int        `json:"id"`
+}
+
+// GetShowtimeByID returns a showtime whether or not it is archived.
+func (repo *ShowtimeRepository) GetShowtimeByID(ctx context.Context, id int) (*models.Showtime, error) {
+	return repo.getShowtime(ctx, "s.id = $1", id)
+}
+
+// GetShowtimeByExternalID returns the showtime imported under externalID.
+func (repo *ShowtimeRepository) GetShowtimeByExternalID(ctx context.Context, externalID string) (*models.Showtime, error) {
+	return repo.getShowtime(ctx, "s.external_id = $1", externalID)
+}
+
+func (repo *ShowtimeRepository) getShowtime(ctx context.Context, condition string, arg any) (*models.Showtime, error) {
+	var showtime models.Showtime
+	err := scanShowtime(repo.DB.QueryRow(ctx, `
+		SELECT `+showtimeColumns+`
+		FROM showtimes s
+		WHERE `+condition, arg), &showtime)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrShowtimeNotFound
+		}
+		return nil, fmt.Errorf("error fetching showtime: %w", err)
+	}
+	return &showtime, nil
+}
+
+// UpdateShowtime replaces the fields of a showtime. Like InsertShowtime it
+// fails with a *ShowtimeConflictError if the new slot is taken.
 func (repo *ShowtimeRepository) UpdateShowtime(ctx context.Context, id int, showtime *models.Showtime) error {
-	_, err := repo.DB.Exec(ctx, `
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := scheduleShowtime(ctx, tx, id, showtime); err != nil {
+		return err
+	}
+	tag, err := tx.Exec(ctx, `
 		UPDATE showtimes
-		SET movie_id = $1, start_time = $2, capacity = $3, reserved = $4
+		SET movie_id = $1, start_time = $2, capacity = $3, reserved = $4,
+			external_id = COALESCE(NULLIF($6, ''), external_id),
+			auditorium_id = NULLIF($7, 0), ends_at = $8, cinema_id = $9
 		WHERE id = $5`,
-		showtime.MovieID, showtime.StartTime, showtime.Capacity, showtime.Reserved, id)
-	return err
+		showtime.MovieID, showtime.StartTime, showtime.Capacity, showtime.Reserved, id, showtime.ExternalID,
+		showtime.AuditoriumID, showtime.EndsAt, showtime.CinemaID)
+	if err != nil {
+		return showtimeWriteError(err, "error updating showtime")
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrShowtimeNotFound
+	}
+	return tx.Commit(ctx)
+}
+
+// ExportShowtimes returns every bookable showtime ordered by start time,
+// naming the movie by both id and external id.
+func (repo *ShowtimeRepository) ExportShowtimes(ctx context.Context) ([]models.Showtime, error) {
+	rows, err := repo.DB.Query(ctx, `
+		SELECT s.id, COALESCE(s.external_id, ''), s.movie_id, COALESCE(m.external_id, ''), s.cinema_id, COALESCE(s.auditorium_id, 0),
+			s.start_time, c.timezone, s.capacity, s.reserved
+		FROM showtimes s
+		JOIN movies m ON m.id = s.movie_id
+		JOIN cinemas c ON c.id = s.cinema_id
+		WHERE `+bookableShowtime+`
+		ORDER BY s.start_time, s.id`)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching showtimes: %w", err)
+	}
+	defer rows.Close()
+
+	showtimes := []models.Showtime{}
+	for rows.Next() {
+		var showtime models.Showtime
+		if err := rows.Scan(&showtime.ID, &showtime.ExternalID, &showtime.MovieID, &showtime.MovieExternalID, &showtime.CinemaID, &showtime.AuditoriumID,
+			&showtime.StartTime, &showtime.TimeZone, &showtime.Capacity, &showtime.Reserved); err != nil {
+			return nil, fmt.Errorf("error scanning showtime: %w", err)
+		}
+		localizeShowtime(&showtime)
+		showtimes = append(showtimes, showtime)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+	return showtimes, nil
+}
+
+// ImportShowtimes creates or updates showtimes keyed by their external id
+// or, without one, by id, like ImportMovies. The movie may be given by its external id. Reserved seat
+// counts are never imported: new showtimes start empty and existing ones
+// keep their reservations, so their capacity cannot drop below them. Rows
+// that would overlap another showtime in their auditorium fail.
+func (repo *ShowtimeRepository) ImportShowtimes(ctx context.Context, showtimes []models.Showtime, opts models.ImportOptions) ([]error, bool, error) {
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, false, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	errs, err := eachInSavepoint(ctx, tx, len(showtimes), func(tx pgx.Tx, i int) error {
+		showtime := &showtimes[i]
+		if showtime.MovieExternalID != "" {
+			var movieID int
+			err := tx.QueryRow(ctx, "SELECT id FROM movies WHERE external_id = $1", showtime.MovieExternalID).Scan(&movieID)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("%w: %s", ErrMovieNotFound, showtime.MovieExternalID)
+			}
+			if err != nil {
+				return fmt.Errorf("error fetching movie: %w", err)
+			}
+			showtime.MovieID = uint(movieID)
+		}
+		var exists bool
+		if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM movies WHERE id = $1)", showtime.MovieID).Scan(&exists); err != nil {
+			return fmt.Errorf("error fetching movie: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("%w: %d", ErrMovieNotFound, showtime.MovieID)
+		}
+
+		var id int
+		var err error
+		if showtime.ExternalID == "" {
+			err = tx.QueryRow(ctx, "SELECT id FROM showtimes WHERE id = $1 FOR UPDATE", showtime.ID).Scan(&id)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrShowtimeNotFound
+			}
+		} else {
+			err = tx.QueryRow(ctx, "SELECT id FROM showtimes WHERE external_id = $1 FOR UPDATE", showtime.ExternalID).Scan(&id)
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			if err := scheduleShowtime(ctx, tx, 0, showtime); err != nil {
+				return err
+			}
+			showtime.Reserved = 0
+			err = tx.QueryRow(ctx, `
+				INSERT INTO showtimes (external_id, movie_id, cinema_id, auditorium_id, start_time, ends_at, capacity, reserved)
+				VALUES ($1, $2, $3, NULLIF($4, 0), $5, $6, $7, 0)
+				RETURNING id`, showtime.ExternalID, showtime.MovieID, showtime.CinemaID, showtime.AuditoriumID, showtime.StartTime, showtime.EndsAt, showtime.Capacity).Scan(&id)
+			if err != nil {
+				return showtimeWriteError(err, "error inserting showtime")
+			}
+			showtime.ID = uint(id)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error fetching showtime: %w", err)
+		}
+
+		showtime.ID = uint(id)
+		if err := scheduleShowtime(ctx, tx, id, showtime); err != nil {
+			return err
+		}
+		err = tx.QueryRow(ctx, `
+			UPDATE showtimes
+			SET movie_id = $1, start_time = $2, capacity = $3, auditorium_id = NULLIF($5, 0), ends_at = $6, cinema_id = $7
+			WHERE id = $4 AND reserved <= $3
+			RETURNING reserved`, showtime.MovieID, showtime.StartTime, showtime.Capacity, id, showtime.AuditoriumID, showtime.EndsAt, showtime.CinemaID).Scan(&showtime.Reserved)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrCapacityBelowReserved
+		}
+		if err != nil {
+			return showtimeWriteError(err, "error updating showtime")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if opts.DryRun || (opts.Atomic && anyError(errs)) {
+		return errs, false, nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("error committing import: %w", err)
+	}
+	return errs, true, nil
+}
+
+// ArchiveShowtime hides a showtime from listings and stops new bookings for
+// it. Its reservations are kept.
+func (repo *ShowtimeRepository) ArchiveShowtime(ctx context.Context, id int) error {
+	tag, err := repo.DB.Exec(ctx, "UPDATE showtimes SET archived_at = COALESCE(archived_at, NOW()) WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error archiving showtime: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrShowtimeNotFound
+	}
+	return nil
+}
+
+// RestoreShowtime makes an archived showtime bookable again, provided its
+// auditorium has not been taken at the time since.
+func (repo *ShowtimeRepository) RestoreShowtime(ctx context.Context, id int) error {
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var showtime models.Showtime
+	err = tx.QueryRow(ctx, `
+		SELECT movie_id, cinema_id, COALESCE(auditorium_id, 0), start_time
+		FROM showtimes
+		WHERE id = $1
+		FOR UPDATE`, id).Scan(&showtime.MovieID, &showtime.CinemaID, &showtime.AuditoriumID, &showtime.StartTime)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrShowtimeNotFound
+		}
+		return fmt.Errorf("error fetching showtime: %w", err)
+	}
+	if err := scheduleShowtime(ctx, tx, id, &showtime); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE showtimes SET archived_at = NULL, ends_at = $2 WHERE id = $1", id, showtime.EndsAt)
+	if err != nil {
+		return showtimeWriteError(err, "error restoring showtime")
+	}
+	return tx.Commit(ctx)
 }
 
-func (repo *ShowtimeRepository) DeleteShowtime(ctx context.Context, id int) error {
-	_, err := repo.DB.Exec(ctx, "DELETE FROM showtimes WHERE id = $1", id)
-	return err
+// PurgeShowtime deletes an archived showtime for good, together with its
+// reservations.
+func (repo *ShowtimeRepository) PurgeShowtime(ctx context.Context, id int) error {
+	var archived bool
+	err := repo.DB.QueryRow(ctx, "SELECT archived_at IS NOT NULL FROM showtimes WHERE id = $1", id).Scan(&archived)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrShowtimeNotFound
+		}
+		return fmt.Errorf("error fetching showtime: %w", err)
+	}
+	if !archived {
+		return ErrNotArchived
+	}
+
+	if _, err := repo.DB.Exec(ctx, "DELETE FROM showtimes WHERE id = $1 AND archived_at IS NOT NULL", id); err != nil {
+		return fmt.Errorf("error purging showtime: %w", err)
+	}
+	return nil
 }
 
 func (repo *ShowtimeRepository) GetAvailableSeats(ctx context.Context, id int) ([]string, error) {
@@ -137,6 +565,34 @@ func (repo *ShowtimeRepository) GetAvailableSeats(ctx context.Context, id int) (
 	return availableSeats, nil
 }
 
+// UpcomingShowtimes returns the next perMovie bookable showtimes with free
+// seats of each of the given movies, by movie id and in order of start time.
+func (repo *ShowtimeRepository) UpcomingShowtimes(ctx context.Context, movieIDs []int, perMovie int) (map[uint][]models.Showtime, error) {
+	rows, err := repo.DB.Query(ctx, `
+		SELECT `+showtimeColumns+`
+		FROM (
+			SELECT s.*, ROW_NUMBER() OVER (PARTITION BY s.movie_id ORDER BY s.start_time, s.id) AS n
+			FROM showtimes s
+			WHERE `+bookableShowtime+` AND s.movie_id = ANY($1) AND s.start_time > NOW() AND s.capacity > `+bookedSeats+`
+		) s
+		WHERE n <= $2
+		ORDER BY s.movie_id, s.start_time, s.id`, movieIDs, perMovie)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching showtimes: %w", err)
+	}
+	defer rows.Close()
+
+	showtimes := make(map[uint][]models.Showtime)
+	for rows.Next() {
+		var showtime models.Showtime
+		if err := scanShowtime(rows, &showtime); err != nil {
+			return nil, fmt.Errorf("error scanning showtime: %w", err)
+		}
+		showtimes[showtime.MovieID] = append(showtimes[showtime.MovieID], showtime)
+	}
+	return showtimes, rows.Err()
+}
+
 func generateAllSeats(capacity int) []string {
     const maxRows = 10
     const maxSeatsPerRow = 10
@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"movie-system/internal/models"
+	"movie-system/internal/pagination"
 	"movie-system/test"
 	"testing"
 	"time"
@@ -32,12 +33,14 @@ func TestShowtimeRepository(t *testing.T) {
 		assert.NoError(t, err)
 
 		_, err = db.Exec(ctx, `
-			INSERT INTO movies (id, title, description, genre, poster_image) VALUES
-			(1, 'Test Movie 1', 'Test Description 1', 'Action', 'poster1.jpg')
+			INSERT INTO cinemas (id, name, timezone) VALUES (1, 'Main', 'UTC');
+			INSERT INTO movies (id, title, description, poster_image) VALUES
+			(1, 'Test Movie 1', 'Test Description 1', 'poster1.jpg')
 		`)
 		assert.NoError(t, err)
 
 		showtime := &models.Showtime{
+			CinemaID:  1,
 			MovieID:   1,
 			StartTime: time.Now(),
 			Capacity:  100,
@@ -53,18 +56,19 @@ func TestShowtimeRepository(t *testing.T) {
 		assert.NoError(t, err)
 
 		_, err = db.Exec(ctx, `
-			INSERT INTO movies (id, title, description, genre, poster_image) VALUES
-			(1, 'Test Movie 1', 'Test Description 1', 'Action', 'poster1.jpg')
+			INSERT INTO movies (id, title, description, poster_image) VALUES
+			(1, 'Test Movie 1', 'Test Description 1', 'poster1.jpg')
 		`)
 		assert.NoError(t, err)
 
 		_, err = db.Exec(ctx, `
-			INSERT INTO showtimes (id, movie_id, start_time, capacity, reserved) VALUES
-			(1, 1, '2024-01-01 10:00:00', 100, 0)
+			INSERT INTO cinemas (id, name, timezone) VALUES (1, 'Main', 'UTC');
+			INSERT INTO showtimes (id, cinema_id, movie_id, start_time, capacity, reserved) VALUES
+			(1, 1, 1, NOW() + INTERVAL '1 day', 100, 0)
 		`)
 		assert.NoError(t, err)
 
-		showtimes, err := repo.GetShowtimes(ctx)
+		showtimes, err := repo.GetShowtimes(ctx, models.ShowtimeFilter{})
 		assert.NoError(t, err)
 		assert.Len(t, showtimes, 1)
 		assert.Equal(t, uint(100), showtimes[0].Capacity)
@@ -75,21 +79,23 @@ func TestShowtimeRepository(t *testing.T) {
 		assert.NoError(t, err)
 
 		_, err = db.Exec(ctx, `
-			INSERT INTO movies (id, title, description, genre, poster_image) VALUES
-			(1, 'Test Movie 1', 'Test Description 1', 'Action', 'poster1.jpg'),
-			(2, 'Test Movie 2', 'Test Description 2', 'Comedy', 'poster2.jpg')
+			INSERT INTO movies (id, title, description, poster_image) VALUES
+			(1, 'Test Movie 1', 'Test Description 1', 'poster1.jpg'),
+			(2, 'Test Movie 2', 'Test Description 2', 'poster2.jpg')
 		`)
 		assert.NoError(t, err)
 
 		_, err = db.Exec(ctx, `
-			INSERT INTO showtimes (id, movie_id, start_time, capacity, reserved) VALUES
-			(1, 1, '2024-01-01 10:00:00', 100, 0)
+			INSERT INTO cinemas (id, name, timezone) VALUES (1, 'Main', 'UTC');
+			INSERT INTO showtimes (id, cinema_id, movie_id, start_time, capacity, reserved) VALUES
+			(1, 1, 1, NOW() + INTERVAL '1 day', 100, 0)
 		`)
 		assert.NoError(t, err)
 
 		updatedShowtime := &models.Showtime{
+			CinemaID:  1,
 			MovieID:   2,
-			StartTime: time.Now(),
+			StartTime: time.Now().Add(time.Hour),
 			Capacity:  150,
 			Reserved:  10,
 		}
@@ -97,38 +103,77 @@ func TestShowtimeRepository(t *testing.T) {
 		err = repo.UpdateShowtime(ctx, 1, updatedShowtime)
 		assert.NoError(t, err)
 
-		showtimes, err := repo.GetShowtimes(ctx)
+		showtimes, err := repo.GetShowtimes(ctx, models.ShowtimeFilter{})
 		assert.NoError(t, err)
 		assert.Equal(t, uint(150), showtimes[0].Capacity)
 		assert.Equal(t, uint(10), showtimes[0].Reserved)
 	})
 
-	t.Run("DeleteShowtime", func(t *testing.T) {
+	t.Run("ArchiveShowtime", func(t *testing.T) {
 		err := test.ClearTestDB(db)
 		assert.NoError(t, err)
 
 		_, err = db.Exec(ctx, `
-			INSERT INTO movies (id, title, description, genre, poster_image) VALUES
-			(1, 'Test Movie 1', 'Test Description 1', 'Action', 'poster1.jpg')
+			INSERT INTO movies (id, title, description, poster_image) VALUES
+			(1, 'Test Movie 1', 'Test Description 1', 'poster1.jpg')
 		`)
 		assert.NoError(t, err)
 
 		_, err = db.Exec(ctx, `
-			INSERT INTO showtimes (id, movie_id, start_time, capacity, reserved) VALUES
-			(1, 1, '2024-01-01 10:00:00', 100, 0)
+			INSERT INTO cinemas (id, name, timezone) VALUES (1, 'Main', 'UTC');
+			INSERT INTO showtimes (id, cinema_id, movie_id, start_time, capacity, reserved) VALUES
+			(1, 1, 1, NOW() + INTERVAL '1 day', 100, 0)
 		`)
 		assert.NoError(t, err)
 
-		showtimes, err := repo.GetShowtimes(ctx)
+		showtimes, err := repo.GetShowtimes(ctx, models.ShowtimeFilter{})
 		assert.NoError(t, err)
 		assert.Len(t, showtimes, 1)
 
-		err = repo.DeleteShowtime(ctx, 1)
+		err = repo.PurgeShowtime(ctx, 1)
+		assert.ErrorIs(t, err, ErrNotArchived)
+
+		err = repo.ArchiveShowtime(ctx, 1)
+		assert.NoError(t, err)
+
+		showtimes, err = repo.GetShowtimes(ctx, models.ShowtimeFilter{})
+		assert.NoError(t, err)
+		assert.Len(t, showtimes, 0)
+
+		archived, err := repo.ListShowtimes(ctx, models.ShowtimeFilter{Archived: true}, pagination.Params{Limit: 10})
+		assert.NoError(t, err)
+		if assert.Len(t, archived.Items, 1) {
+			assert.NotNil(t, archived.Items[0].ArchivedAt)
+		}
+
+		_, err = db.Exec(ctx, `
+			INSERT INTO users (id, username, password_hash, role) VALUES
+			(1, 'testuser1', 'password1', 'user')
+		`)
+		assert.NoError(t, err)
+		reservations := NewReservationRepository(db)
+		err = reservations.ReserveSeat(ctx, &models.Reservation{UserID: 1, MovieID: 1, ShowtimeID: 1, Seats: []string{"A1"}})
+		assert.ErrorIs(t, err, ErrShowtimeUnavailable)
+
+		err = repo.RestoreShowtime(ctx, 1)
+		assert.NoError(t, err)
+		showtimes, err = repo.GetShowtimes(ctx, models.ShowtimeFilter{})
 		assert.NoError(t, err)
+		assert.Len(t, showtimes, 1)
 
-		showtimes, err = repo.GetShowtimes(ctx)
+		// Archiving the movie hides its showtimes as well
+		err = NewMovieRepository(db).ArchiveMovie(ctx, 1)
+		assert.NoError(t, err)
+		showtimes, err = repo.GetShowtimes(ctx, models.ShowtimeFilter{})
 		assert.NoError(t, err)
 		assert.Len(t, showtimes, 0)
+
+		err = repo.ArchiveShowtime(ctx, 1)
+		assert.NoError(t, err)
+		err = repo.PurgeShowtime(ctx, 1)
+		assert.NoError(t, err)
+		err = repo.RestoreShowtime(ctx, 1)
+		assert.ErrorIs(t, err, ErrShowtimeNotFound)
 	})
 
 	t.Run("GetAvailableSeats", func(t *testing.T) {
@@ -136,14 +181,15 @@ func TestShowtimeRepository(t *testing.T) {
 		assert.NoError(t, err)
 
 		_, err = db.Exec(ctx, `
-			INSERT INTO movies (id, title, description, genre, poster_image) VALUES
-			(1, 'Test Movie 1', 'Test Description 1', 'Action', 'poster1.jpg')
+			INSERT INTO movies (id, title, description, poster_image) VALUES
+			(1, 'Test Movie 1', 'Test Description 1', 'poster1.jpg')
 		`)
 		assert.NoError(t, err)
 
 		_, err = db.Exec(ctx, `
-			INSERT INTO showtimes (id, movie_id, start_time, capacity, reserved) VALUES
-			(1, 1, '2024-01-01 10:00:00', 100, 0)
+			INSERT INTO cinemas (id, name, timezone) VALUES (1, 'Main', 'UTC');
+			INSERT INTO showtimes (id, cinema_id, movie_id, start_time, capacity, reserved) VALUES
+			(1, 1, 1, NOW() + INTERVAL '1 day', 100, 0)
 		`)
 		assert.NoError(t, err)
 
@@ -167,4 +213,190 @@ func TestShowtimeRepository(t *testing.T) {
 		assert.Contains(t, availableSeats, "A3")
 		assert.Contains(t, availableSeats, "B1")
 	})
+
+	t.Run("Filters", func(t *testing.T) {
+		err := test.ClearTestDB(db)
+		assert.NoError(t, err)
+
+		// Tokyo is ahead of UTC, so its showtime at 23:00 UTC the day after
+		// tomorrow is on the third day there
+		tomorrow := time.Now().UTC().AddDate(0, 0, 1)
+		day := func(days, hour int) time.Time {
+			return time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day()+days, hour, 0, 0, 0, time.UTC)
+		}
+		_, err = db.Exec(ctx, `
+			INSERT INTO users (id, username, password_hash, role) VALUES (1, 'testuser1', 'password1', 'user');
+			INSERT INTO cinemas (id, name, timezone) VALUES (1, 'Main', 'UTC'), (2, 'Tokyo', 'Asia/Tokyo');
+			INSERT INTO auditoriums (id, cinema_id, name) VALUES (1, 1, 'Hall 1');
+			INSERT INTO movies (id, title, description, poster_image) VALUES
+			(1, 'Test Movie 1', 'Test Description 1', 'poster1.jpg'),
+			(2, 'Test Movie 2', 'Test Description 2', 'poster2.jpg')`)
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = db.Exec(ctx, `
+			INSERT INTO showtimes (id, cinema_id, movie_id, auditorium_id, start_time, capacity, reserved) VALUES
+			(1, 1, 1, NULL, NOW() - INTERVAL '1 hour', 10, 0),
+			(2, 1, 1, 1, $1, 3, 0),
+			(3, 2, 1, NULL, $2, 10, 0),
+			(4, 1, 2, NULL, $3, 10, 0),
+			(5, 1, 1, NULL, $4, 10, 0)`, day(0, 10), day(1, 23), day(0, 12), day(9, 10))
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = db.Exec(ctx, "INSERT INTO reservations (user_id, movie_id, showtime_id, seats) VALUES (1, 1, 2, ARRAY['A1', 'A2'])")
+		assert.NoError(t, err)
+
+		ids := func(filter models.ShowtimeFilter) []uint {
+			showtimes, err := repo.GetShowtimes(ctx, filter)
+			assert.NoError(t, err)
+			var ids []uint
+			for _, showtime := range showtimes {
+				ids = append(ids, showtime.ID)
+			}
+			return ids
+		}
+		from, to := day(1, 0), day(2, 0)
+		assert.Equal(t, []uint{2, 4, 3, 5}, ids(models.ShowtimeFilter{}), "started showtimes are left out")
+		assert.Equal(t, []uint{2, 3, 5}, ids(models.ShowtimeFilter{MovieID: 1}))
+		assert.Equal(t, []uint{3}, ids(models.ShowtimeFilter{CinemaID: 2}))
+		assert.Equal(t, []uint{2}, ids(models.ShowtimeFilter{AuditoriumID: 1}))
+		assert.Equal(t, []uint{4, 3, 5}, ids(models.ShowtimeFilter{MinAvailable: 2}), "two of three seats are booked")
+		assert.Equal(t, []uint{3, 5}, ids(models.ShowtimeFilter{From: &to}), "dates are local to the cinema")
+		assert.Empty(t, ids(models.ShowtimeFilter{From: &from, To: &from}))
+		assert.Equal(t, []uint{2, 4, 3}, ids(models.ShowtimeFilter{Days: 7}))
+
+		showtime, err := repo.GetShowtimeByID(ctx, 2)
+		if assert.NoError(t, err) && assert.NotNil(t, showtime.Available) {
+			assert.Equal(t, uint(1), *showtime.Available)
+		}
+
+		schedule, err := repo.GetMovieShowtimes(ctx, 1, models.ShowtimeFilter{Days: 7})
+		if assert.NoError(t, err) && assert.Len(t, schedule.Days, 2) {
+			assert.Equal(t, day(0, 0).Format(time.DateOnly), schedule.Days[0].Date)
+			assert.Equal(t, uint(2), schedule.Days[0].Showtimes[0].ID)
+			assert.Equal(t, to.Format(time.DateOnly), schedule.Days[1].Date)
+			assert.Equal(t, uint(3), schedule.Days[1].Showtimes[0].ID)
+		}
+		_, err = repo.GetMovieShowtimes(ctx, 3, models.ShowtimeFilter{})
+		assert.ErrorIs(t, err, ErrMovieNotFound)
+	})
+
+	t.Run("AuditoriumConflicts", func(t *testing.T) {
+		err := test.ClearTestDB(db)
+		assert.NoError(t, err)
+
+		// Showtimes of the 90 minute movie take hall 1 for two hours
+		_, err = db.Exec(ctx, `
+			INSERT INTO movies (id, title, description, poster_image, runtime_minutes) VALUES
+			(1, 'Test Movie 1', 'Test Description 1', 'poster1.jpg', 90),
+			(2, 'Test Movie 2', 'Test Description 2', 'poster2.jpg', 0)
+		`)
+		assert.NoError(t, err)
+		_, err = db.Exec(ctx, `
+			INSERT INTO cinemas (id, name, timezone) VALUES (1, 'Main', 'UTC');
+			INSERT INTO auditoriums (id, cinema_id, name, ad_minutes, cleaning_minutes) VALUES
+			(1, 1, 'Hall 1', 20, 10),
+			(2, 1, 'Hall 2', 20, 10)
+		`)
+		assert.NoError(t, err)
+		auditoriums := NewAuditoriumRepository(db)
+
+		start := time.Now().Add(24 * time.Hour).Truncate(time.Hour)
+		first := &models.Showtime{MovieID: 1, AuditoriumID: 1, StartTime: start, Capacity: 100}
+		if !assert.NoError(t, repo.InsertShowtime(ctx, first)) {
+			return
+		}
+		if assert.NotNil(t, first.EndsAt) {
+			assert.Equal(t, start.Add(2*time.Hour), *first.EndsAt)
+		}
+
+		clash := &models.Showtime{MovieID: 1, AuditoriumID: 1, StartTime: start.Add(-30 * time.Minute), Capacity: 100}
+		err = repo.InsertShowtime(ctx, clash)
+		assert.ErrorIs(t, err, ErrShowtimeConflict)
+		var conflict *ShowtimeConflictError
+		if assert.ErrorAs(t, err, &conflict) {
+			assert.Equal(t, first.ID, conflict.ShowtimeID)
+			assert.Equal(t, "Test Movie 1", conflict.MovieTitle)
+			assert.Contains(t, err.Error(), "Hall 1")
+		}
+
+		// Back to back, in another hall or without a hall is fine
+		next := &models.Showtime{MovieID: 1, AuditoriumID: 1, StartTime: start.Add(2 * time.Hour), Capacity: 100}
+		assert.NoError(t, repo.InsertShowtime(ctx, next))
+		assert.NoError(t, repo.InsertShowtime(ctx, &models.Showtime{MovieID: 1, AuditoriumID: 2, StartTime: start, Capacity: 100}))
+		assert.NoError(t, repo.InsertShowtime(ctx, &models.Showtime{CinemaID: 1, MovieID: 1, StartTime: start, Capacity: 100}))
+
+		err = repo.InsertShowtime(ctx, &models.Showtime{MovieID: 2, AuditoriumID: 2, StartTime: start.Add(6 * time.Hour), Capacity: 100})
+		assert.ErrorIs(t, err, ErrRuntimeUnknown)
+
+		// Moving a showtime onto another one fails, and so does longer
+		// cleaning that no longer leaves room between them
+		moved := *next
+		moved.StartTime = start.Add(30 * time.Minute)
+		assert.ErrorIs(t, repo.UpdateShowtime(ctx, int(next.ID), &moved), ErrShowtimeConflict)
+		_, err = auditoriums.UpdateAuditorium(ctx, 1, &models.Auditorium{Name: "Hall 1", AdMinutes: 20, CleaningMinutes: 15})
+		assert.ErrorIs(t, err, ErrShowtimeConflict)
+
+		// An archived showtime frees its slot until it is restored
+		assert.NoError(t, repo.ArchiveShowtime(ctx, int(first.ID)))
+		assert.NoError(t, repo.InsertShowtime(ctx, clash))
+		assert.ErrorIs(t, repo.RestoreShowtime(ctx, int(first.ID)), ErrShowtimeConflict)
+
+		// The constraint holds even for writes that skip the check
+		_, err = db.Exec(ctx, "UPDATE showtimes SET archived_at = NULL WHERE id = $1", first.ID)
+		assert.Error(t, err)
+
+		assert.ErrorIs(t, auditoriums.DeleteAuditorium(ctx, 1), ErrAuditoriumInUse)
+	})
+
+	t.Run("TimeZones", func(t *testing.T) {
+		err := test.ClearTestDB(db)
+		assert.NoError(t, err)
+
+		_, err = db.Exec(ctx, `
+			INSERT INTO cinemas (id, name, timezone) VALUES (1, 'Berlin', 'Europe/Berlin');
+			INSERT INTO users (id, username, password_hash, role) VALUES (1, 'testuser1', 'password1', 'user');
+			INSERT INTO movies (id, title, description, poster_image) VALUES
+			(1, 'Test Movie 1', 'Test Description 1', 'poster1.jpg')
+		`)
+		assert.NoError(t, err)
+
+		// Start times are instants whatever zone they are given in, and are
+		// read back in the local time of the cinema, across the DST change
+		newYork, err := time.LoadLocation("America/New_York")
+		if !assert.NoError(t, err) {
+			return
+		}
+		spring := &models.Showtime{CinemaID: 1, MovieID: 1, StartTime: time.Date(2025, 3, 30, 13, 0, 0, 0, newYork), Capacity: 100}
+		assert.NoError(t, repo.InsertShowtime(ctx, spring))
+		saved, err := repo.GetShowtimeByID(ctx, int(spring.ID))
+		if assert.NoError(t, err) {
+			assert.True(t, spring.StartTime.Equal(saved.StartTime))
+			assert.Equal(t, "2025-03-30T19:00:00+02:00", saved.StartTime.Format(time.RFC3339))
+		}
+
+		// Cancelling is allowed until the showtime starts, whatever zone the
+		// server runs in
+		reservations := NewReservationRepository(db)
+		for _, tc := range []struct {
+			start time.Time
+			ok    bool
+		}{
+			{time.Now().Add(-time.Minute).In(newYork), false},
+			{time.Now().Add(time.Minute).In(newYork), true},
+		} {
+			showtime := &models.Showtime{CinemaID: 1, MovieID: 1, StartTime: tc.start, Capacity: 100, Reserved: 1}
+			if !assert.NoError(t, repo.InsertShowtime(ctx, showtime)) {
+				return
+			}
+			var reservationID int
+			err = db.QueryRow(ctx, `
+				INSERT INTO reservations (user_id, movie_id, showtime_id, seats) VALUES (1, 1, $1, ARRAY['A1'])
+				RETURNING id`, showtime.ID).Scan(&reservationID)
+			assert.NoError(t, err)
+			err = reservations.CancelReservation(ctx, reservationID)
+			assert.Equal(t, tc.ok, err == nil, "start %s: %v", tc.start, err)
+		}
+	})
 }
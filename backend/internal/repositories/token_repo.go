@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposePasswordReset     = "password_reset"
+)
+
+var ErrInvalidToken = errors.New("token is invalid, expired or already used")
+
+// tokenQuerier is implemented by both the pool and transactions.
+type tokenQuerier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// UserTokenRepository stores single-use tokens that are mailed to users.
+// Only a hash of each token is persisted.
+type UserTokenRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewUserTokenRepository(db *pgxpool.Pool) *UserTokenRepository {
+	return &UserTokenRepository{DB: db}
+}
+
+// CreateToken stores a token for the user at the given email address, the
+// one it is mailed to.
+func (repo *UserTokenRepository) CreateToken(ctx context.Context, userID int, email, purpose, tokenHash string, ttl time.Duration) error {
+	_, err := repo.DB.Exec(ctx, `
+		INSERT INTO user_tokens (user_id, email, purpose, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP + $5 * INTERVAL '1 second')`, userID, email, purpose, tokenHash, int(ttl.Seconds()))
+	if err != nil {
+		return fmt.Errorf("error creating token: %w", err)
+	}
+	return nil
+}
+
+// ConsumeToken marks a valid token as used and returns the user it belongs
+// to along with the address it was mailed to. A token can only be consumed
+// once.
+func (repo *UserTokenRepository) ConsumeToken(ctx context.Context, purpose, tokenHash string) (int, string, error) {
+	return consumeToken(ctx, repo.DB, purpose, tokenHash)
+}
+
+func consumeToken(ctx context.Context, db tokenQuerier, purpose, tokenHash string) (int, string, error) {
+	var userID int
+	var email *string
+	err := db.QueryRow(ctx, `
+		UPDATE user_tokens
+		SET used_at = CURRENT_TIMESTAMP
+		WHERE token_hash = $1
+		AND purpose = $2
+		AND used_at IS NULL
+		AND expires_at > CURRENT_TIMESTAMP
+		RETURNING user_id, email`, tokenHash, purpose).Scan(&userID, &email)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, "", ErrInvalidToken
+		}
+		return 0, "", fmt.Errorf("error consuming token: %w", err)
+	}
+	if email == nil {
+		return userID, "", nil
+	}
+	return userID, *email, nil
+}
+
+// InvalidateTokens marks every outstanding token of the given purpose for a
+// user as used.
+func (repo *UserTokenRepository) InvalidateTokens(ctx context.Context, userID int, purpose string) error {
+	return invalidateTokens(ctx, repo.DB, userID, purpose)
+}
+
+func invalidateTokens(ctx context.Context, db tokenQuerier, userID int, purpose string) error {
+	_, err := db.Exec(ctx, `
+		UPDATE user_tokens
+		SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`, userID, purpose)
+	if err != nil {
+		return fmt.Errorf("error invalidating tokens: %w", err)
+	}
+	return nil
+}
+
+// ResetPassword consumes a password reset token and sets the password of its
+// user in one transaction, so that a failure leaves the token usable and no
+// other reset link of the user works afterwards.
+func (repo *UserTokenRepository) ResetPassword(ctx context.Context, tokenHash, password string) error {
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	userID, _, err := consumeToken(ctx, tx, TokenPurposePasswordReset, tokenHash)
+	if err != nil {
+		return err
+	}
+	tag, err := tx.Exec(ctx, `
+		UPDATE users
+		SET password_hash = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2`, hashedPassword, userID)
+	if err != nil {
+		return fmt.Errorf("error updating password: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	if err := invalidateTokens(ctx, tx, userID, TokenPurposePasswordReset); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,312 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie-system/internal/models"
+	"movie-system/internal/pagination"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrTranslationNotFound = errors.New("translation not found")
+
+type TranslationRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewTranslationRepository(db *pgxpool.Pool) *TranslationRepository {
+	return &TranslationRepository{DB: db}
+}
+
+// PreferredMovieTranslations returns, by movie id, the translation of each
+// movie in the first of locales it has one for.
+func (repo *TranslationRepository) PreferredMovieTranslations(ctx context.Context, ids []int, locales []string) (map[uint]models.MovieTranslation, error) {
+	rows, err := repo.DB.Query(ctx, `
+		SELECT DISTINCT ON (movie_id) movie_id, locale, title, description, updated_at
+		FROM movie_translations
+		WHERE movie_id = ANY($1) AND locale = ANY($2)
+		ORDER BY movie_id, array_position($2, locale)`, ids, locales)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching movie translations: %w", err)
+	}
+	defer rows.Close()
+
+	translations := make(map[uint]models.MovieTranslation)
+	for rows.Next() {
+		var movieID uint
+		var translation models.MovieTranslation
+		if err := rows.Scan(&movieID, &translation.Locale, &translation.Title, &translation.Description, &translation.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning movie translation: %w", err)
+		}
+		translations[movieID] = translation
+	}
+	return translations, rows.Err()
+}
+
+// PreferredGenreTranslations is PreferredMovieTranslations for genres.
+func (repo *TranslationRepository) PreferredGenreTranslations(ctx context.Context, ids []int, locales []string) (map[uint]models.GenreTranslation, error) {
+	rows, err := repo.DB.Query(ctx, `
+		SELECT DISTINCT ON (genre_id) genre_id, locale, name, updated_at
+		FROM genre_translations
+		WHERE genre_id = ANY($1) AND locale = ANY($2)
+		ORDER BY genre_id, array_position($2, locale)`, ids, locales)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching genre translations: %w", err)
+	}
+	defer rows.Close()
+
+	translations := make(map[uint]models.GenreTranslation)
+	for rows.Next() {
+		var genreID uint
+		var translation models.GenreTranslation
+		if err := rows.Scan(&genreID, &translation.Locale, &translation.Name, &translation.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning genre translation: %w", err)
+		}
+		translations[genreID] = translation
+	}
+	return translations, rows.Err()
+}
+
+// localeCursor is the locale of the last translation on a page.
+type localeCursor struct {
+	Locale string `json:"locale"`
+}
+
+// ListMovieTranslations returns a page of the translations of a movie by
+// locale.
+func (repo *TranslationRepository) ListMovieTranslations(ctx context.Context, movieID int, params pagination.Params) (*pagination.Page[models.MovieTranslation], error) {
+	var cursor localeCursor
+	if err := pagination.DecodeCursor(params.Cursor, &cursor); err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	if err := repo.DB.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM movies WHERE id = $1)", movieID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("error fetching movie: %w", err)
+	}
+	if !exists {
+		return nil, ErrMovieNotFound
+	}
+
+	var total int
+	err := repo.DB.QueryRow(ctx, "SELECT COUNT(*) FROM movie_translations WHERE movie_id = $1", movieID).Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("error counting movie translations: %w", err)
+	}
+
+	rows, err := repo.DB.Query(ctx, `
+		SELECT locale, title, description, updated_at
+		FROM movie_translations
+		WHERE movie_id = $1 AND locale > $2
+		ORDER BY locale
+		LIMIT $3`, movieID, cursor.Locale, params.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching movie translations: %w", err)
+	}
+	defer rows.Close()
+
+	var translations []models.MovieTranslation
+	for rows.Next() {
+		var translation models.MovieTranslation
+		if err := rows.Scan(&translation.Locale, &translation.Title, &translation.Description, &translation.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning movie translation: %w", err)
+		}
+		translations = append(translations, translation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return pagination.NewPage(translations, params, total, func(translation models.MovieTranslation) string {
+		return pagination.EncodeCursor(localeCursor{Locale: translation.Locale})
+	}), nil
+}
+
+// GetMovieTranslation returns the translation of a movie into locale.
+func (repo *TranslationRepository) GetMovieTranslation(ctx context.Context, movieID int, locale string) (*models.MovieTranslation, error) {
+	var translation models.MovieTranslation
+	err := repo.DB.QueryRow(ctx, `
+		SELECT locale, title, description, updated_at
+		FROM movie_translations
+		WHERE movie_id = $1 AND locale = $2`, movieID, locale).
+		Scan(&translation.Locale, &translation.Title, &translation.Description, &translation.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTranslationNotFound
+		}
+		return nil, fmt.Errorf("error fetching movie translation: %w", err)
+	}
+	return &translation, nil
+}
+
+// SetMovieTranslation creates or replaces the translation of a movie into
+// translation.Locale and refreshes the search document of the movie.
+func (repo *TranslationRepository) SetMovieTranslation(ctx context.Context, movieID int, translation *models.MovieTranslation) error {
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO movie_translations (movie_id, locale, title, description)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (movie_id, locale) DO UPDATE
+		SET title = EXCLUDED.title, description = EXCLUDED.description, updated_at = NOW()
+		RETURNING updated_at`,
+		movieID, translation.Locale, translation.Title, translation.Description).Scan(&translation.UpdatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return ErrMovieNotFound
+		}
+		return fmt.Errorf("error saving movie translation: %w", err)
+	}
+
+	if err := refreshSearchDocuments(ctx, tx, "m.id = $1", movieID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (repo *TranslationRepository) DeleteMovieTranslation(ctx context.Context, movieID int, locale string) error {
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, "DELETE FROM movie_translations WHERE movie_id = $1 AND locale = $2", movieID, locale)
+	if err != nil {
+		return fmt.Errorf("error deleting movie translation: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTranslationNotFound
+	}
+
+	if err := refreshSearchDocuments(ctx, tx, "m.id = $1", movieID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ListGenreTranslations returns a page of the translations of a genre by
+// locale.
+func (repo *TranslationRepository) ListGenreTranslations(ctx context.Context, genreID int, params pagination.Params) (*pagination.Page[models.GenreTranslation], error) {
+	var cursor localeCursor
+	if err := pagination.DecodeCursor(params.Cursor, &cursor); err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	if err := repo.DB.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM genres WHERE id = $1)", genreID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("error fetching genre: %w", err)
+	}
+	if !exists {
+		return nil, ErrGenreNotFound
+	}
+
+	var total int
+	err := repo.DB.QueryRow(ctx, "SELECT COUNT(*) FROM genre_translations WHERE genre_id = $1", genreID).Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("error counting genre translations: %w", err)
+	}
+
+	rows, err := repo.DB.Query(ctx, `
+		SELECT locale, name, updated_at
+		FROM genre_translations
+		WHERE genre_id = $1 AND locale > $2
+		ORDER BY locale
+		LIMIT $3`, genreID, cursor.Locale, params.Limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching genre translations: %w", err)
+	}
+	defer rows.Close()
+
+	var translations []models.GenreTranslation
+	for rows.Next() {
+		var translation models.GenreTranslation
+		if err := rows.Scan(&translation.Locale, &translation.Name, &translation.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning genre translation: %w", err)
+		}
+		translations = append(translations, translation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return pagination.NewPage(translations, params, total, func(translation models.GenreTranslation) string {
+		return pagination.EncodeCursor(localeCursor{Locale: translation.Locale})
+	}), nil
+}
+
+func (repo *TranslationRepository) GetGenreTranslation(ctx context.Context, genreID int, locale string) (*models.GenreTranslation, error) {
+	var translation models.GenreTranslation
+	err := repo.DB.QueryRow(ctx, `
+		SELECT locale, name, updated_at
+		FROM genre_translations
+		WHERE genre_id = $1 AND locale = $2`, genreID, locale).
+		Scan(&translation.Locale, &translation.Name, &translation.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTranslationNotFound
+		}
+		return nil, fmt.Errorf("error fetching genre translation: %w", err)
+	}
+	return &translation, nil
+}
+
+// SetGenreTranslation creates or replaces the translation of a genre and
+// refreshes the search documents of the movies in it.
+func (repo *TranslationRepository) SetGenreTranslation(ctx context.Context, genreID int, translation *models.GenreTranslation) error {
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO genre_translations (genre_id, locale, name)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (genre_id, locale) DO UPDATE
+		SET name = EXCLUDED.name, updated_at = NOW()
+		RETURNING updated_at`,
+		genreID, translation.Locale, translation.Name).Scan(&translation.UpdatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return ErrGenreNotFound
+		}
+		return fmt.Errorf("error saving genre translation: %w", err)
+	}
+
+	if err := refreshSearchDocuments(ctx, tx, "m.id IN (SELECT movie_id FROM movie_genres WHERE genre_id = $1)", genreID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (repo *TranslationRepository) DeleteGenreTranslation(ctx context.Context, genreID int, locale string) error {
+	tx, err := repo.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, "DELETE FROM genre_translations WHERE genre_id = $1 AND locale = $2", genreID, locale)
+	if err != nil {
+		return fmt.Errorf("error deleting genre translation: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTranslationNotFound
+	}
+
+	if err := refreshSearchDocuments(ctx, tx, "m.id IN (SELECT movie_id FROM movie_genres WHERE genre_id = $1)", genreID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -2,14 +2,67 @@ package repositories
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 	"movie-system/internal/models"
+	"movie-system/internal/pagination"
+	"sync"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	ErrUserNotFound       = errors.New("user not found")
+	ErrEmailTaken         = errors.New("email is already in use")
+	ErrUserDisabled       = errors.New("user account is disabled")
+	ErrInvalidCredentials = errors.New("invalid username or password")
+)
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// compareDummyHash spends roughly the same time as checking a real password
+// so that unknown usernames cannot be told apart by response time.
+func compareDummyHash(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+	})
+	bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+func hashPassword(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Printf("Error hashing password: %v", err)
+		return "", err
+	}
+	return string(hashedPassword), nil
+}
+
+// isEmailTaken reports whether err is a violation of the uniqueness of email
+// addresses, which ignores case.
+func isEmailTaken(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" &&
+		(pgErr.ConstraintName == "users_email_key" || pgErr.ConstraintName == "users_email_lower_key")
+}
+
+const userColumns = `id, username, COALESCE(email, ''), email_verified, role, COALESCE(cinema_id, 0), disabled, totp_enabled,
+	COALESCE(display_name, ''), COALESCE(phone, ''), preferred_language, marketing_consent, erased_at, created_at, updated_at`
+
+func scanUser(row pgx.Row, user *models.User) error {
+	return row.Scan(&user.ID, &user.Username, &user.Email, &user.EmailVerified, &user.Role, &user.CinemaID, &user.Disabled, &user.TOTPEnabled,
+		&user.DisplayName, &user.Phone, &user.PreferredLanguage, &user.MarketingConsent, &user.ErasedAt, &user.CreatedAt, &user.UpdatedAt)
+}
+
 type UserRepository struct {
 	db *pgxpool.Pool
 }
@@ -28,12 +81,19 @@ func (repo *UserRepository) SignUp(ctx context.Context, user *models.User) error
 
 	// Insert user into DB
 	_, err = repo.db.Exec(ctx, `
-		INSERT INTO users (username, password_hash, role)
-		VALUES ($1, $2, $3)`, user.Username, string(hashedPassword), user.Role)
+		INSERT INTO users (username, email, password_hash, role, cinema_id)
+		VALUES ($1, NULLIF($2, ''), $3, $4, NULLIF($5, 0))`, user.Username, user.Email, string(hashedPassword), user.Role, user.CinemaID)
 
 	// Log SQL error if it occurs
 	if err != nil {
 		log.Printf("Error inserting user into database: %v", err)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return ErrCinemaNotFound
+		}
+		if isEmailTaken(err) {
+			return ErrEmailTaken
+		}
 		return err
 	}
 
@@ -56,16 +116,25 @@ func (repo *UserRepository) SignUp(ctx context.Context, user *models.User) error
 
 func (repo *UserRepository) AuthenticateUser(ctx context.Context, username, password string) (string, error) {
 	var storedHash, role string
+	var disabled bool
 
 	err := repo.db.QueryRow(ctx, `
-		SELECT password_hash, role FROM users WHERE username = $1`, username).
-		Scan(&storedHash, &role)
+		SELECT password_hash, role, disabled FROM users WHERE username = $1`, username).
+		Scan(&storedHash, &role, &disabled)
 	if err != nil {
-		return "", err
+		if errors.Is(err, pgx.ErrNoRows) {
+			compareDummyHash(password)
+			return "", ErrInvalidCredentials
+		}
+		return "", fmt.Errorf("error fetching user: %w", err)
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(storedHash), []byte(password)); err != nil {
-		return "", err
+		return "", ErrInvalidCredentials
+	}
+
+	if disabled {
+		return "", ErrUserDisabled
 	}
 
 	return role, nil
@@ -79,3 +148,270 @@ func (repo *UserRepository) GetUserID(ctx context.Context, username string) (int
 	}
 	return userID, nil
 }
+
+func (repo *UserRepository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	var user models.User
+	err := scanUser(repo.db.QueryRow(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE username = $1`, username), &user)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (repo *UserRepository) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	var user models.User
+	err := scanUser(repo.db.QueryRow(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE id = $1`, id), &user)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ListUsers returns a page of users ordered by id. When search is not empty
+// only users whose username or email contains it (case-insensitively) are
+// returned. When cinemaID is not 0 head office and the admins of other
+// cinemas are left out.
+func (repo *UserRepository) ListUsers(ctx context.Context, search string, cinemaID uint, params pagination.Params) (*pagination.Page[models.User], error) {
+	afterID, err := pagination.AfterID(params)
+	if err != nil {
+		return nil, err
+	}
+
+	const matches = `($1 = '' OR username ILIKE '%' || $1 || '%' OR email ILIKE '%' || $1 || '%')
+		AND ($2 = 0 OR role = 'user' OR role = 'admin' AND cinema_id = $2)`
+
+	var total int
+	if err := repo.db.QueryRow(ctx, `SELECT COUNT(*) FROM users WHERE `+matches, search, cinemaID).Scan(&total); err != nil {
+		return nil, fmt.Errorf("error counting users: %w", err)
+	}
+
+	rows, err := repo.db.Query(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE `+matches+` AND id > $3
+		ORDER BY id
+		LIMIT $4`, search, cinemaID, afterID, params.Limit+1)
+	if err != nil {
+		log.Printf("error fetching users: %v", err)
+		return nil, fmt.Errorf("error fetching users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var user models.User
+		if err := scanUser(rows, &user); err != nil {
+			return nil, fmt.Errorf("error scanning user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+
+	return pagination.NewPage(users, params, total, func(user models.User) string {
+		return pagination.IDCursorOf(user.ID)
+	}), nil
+}
+
+// UpdateUserRole changes the role of a user and the cinema they run, which
+// only admins have.
+func (repo *UserRepository) UpdateUserRole(ctx context.Context, id int, role string, cinemaID uint) error {
+	tag, err := repo.db.Exec(ctx, `
+		UPDATE users
+		SET role = $1, cinema_id = NULLIF($3, 0), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2`, role, id, cinemaID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return ErrCinemaNotFound
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (repo *UserRepository) SetUserDisabled(ctx context.Context, id int, disabled bool) error {
+	tag, err := repo.db.Exec(ctx, `
+		UPDATE users
+		SET disabled = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2`, disabled, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (repo *UserRepository) DeleteUser(ctx context.Context, id int) error {
+	tag, err := repo.db.Exec(ctx, "DELETE FROM users WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (repo *UserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	err := scanUser(repo.db.QueryRow(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE lower(email) = lower($1)`, email), &user)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SetEmailVerified marks the address of the user as verified, provided it is
+// still email. A link mailed to an address the user has since replaced
+// returns ErrInvalidToken.
+func (repo *UserRepository) SetEmailVerified(ctx context.Context, id int, email string) error {
+	tag, err := repo.db.Exec(ctx, `
+		UPDATE users
+		SET email_verified = TRUE, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND lower(email) = lower($2)`, id, email)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+func (repo *UserRepository) UpdatePassword(ctx context.Context, id int, password string) error {
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	tag, err := repo.db.Exec(ctx, `
+		UPDATE users
+		SET password_hash = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2`, hashedPassword, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// CreateExternalUser creates a plain user for someone signing in through an
+// external identity provider. The account gets a random password that is
+// never revealed, so it can only be used through the provider until the user
+// resets it.
+func (repo *UserRepository) CreateExternalUser(ctx context.Context, username, email string) (*models.User, error) {
+	password := make([]byte, 32)
+	if _, err := rand.Read(password); err != nil {
+		return nil, fmt.Errorf("error generating password: %w", err)
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(password)), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	err = scanUser(repo.db.QueryRow(ctx, `
+		INSERT INTO users (username, email, email_verified, password_hash, role)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5)
+		RETURNING `+userColumns, username, email, email != "", string(hashedPassword), models.RoleUser), &user)
+	if err != nil {
+		if isEmailTaken(err) {
+			return nil, ErrEmailTaken
+		}
+		return nil, fmt.Errorf("error inserting user: %w", err)
+	}
+	return &user, nil
+}
+
+// UpdateProfile applies the non-nil fields of update; empty strings clear
+// the optional fields. Changing the email address marks it as unverified and
+// drops the verification links mailed to the previous one.
+func (repo *UserRepository) UpdateProfile(ctx context.Context, id int, update models.ProfileUpdate) error {
+	tx, err := repo.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE users
+		SET display_name = CASE WHEN $1::text IS NULL THEN display_name ELSE NULLIF($1, '') END,
+			email_verified = CASE WHEN $2::text IS NULL OR lower($2) = lower(COALESCE(email, '')) THEN email_verified ELSE FALSE END,
+			email = CASE WHEN $2::text IS NULL THEN email ELSE NULLIF($2, '') END,
+			phone = CASE WHEN $3::text IS NULL THEN phone ELSE NULLIF($3, '') END,
+			preferred_language = COALESCE($4, preferred_language),
+			marketing_consent = COALESCE($5, marketing_consent),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6`,
+		update.DisplayName, update.Email, update.Phone, update.PreferredLanguage, update.MarketingConsent, id)
+	if err != nil {
+		if isEmailTaken(err) {
+			return ErrEmailTaken
+		}
+		return fmt.Errorf("error updating profile: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	if update.Email != nil {
+		_, err = tx.Exec(ctx, `
+			DELETE FROM user_tokens t
+			USING users u
+			WHERE t.user_id = u.id
+			AND u.id = $1
+			AND t.purpose = $2
+			AND t.used_at IS NULL
+			AND lower(COALESCE(t.email, '')) <> lower(COALESCE(u.email, ''))`, id, TokenPurposeEmailVerification)
+		if err != nil {
+			return fmt.Errorf("error deleting verification tokens: %w", err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// CheckPassword returns ErrInvalidCredentials unless password is the current
+// password of the user.
+func (repo *UserRepository) CheckPassword(ctx context.Context, id int, password string) error {
+	var storedHash string
+	err := repo.db.QueryRow(ctx, "SELECT password_hash FROM users WHERE id = $1", id).Scan(&storedHash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("error fetching user: %w", err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(storedHash), []byte(password)); err != nil {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
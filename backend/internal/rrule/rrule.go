@@ -0,0 +1,277 @@
+// Package rrule expands recurrence rules in the format of the RRULE property
+// of iCalendar (RFC 5545) into dates. It supports the parts that make sense
+// for programming a run of showtimes: FREQ=DAILY, WEEKLY or MONTHLY, INTERVAL,
+// COUNT, UNTIL, BYDAY, BYMONTHDAY, BYMONTH and WKST. Rules yield whole days;
+// the times of day are given separately, so BYHOUR, BYMINUTE and BYSECOND are
+// rejected, as are the other frequencies, BYSETPOS and numbered BYDAY values
+// such as 1MO.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalid = errors.New("invalid recurrence rule")
+	// ErrTooMany is returned when a rule yields more dates than asked for,
+	// which every rule without COUNT or UNTIL does.
+	ErrTooMany = errors.New("recurrence rule yields too many dates")
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+// maxPeriods bounds the days, weeks or months Dates looks at, so that rules
+// whose filters rarely or never match, like BYMONTH=2;BYMONTHDAY=30, end.
+const maxPeriods = 100 * 366
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Rule is a parsed recurrence rule. Until is a date at midnight UTC and, as
+// in RFC 5545, inclusive.
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      *time.Time
+	ByDay      []time.Weekday
+	ByMonthDay []int
+	ByMonth    []time.Month
+	WeekStart  time.Weekday
+}
+
+// Parse reads a rule such as "FREQ=DAILY;UNTIL=20250314;BYDAY=TU,WE,TH,FR,SA,SU",
+// with or without the "RRULE:" prefix. Parts are case-insensitive.
+func Parse(value string) (*Rule, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	value = strings.TrimPrefix(value, "RRULE:")
+	rule := &Rule{Interval: 1, WeekStart: time.Monday}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ";") {
+		name, arg, ok := strings.Cut(part, "=")
+		if !ok || arg == "" {
+			return nil, fmt.Errorf("%w: %q is not NAME=VALUE", ErrInvalid, part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: %s is given twice", ErrInvalid, name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			rule.Freq = Frequency(arg)
+			if rule.Freq != Daily && rule.Freq != Weekly && rule.Freq != Monthly {
+				return nil, fmt.Errorf("%w: FREQ must be DAILY, WEEKLY or MONTHLY", ErrInvalid)
+			}
+		case "INTERVAL":
+			rule.Interval, err = parseInt(name, arg, 1, 1000)
+		case "COUNT":
+			rule.Count, err = parseInt(name, arg, 1, 10000)
+		case "UNTIL":
+			rule.Until, err = parseUntil(arg)
+		case "BYDAY":
+			for _, day := range strings.Split(arg, ",") {
+				weekday, ok := weekdays[day]
+				if !ok {
+					return nil, fmt.Errorf("%w: BYDAY takes MO, TU, WE, TH, FR, SA or SU, got %q", ErrInvalid, day)
+				}
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(arg, ",") {
+				n, err := parseInt(name, day, -31, 31)
+				if err != nil {
+					return nil, err
+				}
+				if n == 0 {
+					return nil, fmt.Errorf("%w: BYMONTHDAY cannot be 0", ErrInvalid)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, n)
+			}
+		case "BYMONTH":
+			for _, month := range strings.Split(arg, ",") {
+				n, err := parseInt(name, month, 1, 12)
+				if err != nil {
+					return nil, err
+				}
+				rule.ByMonth = append(rule.ByMonth, time.Month(n))
+			}
+		case "WKST":
+			weekday, ok := weekdays[arg]
+			if !ok {
+				return nil, fmt.Errorf("%w: WKST takes MO, TU, WE, TH, FR, SA or SU, got %q", ErrInvalid, arg)
+			}
+			rule.WeekStart = weekday
+		default:
+			return nil, fmt.Errorf("%w: %s is not supported", ErrInvalid, name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case rule.Freq == "":
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalid)
+	case rule.Count != 0 && rule.Until != nil:
+		return nil, fmt.Errorf("%w: COUNT and UNTIL cannot both be given", ErrInvalid)
+	case rule.Freq == Weekly && len(rule.ByMonthDay) > 0:
+		return nil, fmt.Errorf("%w: BYMONTHDAY cannot be used with FREQ=WEEKLY", ErrInvalid)
+	}
+	return rule, nil
+}
+
+func parseInt(name, value string, min, max int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("%w: %s must be a number from %d to %d, got %q", ErrInvalid, name, min, max, value)
+	}
+	return n, nil
+}
+
+// parseUntil reads a date, or a date-time of which only the date counts.
+func parseUntil(value string) (*time.Time, error) {
+	for _, layout := range []string{"20060102", "20060102T150405Z", "20060102T150405"} {
+		if until, err := time.Parse(layout, value); err == nil {
+			until = dateOf(until)
+			return &until, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: UNTIL must be a date like 20250314, got %q", ErrInvalid, value)
+}
+
+// Dates returns the dates the rule yields from start, its DTSTART, on, in
+// order and at midnight UTC. Only the date of start counts. It fails with
+// ErrTooMany once there are more than max dates.
+func (r *Rule) Dates(start time.Time, max int) ([]time.Time, error) {
+	start = dateOf(start)
+	var dates []time.Time
+	period := r.periodOf(start)
+	for range maxPeriods {
+		for _, date := range r.candidates(period, start) {
+			if date.Before(start) || !r.matches(date) {
+				continue
+			}
+			if r.Until != nil && date.After(*r.Until) {
+				return dates, nil
+			}
+			if len(dates) == max {
+				return nil, ErrTooMany
+			}
+			dates = append(dates, date)
+			if len(dates) == r.Count {
+				return dates, nil
+			}
+		}
+		period = r.next(period)
+	}
+	return dates, nil
+}
+
+// periodOf returns the first day of the day, week or month start is in.
+func (r *Rule) periodOf(start time.Time) time.Time {
+	switch r.Freq {
+	case Weekly:
+		return start.AddDate(0, 0, -int((start.Weekday()-r.WeekStart+7)%7))
+	case Monthly:
+		return time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return start
+	}
+}
+
+func (r *Rule) next(period time.Time) time.Time {
+	switch r.Freq {
+	case Weekly:
+		return period.AddDate(0, 0, 7*r.Interval)
+	case Monthly:
+		return period.AddDate(0, r.Interval, 0)
+	default:
+		return period.AddDate(0, 0, r.Interval)
+	}
+}
+
+// candidates returns the days of a period, in order, that the BYxxx parts
+// expand to, or the day matching start if there are none.
+func (r *Rule) candidates(period, start time.Time) []time.Time {
+	var days []time.Time
+	switch r.Freq {
+	case Weekly:
+		byDay := r.ByDay
+		if len(byDay) == 0 {
+			byDay = []time.Weekday{start.Weekday()}
+		}
+		for i := range 7 {
+			if day := period.AddDate(0, 0, i); slices.Contains(byDay, day.Weekday()) {
+				days = append(days, day)
+			}
+		}
+	case Monthly:
+		length := period.AddDate(0, 1, -1).Day()
+		var monthDays []int
+		switch {
+		case len(r.ByMonthDay) > 0:
+			for _, n := range r.ByMonthDay {
+				if n < 0 {
+					n += length + 1
+				}
+				if n >= 1 && n <= length && !slices.Contains(monthDays, n) {
+					monthDays = append(monthDays, n)
+				}
+			}
+			slices.Sort(monthDays)
+		case len(r.ByDay) > 0:
+			for n := 1; n <= length; n++ {
+				monthDays = append(monthDays, n)
+			}
+		case start.Day() <= length:
+			monthDays = []int{start.Day()}
+		}
+		for _, n := range monthDays {
+			days = append(days, period.AddDate(0, 0, n-1))
+		}
+	default:
+		days = []time.Time{period}
+	}
+	return days
+}
+
+// matches applies the BYxxx parts that limit rather than expand.
+func (r *Rule) matches(date time.Time) bool {
+	if len(r.ByMonth) > 0 && !slices.Contains(r.ByMonth, date.Month()) {
+		return false
+	}
+	if len(r.ByDay) > 0 && !slices.Contains(r.ByDay, date.Weekday()) {
+		return false
+	}
+	if len(r.ByMonthDay) > 0 {
+		length := time.Date(date.Year(), date.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		if !slices.Contains(r.ByMonthDay, date.Day()) && !slices.Contains(r.ByMonthDay, date.Day()-length-1) {
+			return false
+		}
+	}
+	return true
+}
+
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
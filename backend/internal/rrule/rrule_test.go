@@ -0,0 +1,125 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestDates(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  string
+		start time.Time
+		want  []time.Time
+	}{
+		{
+			name:  "daily except Mondays",
+			rule:  "FREQ=DAILY;UNTIL=20250309;BYDAY=TU,WE,TH,FR,SA,SU",
+			start: date(2025, 3, 1),
+			want:  []time.Time{date(2025, 3, 1), date(2025, 3, 2), date(2025, 3, 4), date(2025, 3, 5), date(2025, 3, 6), date(2025, 3, 7), date(2025, 3, 8), date(2025, 3, 9)},
+		},
+		{
+			name:  "UNTIL with a time counts the whole day",
+			rule:  "RRULE:freq=daily;until=20250303T000000Z",
+			start: time.Date(2025, 3, 1, 19, 30, 0, 0, time.UTC),
+			want:  []time.Time{date(2025, 3, 1), date(2025, 3, 2), date(2025, 3, 3)},
+		},
+		{
+			name:  "every other week on Monday and Friday",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;COUNT=5",
+			start: date(2025, 3, 5),
+			want:  []time.Time{date(2025, 3, 7), date(2025, 3, 17), date(2025, 3, 21), date(2025, 3, 31), date(2025, 4, 4)},
+		},
+		{
+			name:  "weekly on the weekday of the start",
+			rule:  "FREQ=WEEKLY;COUNT=3",
+			start: date(2025, 3, 5),
+			want:  []time.Time{date(2025, 3, 5), date(2025, 3, 12), date(2025, 3, 19)},
+		},
+		{
+			name:  "monthly on the 31st skips short months",
+			rule:  "FREQ=MONTHLY;COUNT=3",
+			start: date(2025, 1, 31),
+			want:  []time.Time{date(2025, 1, 31), date(2025, 3, 31), date(2025, 5, 31)},
+		},
+		{
+			name:  "last day of the month",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=3",
+			start: date(2025, 1, 15),
+			want:  []time.Time{date(2025, 1, 31), date(2025, 2, 28), date(2025, 3, 31)},
+		},
+		{
+			name:  "Friday the 13th",
+			rule:  "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13;COUNT=2",
+			start: date(2025, 1, 1),
+			want:  []time.Time{date(2025, 6, 13), date(2026, 2, 13)},
+		},
+		{
+			name:  "daily in summer only",
+			rule:  "FREQ=DAILY;INTERVAL=10;BYMONTH=7,8;UNTIL=20250831",
+			start: date(2025, 6, 21),
+			want:  []time.Time{date(2025, 7, 1), date(2025, 7, 11), date(2025, 7, 21), date(2025, 7, 31), date(2025, 8, 10), date(2025, 8, 20), date(2025, 8, 30)},
+		},
+		{
+			name:  "never matches",
+			rule:  "FREQ=MONTHLY;BYMONTH=2;BYMONTHDAY=30;COUNT=1",
+			start: date(2025, 1, 1),
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if !assert.NoError(t, err) {
+				return
+			}
+			dates, err := rule.Dates(tt.start, 100)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, dates)
+		})
+	}
+}
+
+func TestDatesTooMany(t *testing.T) {
+	rule, err := Parse("FREQ=DAILY")
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = rule.Dates(date(2025, 1, 1), 100)
+	assert.ErrorIs(t, err, ErrTooMany)
+
+	rule, err = Parse("FREQ=DAILY;COUNT=100")
+	if !assert.NoError(t, err) {
+		return
+	}
+	dates, err := rule.Dates(date(2025, 1, 1), 100)
+	assert.NoError(t, err)
+	assert.Len(t, dates, 100)
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"COUNT=3",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;COUNT=3;UNTIL=20250301",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;BYHOUR=14",
+		"FREQ=MONTHLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=DAILY;UNTIL=2025-03-01",
+		"FREQ=DAILY;BYMONTH=13",
+		"FREQ=DAILY;COUNT",
+	} {
+		_, err := Parse(value)
+		assert.ErrorIs(t, err, ErrInvalid, value)
+	}
+}
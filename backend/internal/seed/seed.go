@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"movie-system/config"
+	"movie-system/internal/models"
+	"movie-system/internal/repositories"
+	"movie-system/internal/site"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
@@ -18,48 +21,81 @@ func SeedDatabase() error {
 		return fmt.Errorf("failed to hash admin password: %w", err)
 	}
 
+	// The seeded admin is head office and runs every cinema
 	_, err = config.DB.Exec(ctx, `
 			INSERT INTO users (username, password_hash, role, created_at, updated_at)
 			VALUES ($1, $2, $3, $4, $5)
 			ON CONFLICT (username) DO NOTHING`,
-		"admin", string(hashedPassword), "admin", time.Now(), time.Now())
+		"admin", string(hashedPassword), models.RoleHeadOffice, time.Now(), time.Now())
 	if err != nil {
 		return fmt.Errorf("failed to insert admin user: %w", err)
 	}
+
+	_, err = config.DB.Exec(ctx, `
+			INSERT INTO cinemas (name, timezone)
+			VALUES ($1, $2)
+			ON CONFLICT (name) DO NOTHING`,
+		"Main", site.Location().String())
+	if err != nil {
+		return fmt.Errorf("failed to insert cinema: %w", err)
+	}
 	movies := []struct {
 		Title       string
 		Description string
-		Genre       string
+		Genres      []string
 		PosterImage string
 	}{
 		{
 			Title:       "Inception",
 			Description: "A thief who steals corporate secrets through the use of dream-sharing technology.",
-			Genre:       "Sci-Fi",
+			Genres:      []string{"Sci-Fi"},
 			PosterImage: "https://m.media-amazon.com/images/M/MV5BMjAxMzY3NjcxNF5BMl5BanBnXkFtZTcwNTI5OTM0Mw@@._V1_FMjpg_UX1000_.jpg",
 		},
 		{
 			Title:       "The Dark Knight",
 			Description: "When the menace known as the Joker emerges, Batman must confront chaos.",
-			Genre:       "Action",
+			Genres:      []string{"Action", "Crime"},
 			PosterImage: "https://m.media-amazon.com/images/M/MV5BMTMxNTMwODM0NF5BMl5BanBnXkFtZTcwODAyMTk2Mw@@._V1_FMjpg_UX1000_.jpg",
 		},
 		{
 			Title:       "Interstellar",
 			Description: "A team of explorers travel through a wormhole in space in an attempt to ensure humanity's survival.",
-			Genre:       "Sci-Fi",
+			Genres:      []string{"Sci-Fi"},
 			PosterImage: "https://m.media-amazon.com/images/M/MV5BYzdjMDAxZGItMjI2My00ODA1LTlkNzItOWFjMDU5ZDJlYWY3XkEyXkFqcGc@._V1_FMjpg_UX1000_.jpg",
 		},
 	}
 	for _, movie := range movies {
 		_, err := config.DB.Exec(ctx, `
-				INSERT INTO movies (title, description, genre, poster_image)
-				VALUES ($1,$2,$3,$4)
+				INSERT INTO movies (title, description, poster_image)
+				VALUES ($1,$2,$3)
 				ON CONFLICT (title) DO NOTHING`,
-			movie.Title, movie.Description, movie.Genre, movie.PosterImage)
+			movie.Title, movie.Description, movie.PosterImage)
 		if err != nil {
 			return fmt.Errorf("failed to insert movie: %w", err)
 		}
+		for _, genre := range movie.Genres {
+			_, err := config.DB.Exec(ctx, `
+					INSERT INTO genres (name, slug)
+					VALUES ($1,$2)
+					ON CONFLICT (slug) DO NOTHING`,
+				genre, models.Slugify(genre))
+			if err != nil {
+				return fmt.Errorf("failed to insert genre: %w", err)
+			}
+			_, err = config.DB.Exec(ctx, `
+					INSERT INTO movie_genres (movie_id, genre_id)
+					SELECT m.id, g.id FROM movies m, genres g
+					WHERE m.title = $1 AND g.slug = $2
+					ON CONFLICT DO NOTHING`,
+				movie.Title, models.Slugify(genre))
+			if err != nil {
+				return fmt.Errorf("failed to link genre: %w", err)
+			}
+		}
+	}
+	// Also backfills movies that were added before search existed
+	if err := repositories.NewMovieRepository(config.DB).RefreshSearchDocuments(ctx, 0); err != nil {
+		return fmt.Errorf("failed to build search index: %w", err)
 	}
 	// showtimes := []struct {
 	// 	MovieID   uint
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"movie-system/internal/mail"
+	"movie-system/internal/models"
+	"movie-system/internal/repositories"
+	netmail "net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	verificationTokenTTL  = 48 * time.Hour
+	passwordResetTokenTTL = time.Hour
+)
+
+var (
+	ErrEmailAlreadyVerified = errors.New("email is already verified")
+	ErrInvalidProfile       = errors.New("invalid profile")
+)
+
+var (
+	phonePattern    = regexp.MustCompile(`^\+?[0-9][0-9 ()-]{4,30}$`)
+	languagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})?$`)
+)
+
+// AccountService implements the account recovery flows that rely on tokens
+// delivered by mail: email verification and password reset.
+type AccountService struct {
+	users  *repositories.UserRepository
+	tokens *repositories.UserTokenRepository
+	mailer mail.Mailer
+	appURL string
+}
+
+func NewAccountService(users *repositories.UserRepository, tokens *repositories.UserTokenRepository, mailer mail.Mailer, appURL string) *AccountService {
+	return &AccountService{users: users, tokens: tokens, mailer: mailer, appURL: appURL}
+}
+
+func (s *AccountService) SendVerificationEmail(ctx context.Context, user *models.User) error {
+	if user.Email == "" {
+		return fmt.Errorf("user %s has no email address", user.Username)
+	}
+	if user.EmailVerified {
+		return ErrEmailAlreadyVerified
+	}
+
+	token, err := s.issueToken(ctx, user, repositories.TokenPurposeEmailVerification, verificationTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\nThe link expires in %d hours.\n",
+			user.Username, s.link("/verify-email", token), int(verificationTokenTTL.Hours())),
+	})
+}
+
+func (s *AccountService) VerifyEmail(ctx context.Context, token string) error {
+	userID, email, err := s.tokens.ConsumeToken(ctx, repositories.TokenPurposeEmailVerification, hashToken(token))
+	if err != nil {
+		return err
+	}
+	return s.users.SetEmailVerified(ctx, userID, email)
+}
+
+// RequestPasswordReset mails a reset link to the account registered with
+// email. Unknown addresses are not reported so the endpoint cannot be used to
+// discover which emails have accounts.
+func (s *AccountService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.users.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			log.Printf("Password reset requested for unknown email %s", email)
+			return nil
+		}
+		return err
+	}
+	if user.Disabled {
+		log.Printf("Password reset requested for disabled user %s", user.Username)
+		return nil
+	}
+
+	token, err := s.issueToken(ctx, user, repositories.TokenPurposePasswordReset, passwordResetTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password for your account. If it was you, open the link below:\n\n%s\n\nThe link expires in %d minutes and can only be used once. If you did not ask for a reset you can ignore this message.\n",
+			user.Username, s.link("/reset-password", token), int(passwordResetTokenTTL.Minutes())),
+	})
+}
+
+func (s *AccountService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if newPassword == "" {
+		return fmt.Errorf("password must not be empty")
+	}
+
+	// Any other reset links that are still in flight stop working as well.
+	return s.tokens.ResetPassword(ctx, hashToken(token), newPassword)
+}
+
+// UpdateProfile changes the profile of user and returns the updated user. A
+// new email address has to be verified again, so a verification mail is sent
+// to it.
+func (s *AccountService) UpdateProfile(ctx context.Context, user *models.User, update models.ProfileUpdate) (*models.User, error) {
+	if err := validateProfile(&update); err != nil {
+		return nil, err
+	}
+
+	if err := s.users.UpdateProfile(ctx, int(user.ID), update); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.users.GetUserByID(ctx, int(user.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	if updated.Email != "" && !strings.EqualFold(updated.Email, user.Email) {
+		if err := s.SendVerificationEmail(ctx, updated); err != nil {
+			log.Printf("Error sending verification email to %s: %v", updated.Username, err)
+		}
+	}
+	return updated, nil
+}
+
+// ChangePassword sets a new password after checking the current one.
+func (s *AccountService) ChangePassword(ctx context.Context, user *models.User, currentPassword, newPassword string) error {
+	if newPassword == "" {
+		return fmt.Errorf("%w: password must not be empty", ErrInvalidProfile)
+	}
+
+	if err := s.users.CheckPassword(ctx, int(user.ID), currentPassword); err != nil {
+		return err
+	}
+
+	if err := s.users.UpdatePassword(ctx, int(user.ID), newPassword); err != nil {
+		return err
+	}
+	return s.tokens.InvalidateTokens(ctx, int(user.ID), repositories.TokenPurposePasswordReset)
+}
+
+// validateProfile trims the fields of update and checks their format.
+func validateProfile(update *models.ProfileUpdate) error {
+	for _, field := range []*string{update.DisplayName, update.Email, update.Phone, update.PreferredLanguage} {
+		if field != nil {
+			*field = strings.TrimSpace(*field)
+		}
+	}
+
+	if update.DisplayName != nil && len(*update.DisplayName) > 255 {
+		return fmt.Errorf("%w: display name is too long", ErrInvalidProfile)
+	}
+	if update.Email != nil && *update.Email != "" && !validEmail(*update.Email) {
+		return fmt.Errorf("%w: invalid email address", ErrInvalidProfile)
+	}
+	if update.Phone != nil && *update.Phone != "" && !phonePattern.MatchString(*update.Phone) {
+		return fmt.Errorf("%w: invalid phone number", ErrInvalidProfile)
+	}
+	if update.PreferredLanguage != nil && !languagePattern.MatchString(*update.PreferredLanguage) {
+		return fmt.Errorf("%w: invalid language tag", ErrInvalidProfile)
+	}
+	return nil
+}
+
+// validEmail reports whether email is a bare address such as
+// jane@example.com, without a display name or anything else that would end
+// up in the headers of mails sent to it.
+func validEmail(email string) bool {
+	address, err := netmail.ParseAddress(email)
+	return err == nil && address.Address == email && len(email) <= 255
+}
+
+func (s *AccountService) issueToken(ctx context.Context, user *models.User, purpose string, ttl time.Duration) (string, error) {
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.tokens.CreateToken(ctx, int(user.ID), user.Email, purpose, hashToken(token), ttl); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *AccountService) link(path, token string) string {
+	return s.appURL + path + "?token=" + url.QueryEscape(token)
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"movie-system/internal/mail"
+	"movie-system/internal/models"
+	"movie-system/internal/repositories"
+	"movie-system/test"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var linkPattern = regexp.MustCompile(`https?://\S+`)
+
+func tokenFromMessage(t *testing.T, msg mail.Message) string {
+	t.Helper()
+	link := linkPattern.FindString(msg.Body)
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("Failed to parse link %q: %v", link, err)
+	}
+	return u.Query().Get("token")
+}
+
+func TestAccountService(t *testing.T) {
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+
+	userRepo := repositories.NewUserRepository(db)
+	tokenRepo := repositories.NewUserTokenRepository(db)
+	mailer := &mail.RecordingMailer{}
+	mfaService := NewMFAService(repositories.NewMFARepository(db), "Movie System")
+	loginGuard := NewLoginGuard(repositories.NewLoginAttemptRepository(db))
+	authService := NewAuthService(userRepo, mfaService, loginGuard, "secret")
+	service := NewAccountService(userRepo, tokenRepo, mailer, "http://localhost:5173")
+	ctx := context.Background()
+
+	t.Run("VerifyEmail", func(t *testing.T) {
+		err := test.ClearTestDB(db)
+		assert.NoError(t, err)
+
+		user, err := authService.SignUp(ctx, "jane", "jane@example.com", "password1")
+		assert.NoError(t, err)
+		assert.False(t, user.EmailVerified)
+
+		err = service.SendVerificationEmail(ctx, user)
+		assert.NoError(t, err)
+
+		msg, ok := mailer.Last()
+		assert.True(t, ok)
+		assert.Equal(t, "jane@example.com", msg.To)
+
+		token := tokenFromMessage(t, msg)
+		assert.NoError(t, service.VerifyEmail(ctx, token))
+		assert.ErrorIs(t, service.VerifyEmail(ctx, token), repositories.ErrInvalidToken)
+
+		user, err = userRepo.GetUserByUsername(ctx, "jane")
+		assert.NoError(t, err)
+		assert.True(t, user.EmailVerified)
+	})
+
+	t.Run("VerifyReplacedEmail", func(t *testing.T) {
+		err := test.ClearTestDB(db)
+		assert.NoError(t, err)
+
+		user, err := authService.SignUp(ctx, "mallory", "mallory@example.com", "password1")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NoError(t, service.SendVerificationEmail(ctx, user))
+		msg, _ := mailer.Last()
+		stale := tokenFromMessage(t, msg)
+
+		// A link mailed to the old address must not verify the new one
+		victim := "jane@example.com"
+		user, err = service.UpdateProfile(ctx, user, models.ProfileUpdate{Email: &victim})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.ErrorIs(t, service.VerifyEmail(ctx, stale), repositories.ErrInvalidToken)
+
+		assert.ErrorIs(t, userRepo.SetEmailVerified(ctx, int(user.ID), "mallory@example.com"), repositories.ErrInvalidToken)
+		user, err = userRepo.GetUserByID(ctx, int(user.ID))
+		assert.NoError(t, err)
+		assert.False(t, user.EmailVerified)
+	})
+
+	t.Run("ResetPassword", func(t *testing.T) {
+		err := test.ClearTestDB(db)
+		assert.NoError(t, err)
+
+		_, err = authService.SignUp(ctx, "jane", "jane@example.com", "password1")
+		assert.NoError(t, err)
+
+		sent := len(mailer.Messages())
+		assert.NoError(t, service.RequestPasswordReset(ctx, "nobody@example.com"))
+		assert.Len(t, mailer.Messages(), sent)
+
+		assert.NoError(t, service.RequestPasswordReset(ctx, "jane@example.com"))
+		first, _ := mailer.Last()
+		assert.NoError(t, service.RequestPasswordReset(ctx, "jane@example.com"))
+		second, _ := mailer.Last()
+
+		assert.NoError(t, service.ResetPassword(ctx, tokenFromMessage(t, second), "password2"))
+		assert.ErrorIs(t, service.ResetPassword(ctx, tokenFromMessage(t, second), "password3"), repositories.ErrInvalidToken)
+		assert.ErrorIs(t, service.ResetPassword(ctx, tokenFromMessage(t, first), "password3"), repositories.ErrInvalidToken)
+
+		_, err = authService.LogIn(ctx, "jane", "password2", "127.0.0.1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Profile", func(t *testing.T) {
+		err := test.ClearTestDB(db)
+		assert.NoError(t, err)
+
+		user, err := authService.SignUp(ctx, "jane", "jane@example.com", "password1")
+		assert.NoError(t, err)
+		assert.NoError(t, userRepo.SetEmailVerified(ctx, int(user.ID), user.Email))
+		user, err = userRepo.GetUserByID(ctx, int(user.ID))
+		assert.NoError(t, err)
+
+		name, phone, language, consent := "Jane Doe", "+1 555 0100", "de", true
+		updated, err := service.UpdateProfile(ctx, user, models.ProfileUpdate{
+			DisplayName:       &name,
+			Phone:             &phone,
+			PreferredLanguage: &language,
+			MarketingConsent:  &consent,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "Jane Doe", updated.DisplayName)
+		assert.Equal(t, "+1 555 0100", updated.Phone)
+		assert.Equal(t, "de", updated.PreferredLanguage)
+		assert.True(t, updated.MarketingConsent)
+		assert.True(t, updated.EmailVerified)
+
+		email := "jane.doe@example.com"
+		updated, err = service.UpdateProfile(ctx, updated, models.ProfileUpdate{Email: &email})
+		assert.NoError(t, err)
+		assert.Equal(t, "jane.doe@example.com", updated.Email)
+		assert.False(t, updated.EmailVerified)
+		assert.Equal(t, "Jane Doe", updated.DisplayName)
+		msg, _ := mailer.Last()
+		assert.Equal(t, "jane.doe@example.com", msg.To)
+
+		badPhone := "call me"
+		_, err = service.UpdateProfile(ctx, updated, models.ProfileUpdate{Phone: &badPhone})
+		assert.ErrorIs(t, err, ErrInvalidProfile)
+	})
+
+	t.Run("EmailAddresses", func(t *testing.T) {
+		err := test.ClearTestDB(db)
+		assert.NoError(t, err)
+
+		user, err := authService.SignUp(ctx, "jane", "jane@example.com", "password1")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		_, err = authService.SignUp(ctx, "john", "Jane@Example.com", "password1")
+		assert.ErrorIs(t, err, repositories.ErrEmailTaken)
+
+		for _, email := range []string{"jane", "Jane <jane@example.com>", "jane@example.com\r\nBcc: x@example.com"} {
+			_, err = authService.SignUp(ctx, "john", email, "password1")
+			assert.ErrorIs(t, err, ErrInvalidEmail, email)
+
+			_, err = service.UpdateProfile(ctx, user, models.ProfileUpdate{Email: &email})
+			assert.ErrorIs(t, err, ErrInvalidProfile, email)
+		}
+
+		other, err := authService.SignUp(ctx, "john", "john@example.com", "password1")
+		if !assert.NoError(t, err) {
+			return
+		}
+		taken := "JANE@example.com"
+		_, err = service.UpdateProfile(ctx, other, models.ProfileUpdate{Email: &taken})
+		assert.ErrorIs(t, err, repositories.ErrEmailTaken)
+	})
+
+	t.Run("ChangePassword", func(t *testing.T) {
+		err := test.ClearTestDB(db)
+		assert.NoError(t, err)
+
+		user, err := authService.SignUp(ctx, "jane", "jane@example.com", "password1")
+		assert.NoError(t, err)
+
+		assert.ErrorIs(t, service.ChangePassword(ctx, user, "wrong", "password2"), repositories.ErrInvalidCredentials)
+		assert.NoError(t, service.ChangePassword(ctx, user, "password1", "password2"))
+
+		_, err = authService.LogIn(ctx, "jane", "password2", "127.0.0.1")
+		assert.NoError(t, err)
+	})
+}
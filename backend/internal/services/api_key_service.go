@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"movie-system/internal/auth"
+	"movie-system/internal/models"
+	"movie-system/internal/pagination"
+	"movie-system/internal/repositories"
+	"strings"
+	"time"
+)
+
+const apiKeyPrefix = "mk_"
+
+// APIKeyService issues and checks API keys for machine clients. Keys look
+// like mk_<prefix>_<secret>; only a hash of the whole key is stored, the
+// prefix is kept in clear so admins can tell keys apart.
+type APIKeyService struct {
+	repo *repositories.APIKeyRepository
+}
+
+func NewAPIKeyService(repo *repositories.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{repo: repo}
+}
+
+// CreateAPIKey issues a new key. The returned plain key is not stored and
+// cannot be retrieved again.
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, key *models.APIKey) (string, error) {
+	if key.Name == "" {
+		return "", fmt.Errorf("name is required")
+	}
+	if len(key.Scopes) == 0 {
+		return "", fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range key.Scopes {
+		if !models.IsValidScope(scope) {
+			return "", fmt.Errorf("invalid scope %q", scope)
+		}
+	}
+	if key.RateLimit < 0 {
+		return "", fmt.Errorf("rate limit must not be negative")
+	}
+	if key.ExpiresAt != nil && key.ExpiresAt.Before(time.Now()) {
+		return "", fmt.Errorf("expiry must be in the future")
+	}
+
+	prefixBytes := make([]byte, 4)
+	secretBytes := make([]byte, 24)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", fmt.Errorf("error generating api key: %w", err)
+	}
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", fmt.Errorf("error generating api key: %w", err)
+	}
+
+	key.Prefix = hex.EncodeToString(prefixBytes)
+	plain := apiKeyPrefix + key.Prefix + "_" + base64.RawURLEncoding.EncodeToString(secretBytes)
+
+	if err := s.repo.InsertAPIKey(ctx, key, hashToken(plain)); err != nil {
+		return "", err
+	}
+	return plain, nil
+}
+
+func (s *APIKeyService) GetAPIKeys(ctx context.Context, params pagination.Params) (*pagination.Page[models.APIKey], error) {
+	return s.repo.GetAPIKeys(ctx, params)
+}
+
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, id int) error {
+	return s.repo.RevokeAPIKey(ctx, id)
+}
+
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, plain string) (*auth.APIKeyPrincipal, error) {
+	if !strings.HasPrefix(plain, apiKeyPrefix) {
+		return nil, repositories.ErrAPIKeyNotFound
+	}
+
+	key, err := s.repo.GetActiveAPIKeyByHash(ctx, hashToken(plain))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.TouchAPIKey(ctx, key.ID); err != nil {
+		log.Printf("Error tracking usage of api key %s: %v", key.Prefix, err)
+	}
+
+	return &auth.APIKeyPrincipal{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Scopes:    key.Scopes,
+		RateLimit: key.RateLimit,
+	}, nil
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"movie-system/internal/models"
+	"movie-system/internal/pagination"
+	"movie-system/internal/repositories"
+	"reflect"
+)
+
+var ErrNotRevertable = errors.New("this change cannot be reverted")
+
+// AuditService keeps the audit trail of catalogue changes and reverts
+// entities to the state recorded by an earlier entry.
+type AuditService struct {
+	repo      *repositories.AuditRepository
+	movies    *repositories.MovieRepository
+	showtimes *repositories.ShowtimeRepository
+	genres    *repositories.GenreRepository
+	people    *repositories.PersonRepository
+}
+
+func NewAuditService(repo *repositories.AuditRepository, movies *repositories.MovieRepository, showtimes *repositories.ShowtimeRepository, genres *repositories.GenreRepository, people *repositories.PersonRepository) *AuditService {
+	return &AuditService{repo: repo, movies: movies, showtimes: showtimes, genres: genres, people: people}
+}
+
+// Record stores a change made by actor. before and after are the entity as
+// it was and as it is now; either may be nil.
+func (s *AuditService) Record(ctx context.Context, actor, action, entityType string, entityID int, before, after any) (*models.AuditEntry, error) {
+	entry := &models.AuditEntry{
+		Actor:      actor,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+	}
+	var err error
+	if entry.Before, err = snapshot(before); err != nil {
+		return nil, err
+	}
+	if entry.After, err = snapshot(after); err != nil {
+		return nil, err
+	}
+	if entry.Changes, err = diffSnapshots(entry.Before, entry.After); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.InsertEntry(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *AuditService) List(ctx context.Context, filter models.AuditFilter, params pagination.Params) (*pagination.Page[models.AuditEntry], error) {
+	return s.repo.ListEntries(ctx, filter, params)
+}
+
+// Revert puts the entity of an audit entry back into the state recorded
+// after that entry, and records the revert itself. Deleted entities and
+// images cannot be reverted. Reserved seat counts and archive timestamps are
+// live data and are not rolled back, although archiving is.
+func (s *AuditService) Revert(ctx context.Context, actor string, entryID int) (*models.AuditEntry, error) {
+	entry, err := s.repo.GetEntry(ctx, entryID)
+	if err != nil {
+		return nil, err
+	}
+	if entry.After == nil {
+		return nil, ErrNotRevertable
+	}
+
+	var before, after any
+	switch entry.EntityType {
+	case models.AuditEntityMovie:
+		before, after, err = s.revertMovie(ctx, entry)
+	case models.AuditEntityShowtime:
+		before, after, err = s.revertShowtime(ctx, entry)
+	case models.AuditEntityGenre:
+		before, after, err = s.revertGenre(ctx, entry)
+	case models.AuditEntityPerson:
+		before, after, err = s.revertPerson(ctx, entry)
+	default:
+		return nil, ErrNotRevertable
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Record(ctx, actor, models.AuditActionRevert, entry.EntityType, entry.EntityID, before, after)
+}
+
+func (s *AuditService) revertMovie(ctx context.Context, entry *models.AuditEntry) (any, any, error) {
+	var version models.Movie
+	if err := json.Unmarshal(entry.After, &version); err != nil {
+		return nil, nil, fmt.Errorf("error decoding movie snapshot: %w", err)
+	}
+	current, err := s.movies.GetMovieByID(ctx, entry.EntityID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := s.movies.UpdateMovie(ctx, entry.EntityID, &version); err != nil {
+		return nil, nil, err
+	}
+	switch {
+	case version.ArchivedAt == nil && current.ArchivedAt != nil:
+		err = s.movies.RestoreMovie(ctx, entry.EntityID)
+	case version.ArchivedAt != nil && current.ArchivedAt == nil:
+		err = s.movies.ArchiveMovie(ctx, entry.EntityID)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reverted, err := s.movies.GetMovieByID(ctx, entry.EntityID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return current, reverted, nil
+}
+
+func (s *AuditService) revertShowtime(ctx context.Context, entry *models.AuditEntry) (any, any, error) {
+	var version models.Showtime
+	if err := json.Unmarshal(entry.After, &version); err != nil {
+		return nil, nil, fmt.Errorf("error decoding showtime snapshot: %w", err)
+	}
+	current, err := s.showtimes.GetShowtimeByID(ctx, entry.EntityID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	version.Reserved = current.Reserved
+	if err := s.showtimes.UpdateShowtime(ctx, entry.EntityID, &version); err != nil {
+		return nil, nil, err
+	}
+	switch {
+	case version.ArchivedAt == nil && current.ArchivedAt != nil:
+		err = s.showtimes.RestoreShowtime(ctx, entry.EntityID)
+	case version.ArchivedAt != nil && current.ArchivedAt == nil:
+		err = s.showtimes.ArchiveShowtime(ctx, entry.EntityID)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reverted, err := s.showtimes.GetShowtimeByID(ctx, entry.EntityID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return current, reverted, nil
+}
+
+func (s *AuditService) revertGenre(ctx context.Context, entry *models.AuditEntry) (any, any, error) {
+	var version models.Genre
+	if err := json.Unmarshal(entry.After, &version); err != nil {
+		return nil, nil, fmt.Errorf("error decoding genre snapshot: %w", err)
+	}
+	current, err := s.genres.GetGenreByID(ctx, entry.EntityID)
+	if err != nil {
+		return nil, nil, err
+	}
+	reverted, err := s.genres.UpdateGenre(ctx, entry.EntityID, version.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return current, reverted, nil
+}
+
+func (s *AuditService) revertPerson(ctx context.Context, entry *models.AuditEntry) (any, any, error) {
+	var version models.Person
+	if err := json.Unmarshal(entry.After, &version); err != nil {
+		return nil, nil, fmt.Errorf("error decoding person snapshot: %w", err)
+	}
+	current, err := s.people.GetPersonByID(ctx, entry.EntityID)
+	if err != nil {
+		return nil, nil, err
+	}
+	reverted, err := s.people.UpdatePerson(ctx, entry.EntityID, version.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return current, reverted, nil
+}
+
+// snapshot encodes an entity for the audit log. Nil, including a nil
+// pointer, is stored as no snapshot at all.
+func snapshot(entity any) (json.RawMessage, error) {
+	if entity == nil {
+		return nil, nil
+	}
+	if value := reflect.ValueOf(entity); value.Kind() == reflect.Pointer && value.IsNil() {
+		return nil, nil
+	}
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding audit snapshot: %w", err)
+	}
+	return data, nil
+}
+
+// diffSnapshots compares the top-level fields of two snapshots. A missing
+// snapshot counts as an object without fields.
+func diffSnapshots(before, after json.RawMessage) (map[string]models.AuditChange, error) {
+	var from, to map[string]any
+	if before != nil {
+		if err := json.Unmarshal(before, &from); err != nil {
+			return nil, fmt.Errorf("error decoding audit snapshot: %w", err)
+		}
+	}
+	if after != nil {
+		if err := json.Unmarshal(after, &to); err != nil {
+			return nil, fmt.Errorf("error decoding audit snapshot: %w", err)
+		}
+	}
+
+	changes := make(map[string]models.AuditChange)
+	for field, value := range from {
+		if !reflect.DeepEqual(value, to[field]) {
+			changes[field] = models.AuditChange{From: value, To: to[field]}
+		}
+	}
+	for field, value := range to {
+		if _, ok := from[field]; !ok {
+			changes[field] = models.AuditChange{To: value}
+		}
+	}
+	return changes, nil
+}
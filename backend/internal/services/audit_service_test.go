@@ -0,0 +1,41 @@
+package services
+
+import (
+	"movie-system/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditSnapshots(t *testing.T) {
+	var movie *models.Movie
+	data, err := snapshot(movie)
+	assert.NoError(t, err)
+	assert.Nil(t, data, "a nil pointer has no snapshot")
+
+	before, err := snapshot(&models.Genre{ID: 1, Name: "Sci-Fi", Slug: "sci-fi"})
+	assert.NoError(t, err)
+	after, err := snapshot(&models.Genre{ID: 1, Name: "Science Fiction", Slug: "science-fiction"})
+	assert.NoError(t, err)
+
+	changes, err := diffSnapshots(before, after)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]models.AuditChange{
+		"name": {From: "Sci-Fi", To: "Science Fiction"},
+		"slug": {From: "sci-fi", To: "science-fiction"},
+	}, changes)
+
+	// Creations and deletions list every field
+	changes, err = diffSnapshots(nil, after)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 3)
+	assert.Equal(t, models.AuditChange{To: float64(1)}, changes["id"])
+
+	changes, err = diffSnapshots(before, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, models.AuditChange{From: "Sci-Fi"}, changes["name"])
+
+	changes, err = diffSnapshots(before, before)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+}
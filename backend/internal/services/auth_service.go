@@ -2,46 +2,249 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"movie-system/internal/auth"
 	"movie-system/internal/models"
 	"movie-system/internal/repositories"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
+// ErrInvalidEmail is returned for email addresses that are not a bare
+// address such as jane@example.com.
+var ErrInvalidEmail = errors.New("invalid email address")
+
+const (
+	tokenTTL        = 72 * time.Hour
+	pendingTokenTTL = 5 * time.Minute
+)
+
 type AuthService struct {
 	repo      *repositories.UserRepository
+	mfa       *MFAService
+	guard     *LoginGuard
 	jwtSecret string
 }
 
-func NewAuthService(repo *repositories.UserRepository, secret string) *AuthService {
-	return &AuthService{repo: repo, jwtSecret: secret}
+// LoginResult carries either a session token or, when a second factor is
+// needed, a short-lived token that can only be used to finish the login.
+type LoginResult struct {
+	Token            string `json:"token,omitempty"`
+	MFAToken         string `json:"mfa_token,omitempty"`
+	MFARequired      bool   `json:"mfa_required,omitempty"`
+	MFASetupRequired bool   `json:"mfa_setup_required,omitempty"`
+}
+
+func NewAuthService(repo *repositories.UserRepository, mfa *MFAService, guard *LoginGuard, secret string) *AuthService {
+	return &AuthService{repo: repo, mfa: mfa, guard: guard, jwtSecret: secret}
+}
+
+// SignUp registers a new account through public self-registration. The
+// account always gets the plain user role regardless of what was requested.
+func (s *AuthService) SignUp(ctx context.Context, username, email, password string) (*models.User, error) {
+	email = strings.TrimSpace(email)
+	if email != "" && !validEmail(email) {
+		return nil, ErrInvalidEmail
+	}
+
+	user := &models.User{
+		Username:     username,
+		Email:        email,
+		PasswordHash: password,
+		Role:         models.RoleUser,
+	}
+	if err := s.repo.SignUp(ctx, user); err != nil {
+		return nil, err
+	}
+	return s.repo.GetUserByUsername(ctx, username)
+}
+
+// CreateUser creates an account with an arbitrary role on behalf of an admin.
+// Admins run the cinema with cinemaID; other roles have none.
+func (s *AuthService) CreateUser(ctx context.Context, username, email, password, role string, cinemaID uint) (*models.User, error) {
+	if !models.IsValidRole(role) {
+		return nil, fmt.Errorf("invalid role %q", role)
+	}
+	email = strings.TrimSpace(email)
+	if email != "" && !validEmail(email) {
+		return nil, ErrInvalidEmail
+	}
+
+	user := &models.User{
+		Username:     username,
+		Email:        email,
+		PasswordHash: password,
+		Role:         role,
+		CinemaID:     cinemaID,
+	}
+	if err := s.repo.SignUp(ctx, user); err != nil {
+		return nil, err
+	}
+	return s.repo.GetUserByUsername(ctx, username)
+}
+
+// ValidateClaims rejects tokens issued to accounts that have since been
+// disabled, deleted or moved to a different role or cinema.
+func (s *AuthService) ValidateClaims(ctx context.Context, claims *auth.TokenClaims) error {
+	user, err := s.repo.GetUserByUsername(ctx, claims.Username)
+	if err != nil {
+		return err
+	}
+	if user.Disabled {
+		return repositories.ErrUserDisabled
+	}
+	if user.Role != claims.Role {
+		return fmt.Errorf("role has changed since the token was issued")
+	}
+	if user.CinemaID != claims.CinemaID {
+		return fmt.Errorf("cinema has changed since the token was issued")
+	}
+	return nil
+}
+
+// LogIn checks the password and starts a session. Users with two-factor
+// authentication enabled, or whose role requires it, get a pending token
+// instead and have to finish with CompleteMFALogin or by enrolling. Every
+// attempt is recorded, and repeated failures from the same username or
+// address are throttled.
+func (s *AuthService) LogIn(ctx context.Context, username, password, ip string) (*LoginResult, error) {
+	if err := s.guard.Check(ctx, username, ip); err != nil {
+		s.guard.Record(ctx, username, ip, repositories.LoginResultLocked)
+		return nil, err
+	}
+
+	_, err := s.repo.AuthenticateUser(ctx, username, password)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrInvalidCredentials):
+			s.guard.Record(ctx, username, ip, repositories.LoginResultInvalid)
+		case errors.Is(err, repositories.ErrUserDisabled):
+			s.guard.Record(ctx, username, ip, repositories.LoginResultDisabled)
+		}
+		return nil, err
+	}
+
+	user, err := s.repo.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.StartSession(ctx, user, ip)
 }
 
-func (s *AuthService) SignUp(ctx context.Context, user *models.User) error {
-	return s.repo.SignUp(ctx, user)
+// StartSession issues a session token for a user whose primary credential
+// has been checked, or a pending token if a second factor is still needed.
+func (s *AuthService) StartSession(ctx context.Context, user *models.User, ip string) (*LoginResult, error) {
+	if user.Disabled {
+		s.guard.Record(ctx, user.Username, ip, repositories.LoginResultDisabled)
+		return nil, repositories.ErrUserDisabled
+	}
+
+	if user.TOTPEnabled {
+		mfaToken, err := s.generateToken(user, auth.PurposeMFA, pendingTokenTTL)
+		if err != nil {
+			return nil, err
+		}
+		s.guard.Record(ctx, user.Username, ip, repositories.LoginResultMFAPending)
+		return &LoginResult{MFAToken: mfaToken, MFARequired: true}, nil
+	}
+
+	required, err := s.mfa.IsRequired(ctx, user.Role)
+	if err != nil {
+		return nil, err
+	}
+	if required {
+		mfaToken, err := s.generateToken(user, auth.PurposeMFASetup, pendingTokenTTL)
+		if err != nil {
+			return nil, err
+		}
+		s.guard.Record(ctx, user.Username, ip, repositories.LoginResultMFAPending)
+		return &LoginResult{MFAToken: mfaToken, MFASetupRequired: true}, nil
+	}
+
+	token, err := s.GenerateJWT(user)
+	if err != nil {
+		return nil, err
+	}
+
+	s.guard.Record(ctx, user.Username, ip, repositories.LoginResultSuccess)
+	return &LoginResult{Token: token}, nil
 }
 
-func (s *AuthService) LogIn(ctx context.Context, username, password string) (string, error) {
-	role, err := s.repo.AuthenticateUser(ctx, username, password)
+// CompleteMFALogin exchanges a pending token and a TOTP or recovery code for
+// a session token. Wrong codes count towards the same lockout as wrong
+// passwords.
+func (s *AuthService) CompleteMFALogin(ctx context.Context, mfaToken, code, ip string) (string, error) {
+	claims, err := auth.ParseToken(mfaToken, s.jwtSecret)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse token: %w", err)
+	}
+	if claims.Purpose != auth.PurposeMFA {
+		return "", fmt.Errorf("token cannot be used to complete a login")
+	}
+	if err := s.ValidateClaims(ctx, claims); err != nil {
+		return "", err
+	}
+
+	if err := s.guard.Check(ctx, claims.Username, ip); err != nil {
+		s.guard.Record(ctx, claims.Username, ip, repositories.LoginResultLocked)
+		return "", err
+	}
+
+	user, err := s.repo.GetUserByUsername(ctx, claims.Username)
 	if err != nil {
 		return "", err
 	}
+	if err := s.mfa.Verify(ctx, user, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			s.guard.Record(ctx, user.Username, ip, repositories.LoginResultInvalidCode)
+		}
+		return "", err
+	}
 
-	token, err := s.GenerateJWT(username, role)
+	token, err := s.GenerateJWT(user)
 	if err != nil {
 		return "", err
 	}
 
+	s.guard.Record(ctx, user.Username, ip, repositories.LoginResultSuccess)
 	return token, nil
 }
 
-func (s *AuthService) GenerateJWT(username, role string) (string, error) {
+// EnrollmentClaims accepts either a session token or the pending token handed
+// out to users who must set up two-factor authentication before logging in.
+func (s *AuthService) EnrollmentClaims(ctx context.Context, tokenString string) (*auth.TokenClaims, error) {
+	claims, err := auth.ParseToken(tokenString, s.jwtSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token: %w", err)
+	}
+	if claims.Purpose != "" && claims.Purpose != auth.PurposeMFASetup {
+		return nil, fmt.Errorf("token cannot be used for enrollment")
+	}
+	if err := s.ValidateClaims(ctx, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (s *AuthService) GenerateJWT(user *models.User) (string, error) {
+	return s.generateToken(user, "", tokenTTL)
+}
+
+func (s *AuthService) generateToken(user *models.User, purpose string, ttl time.Duration) (string, error) {
 	claims := jwt.MapClaims{
-		"username": username,
-		"role":     role,
-		"exp":      time.Now().Add(72 * time.Hour).Unix(),
+		"username": user.Username,
+		"role":     user.Role,
+		"exp":      time.Now().Add(ttl).Unix(),
+	}
+	if user.CinemaID != 0 {
+		claims["cinema_id"] = user.CinemaID
+	}
+	if purpose != "" {
+		claims["purpose"] = purpose
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(s.jwtSecret))
@@ -63,6 +266,9 @@ func (s *AuthService) ExtractUserIDFromJWT(tokenString string) (int, error) {
 		if !ok {
 			return 0, fmt.Errorf("username not found in token")
 		}
+		if _, pending := claims["purpose"]; pending {
+			return 0, fmt.Errorf("token is only valid for completing a login")
+		}
 
 	} else {
 		return 0, fmt.Errorf("invalid token or token claims are malformed")
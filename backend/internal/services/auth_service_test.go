@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"movie-system/internal/auth"
+	"movie-system/internal/models"
+	"movie-system/internal/repositories"
+	"movie-system/test"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthService(t *testing.T) {
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+
+	userRepo := repositories.NewUserRepository(db)
+	mfaService := NewMFAService(repositories.NewMFARepository(db), "Movie System")
+	loginGuard := NewLoginGuard(repositories.NewLoginAttemptRepository(db))
+	service := NewAuthService(userRepo, mfaService, loginGuard, "secret")
+	ctx := context.Background()
+
+	t.Run("SignUpForcesUserRole", func(t *testing.T) {
+		err := test.ClearTestDB(db)
+		assert.NoError(t, err)
+
+		user, err := service.SignUp(ctx, "jane", "jane@example.com", "password1")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, models.RoleUser, user.Role)
+		assert.Zero(t, user.CinemaID)
+
+		result, err := service.LogIn(ctx, "jane", "password1", "10.0.0.1")
+		if assert.NoError(t, err) {
+			claims, err := auth.ParseToken(result.Token, "secret")
+			if assert.NoError(t, err) {
+				assert.Equal(t, models.RoleUser, claims.Role)
+			}
+		}
+
+		// Only admins create accounts with other roles
+		admin, err := service.CreateUser(ctx, "boss", "boss@example.com", "password1", models.RoleHeadOffice, 0)
+		if assert.NoError(t, err) {
+			assert.Equal(t, models.RoleHeadOffice, admin.Role)
+		}
+		_, err = service.CreateUser(ctx, "root", "root@example.com", "password1", "root", 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("DisabledUsersCannotLogIn", func(t *testing.T) {
+		err := test.ClearTestDB(db)
+		assert.NoError(t, err)
+
+		user, err := service.SignUp(ctx, "jane", "jane@example.com", "password1")
+		if !assert.NoError(t, err) {
+			return
+		}
+		result, err := service.LogIn(ctx, "jane", "password1", "10.0.0.1")
+		if !assert.NoError(t, err) {
+			return
+		}
+		claims, err := auth.ParseToken(result.Token, "secret")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.NoError(t, userRepo.SetUserDisabled(ctx, int(user.ID), true))
+		_, err = service.LogIn(ctx, "jane", "password1", "10.0.0.1")
+		assert.ErrorIs(t, err, repositories.ErrUserDisabled)
+		assert.ErrorIs(t, service.ValidateClaims(ctx, claims), repositories.ErrUserDisabled, "earlier tokens stop working")
+
+		assert.NoError(t, userRepo.SetUserDisabled(ctx, int(user.ID), false))
+		_, err = service.LogIn(ctx, "jane", "password1", "10.0.0.1")
+		assert.NoError(t, err)
+	})
+}
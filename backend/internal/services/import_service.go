@@ -0,0 +1,569 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"movie-system/internal/models"
+	"movie-system/internal/repositories"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxImportRows bounds the size of a single bulk import.
+const MaxImportRows = 5000
+
+var ErrInvalidImport = errors.New("invalid import")
+
+// Columns of the CSV formats, in export order. On import the columns may come
+// in any order; missing ones are empty, and the read-only ones are ignored.
+// The id column keys the rows that have no external_id, so an export edited
+// in a spreadsheet can be imported again.
+var (
+	movieCSVColumns    = []string{"id", "external_id", "title", "description", "release_date", "runtime_minutes", "certification", "original_language", "subtitles", "genres", "poster_image"}
+	showtimeCSVColumns = []string{"id", "external_id", "movie_external_id", "movie_id", "cinema_id", "auditorium_id", "start_time", "capacity", "reserved"}
+	readOnlyCSVColumns = map[string]bool{"reserved": true}
+)
+
+// csvListSeparator separates the subtitles and genres of a movie in a CSV
+// cell.
+const csvListSeparator = ";"
+
+// ImportService reads movies and showtimes in bulk from CSV or JSON and
+// writes them back out in the same formats. Every saved row is recorded in
+// the audit log.
+type ImportService struct {
+	movies    *repositories.MovieRepository
+	showtimes *repositories.ShowtimeRepository
+	genres    *repositories.GenreRepository
+	audit     *AuditService
+}
+
+func NewImportService(movies *repositories.MovieRepository, showtimes *repositories.ShowtimeRepository, genres *repositories.GenreRepository, audit *AuditService) *ImportService {
+	return &ImportService{movies: movies, showtimes: showtimes, genres: genres, audit: audit}
+}
+
+// importRow is a parsed row and whatever was wrong with it.
+type importRow[T any] struct {
+	item   T
+	errors []string
+}
+
+func (row *importRow[T]) fail(format string, args ...any) {
+	row.errors = append(row.errors, fmt.Sprintf(format, args...))
+}
+
+// ImportMovies creates or updates the movies in data, keyed by external_id.
+// Rows without one update the movie with their id. In CSV, subtitles and genres are lists separated by ";" and genres are
+// given by name; credits can only be imported from JSON. Genres and credits
+// are left alone when their column or field is missing.
+func (s *ImportService) ImportMovies(ctx context.Context, actor, format string, data io.Reader, opts models.ImportOptions) (*models.ImportReport, error) {
+	var rows []importRow[models.Movie]
+	var err error
+	switch format {
+	case models.ImportFormatCSV:
+		rows, err = parseCSV(data, movieCSVColumns, []string{"title"}, movieFromCSV)
+	case models.ImportFormatJSON:
+		rows, err = parseJSON[models.Movie](data)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidImport, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	genres := make(map[string]*models.Genre)
+	for i := range rows {
+		movie := &rows[i].item
+		resolved := movie.Genres[:0]
+		for _, genre := range movie.Genres {
+			if genre.ID != 0 {
+				resolved = append(resolved, genre)
+				continue
+			}
+			slug := genre.Slug
+			if slug == "" {
+				slug = models.Slugify(genre.Name)
+			}
+			if _, ok := genres[slug]; !ok {
+				found, err := s.genres.GetGenreBySlug(ctx, slug)
+				if err != nil && !errors.Is(err, repositories.ErrGenreNotFound) {
+					return nil, err
+				}
+				genres[slug] = found
+			}
+			if found := genres[slug]; found != nil {
+				resolved = append(resolved, *found)
+			} else if genre.Name != "" {
+				rows[i].fail("unknown genre %q", genre.Name)
+			} else {
+				rows[i].fail("unknown genre %q", genre.Slug)
+			}
+		}
+		if movie.Genres != nil {
+			movie.Genres = resolved
+		}
+		if movie.ExternalID == "" && movie.ID == 0 {
+			rows[i].fail("external_id or id is required")
+		}
+		if err := movie.Validate(); err != nil {
+			rows[i].fail("%v", err)
+		}
+	}
+	externalIDs := make([]string, len(rows))
+	keys := make([]string, len(rows))
+	for i, row := range rows {
+		externalIDs[i] = row.item.ExternalID
+		keys[i] = importKey(row.item.ExternalID, row.item.ID)
+	}
+	failDuplicates(rows, keys)
+
+	report, valid, opts := newImportReport(rows, externalIDs, opts)
+	movies := make([]models.Movie, len(valid))
+	before := make([]*models.Movie, len(valid))
+	for i, index := range valid {
+		movies[i] = rows[index].item
+		if movies[i].ExternalID != "" {
+			before[i], err = s.movies.GetMovieByExternalID(ctx, movies[i].ExternalID)
+		} else {
+			before[i], err = s.movies.GetMovieByID(ctx, int(movies[i].ID))
+		}
+		if err != nil && !errors.Is(err, repositories.ErrMovieNotFound) {
+			return nil, err
+		}
+	}
+
+	errs, committed, err := s.movies.ImportMovies(ctx, movies, opts)
+	if err != nil {
+		return nil, err
+	}
+	report.Committed = committed
+	for i, index := range valid {
+		report.record(index, before[i] != nil, movies[i].ID, errs[i])
+		if errs[i] != nil || !committed {
+			continue
+		}
+		after, err := s.movies.GetMovieByID(ctx, int(movies[i].ID))
+		if err != nil {
+			return nil, err
+		}
+		s.recordAudit(ctx, actor, models.AuditEntityMovie, int(movies[i].ID), before[i] != nil, before[i], after)
+	}
+	return &report.ImportReport, nil
+}
+
+// ImportShowtimes creates or updates the showtimes in data, keyed by
+// external_id or, for rows without one, by id. The movie is given by movie_external_id or movie_id, and the
+// cinema by cinema_id or the auditorium.
+func (s *ImportService) ImportShowtimes(ctx context.Context, actor, format string, data io.Reader, opts models.ImportOptions) (*models.ImportReport, error) {
+	var rows []importRow[models.Showtime]
+	var err error
+	switch format {
+	case models.ImportFormatCSV:
+		rows, err = parseCSV(data, showtimeCSVColumns, []string{"start_time", "capacity"}, showtimeFromCSV)
+	case models.ImportFormatJSON:
+		rows, err = parseJSON[models.Showtime](data)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidImport, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	externalIDs := make([]string, len(rows))
+	keys := make([]string, len(rows))
+	for i := range rows {
+		showtime := &rows[i].item
+		externalIDs[i] = showtime.ExternalID
+		keys[i] = importKey(showtime.ExternalID, showtime.ID)
+		if showtime.ExternalID == "" && showtime.ID == 0 {
+			rows[i].fail("external_id or id is required")
+		}
+		if showtime.MovieExternalID == "" && showtime.MovieID == 0 {
+			rows[i].fail("movie_external_id or movie_id is required")
+		}
+		if showtime.CinemaID == 0 && showtime.AuditoriumID == 0 {
+			rows[i].fail("cinema_id or auditorium_id is required")
+		}
+		if showtime.StartTime.IsZero() {
+			rows[i].fail("start_time is required")
+		}
+		if showtime.Capacity == 0 {
+			rows[i].fail("capacity must be positive")
+		}
+	}
+	failDuplicates(rows, keys)
+
+	report, valid, opts := newImportReport(rows, externalIDs, opts)
+	showtimes := make([]models.Showtime, len(valid))
+	before := make([]*models.Showtime, len(valid))
+	for i, index := range valid {
+		showtimes[i] = rows[index].item
+		if showtimes[i].ExternalID != "" {
+			before[i], err = s.showtimes.GetShowtimeByExternalID(ctx, showtimes[i].ExternalID)
+		} else {
+			before[i], err = s.showtimes.GetShowtimeByID(ctx, int(showtimes[i].ID))
+		}
+		if err != nil && !errors.Is(err, repositories.ErrShowtimeNotFound) {
+			return nil, err
+		}
+	}
+
+	errs, committed, err := s.showtimes.ImportShowtimes(ctx, showtimes, opts)
+	if err != nil {
+		return nil, err
+	}
+	report.Committed = committed
+	for i, index := range valid {
+		report.record(index, before[i] != nil, showtimes[i].ID, errs[i])
+		if errs[i] != nil || !committed {
+			continue
+		}
+		after, err := s.showtimes.GetShowtimeByID(ctx, int(showtimes[i].ID))
+		if err != nil {
+			return nil, err
+		}
+		s.recordAudit(ctx, actor, models.AuditEntityShowtime, int(showtimes[i].ID), before[i] != nil, before[i], after)
+	}
+	return &report.ImportReport, nil
+}
+
+// ExportMovies writes every movie that is not archived in format.
+func (s *ImportService) ExportMovies(ctx context.Context, format string, w io.Writer) error {
+	movies, err := s.movies.ExportMovies(ctx)
+	if err != nil {
+		return err
+	}
+	if format == models.ImportFormatJSON {
+		return json.NewEncoder(w).Encode(movies)
+	}
+
+	return writeCSV(w, movieCSVColumns, movies, func(movie models.Movie) []string {
+		var releaseDate string
+		if movie.ReleaseDate != nil {
+			releaseDate = movie.ReleaseDate.Format(time.DateOnly)
+		}
+		genres := make([]string, len(movie.Genres))
+		for i, genre := range movie.Genres {
+			genres[i] = genre.Name
+		}
+		return []string{
+			strconv.Itoa(int(movie.ID)), movie.ExternalID, movie.Title, movie.Description, releaseDate,
+			strconv.Itoa(movie.RuntimeMinutes), movie.Certification, movie.OriginalLanguage,
+			strings.Join(movie.Subtitles, csvListSeparator), strings.Join(genres, csvListSeparator), movie.PosterImage,
+		}
+	})
+}
+
+// ExportShowtimes writes every bookable showtime in format.
+func (s *ImportService) ExportShowtimes(ctx context.Context, format string, w io.Writer) error {
+	showtimes, err := s.showtimes.ExportShowtimes(ctx)
+	if err != nil {
+		return err
+	}
+	if format == models.ImportFormatJSON {
+		return json.NewEncoder(w).Encode(showtimes)
+	}
+
+	return writeCSV(w, showtimeCSVColumns, showtimes, func(showtime models.Showtime) []string {
+		auditoriumID := ""
+		if showtime.AuditoriumID != 0 {
+			auditoriumID = strconv.Itoa(int(showtime.AuditoriumID))
+		}
+		return []string{
+			strconv.Itoa(int(showtime.ID)), showtime.ExternalID, showtime.MovieExternalID,
+			strconv.Itoa(int(showtime.MovieID)), strconv.Itoa(int(showtime.CinemaID)), auditoriumID, showtime.StartTime.Format(time.RFC3339),
+			strconv.Itoa(int(showtime.Capacity)), strconv.Itoa(int(showtime.Reserved)),
+		}
+	})
+}
+
+func (s *ImportService) recordAudit(ctx context.Context, actor, entityType string, id int, exists bool, before, after any) {
+	action := models.AuditActionCreate
+	if exists {
+		action = models.AuditActionUpdate
+	}
+	// The import is committed already; a missing entry is not worth failing
+	// it for
+	if _, err := s.audit.Record(ctx, actor, action, entityType, id, before, after); err != nil {
+		log.Printf("Failed to record import of %s %d: %v", entityType, id, err)
+	}
+}
+
+// importReport collects the results of an import by row.
+type importReport struct {
+	models.ImportReport
+}
+
+// newImportReport starts a report with the rows that failed to parse or
+// validate, and returns the indexes of the others. An atomic import with
+// invalid rows is turned into a dry run, so the valid rows are still checked
+// against the database.
+func newImportReport[T any](rows []importRow[T], externalIDs []string, opts models.ImportOptions) (*importReport, []int, models.ImportOptions) {
+	report := &importReport{models.ImportReport{
+		DryRun: opts.DryRun,
+		Atomic: opts.Atomic,
+		Rows:   make([]models.ImportResult, len(rows)),
+	}}
+	var valid []int
+	for i, row := range rows {
+		report.Rows[i] = models.ImportResult{Row: i + 1, ExternalID: externalIDs[i]}
+		if len(row.errors) > 0 {
+			report.Rows[i].Action = models.ImportActionFail
+			report.Rows[i].Errors = row.errors
+			report.Failed++
+		} else {
+			valid = append(valid, i)
+		}
+	}
+	if opts.Atomic && report.Failed > 0 {
+		opts.DryRun = true
+	}
+	return report, valid, opts
+}
+
+// record stores the database outcome of row index. Ids of movies that
+// would have been created are only reported once they exist.
+func (report *importReport) record(index int, exists bool, id uint, err error) {
+	result := &report.Rows[index]
+	switch {
+	case err != nil:
+		result.Action = models.ImportActionFail
+		result.Errors = []string{err.Error()}
+		report.Failed++
+	case exists:
+		result.Action = models.ImportActionUpdate
+		result.ID = id
+		report.Updated++
+	default:
+		result.Action = models.ImportActionCreate
+		if report.Committed {
+			result.ID = id
+		}
+		report.Created++
+	}
+}
+
+// importKey describes what a row is keyed by: its external id or, without
+// one, its id. It is empty for rows with neither.
+func importKey(externalID string, id uint) string {
+	switch {
+	case externalID != "":
+		return fmt.Sprintf("external_id %q", externalID)
+	case id != 0:
+		return fmt.Sprintf("id %d", id)
+	}
+	return ""
+}
+
+// failDuplicates marks every row whose key already appeared in an earlier
+// row.
+func failDuplicates[T any](rows []importRow[T], keys []string) {
+	seen := make(map[string]int)
+	for i, key := range keys {
+		if key == "" {
+			continue
+		}
+		if first, ok := seen[key]; ok {
+			rows[i].fail("%s is already used by row %d", key, first+1)
+			continue
+		}
+		seen[key] = i
+	}
+}
+
+// parseJSON reads an array of objects. An object that does not decode fails
+// its own row only.
+func parseJSON[T any](data io.Reader) ([]importRow[T], error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(data).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%w: expected a JSON array: %w", ErrInvalidImport, err)
+	}
+	if len(raw) > MaxImportRows {
+		return nil, fmt.Errorf("%w: at most %d rows can be imported at once", ErrInvalidImport, MaxImportRows)
+	}
+
+	rows := make([]importRow[T], len(raw))
+	for i, item := range raw {
+		if err := json.Unmarshal(item, &rows[i].item); err != nil {
+			rows[i].fail("%v", err)
+		}
+	}
+	return rows, nil
+}
+
+// parseCSV reads a CSV file with a header row naming some of columns, and
+// converts each record with convert. The header has to name the required
+// columns.
+func parseCSV[T any](data io.Reader, columns, required []string, convert func(record map[string]string, row *importRow[T])) ([]importRow[T], error) {
+	reader := csv.NewReader(data)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing CSV header: %w", ErrInvalidImport, err)
+	}
+	for i, name := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(columns, header[i]) {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidImport, name)
+		}
+	}
+	for _, name := range required {
+		if !slices.Contains(header, name) {
+			return nil, fmt.Errorf("%w: missing column %q", ErrInvalidImport, name)
+		}
+	}
+
+	var rows []importRow[T]
+	for {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidImport, err)
+		}
+		if len(rows) == MaxImportRows {
+			return nil, fmt.Errorf("%w: at most %d rows can be imported at once", ErrInvalidImport, MaxImportRows)
+		}
+
+		var row importRow[T]
+		if len(fields) > len(header) {
+			row.fail("row has %d fields, the header %d", len(fields), len(header))
+		}
+		record := make(map[string]string, len(header))
+		for i, name := range header {
+			if readOnlyCSVColumns[name] {
+				continue
+			}
+			record[name] = ""
+			if i < len(fields) {
+				record[name] = strings.TrimSpace(fields[i])
+			}
+		}
+		convert(record, &row)
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func movieFromCSV(record map[string]string, row *importRow[models.Movie]) {
+	movie := &row.item
+	if value := record["id"]; value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			row.fail("id must be a number, got %q", value)
+		}
+		movie.ID = uint(id)
+	}
+	movie.ExternalID = record["external_id"]
+	movie.Title = record["title"]
+	movie.Description = record["description"]
+	movie.Certification = record["certification"]
+	movie.OriginalLanguage = record["original_language"]
+	movie.PosterImage = record["poster_image"]
+	movie.Subtitles = splitCSVList(record["subtitles"])
+
+	if value := record["release_date"]; value != "" {
+		date, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			row.fail("release_date must be YYYY-MM-DD, got %q", value)
+		} else {
+			movie.ReleaseDate = &date
+		}
+	}
+	if value := record["runtime_minutes"]; value != "" {
+		minutes, err := strconv.Atoi(value)
+		if err != nil {
+			row.fail("runtime_minutes must be a number, got %q", value)
+		}
+		movie.RuntimeMinutes = minutes
+	}
+	if value, ok := record["genres"]; ok {
+		movie.Genres = []models.Genre{}
+		for _, name := range splitCSVList(value) {
+			movie.Genres = append(movie.Genres, models.Genre{Name: name})
+		}
+	}
+}
+
+func showtimeFromCSV(record map[string]string, row *importRow[models.Showtime]) {
+	showtime := &row.item
+	if value := record["id"]; value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			row.fail("id must be a number, got %q", value)
+		}
+		showtime.ID = uint(id)
+	}
+	showtime.ExternalID = record["external_id"]
+	showtime.MovieExternalID = record["movie_external_id"]
+
+	if value := record["movie_id"]; value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			row.fail("movie_id must be a number, got %q", value)
+		}
+		showtime.MovieID = uint(id)
+	}
+	if value := record["cinema_id"]; value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			row.fail("cinema_id must be a number, got %q", value)
+		}
+		showtime.CinemaID = uint(id)
+	}
+	if value := record["auditorium_id"]; value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			row.fail("auditorium_id must be a number, got %q", value)
+		}
+		showtime.AuditoriumID = uint(id)
+	}
+	if value := record["start_time"]; value != "" {
+		start, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			row.fail("start_time must be an RFC 3339 timestamp, got %q", value)
+		}
+		showtime.StartTime = start
+	}
+	if value := record["capacity"]; value != "" {
+		capacity, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			row.fail("capacity must be a number, got %q", value)
+		}
+		showtime.Capacity = uint(capacity)
+	}
+}
+
+func writeCSV[T any](w io.Writer, columns []string, items []T, fields func(T) []string) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := writer.Write(fields(item)); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func splitCSVList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, csvListSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"movie-system/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoviesCSV(t *testing.T) {
+	data := `external_id,title,release_date,runtime_minutes,subtitles,genres,id
+tt1375666,Inception,2010-07-16,148,en; fr,Sci-Fi;Action,7
+tt0133093,The Matrix,16/07/2010,two hours,,,
+tt1375666,"Inception, again",,,,
+,Memento,,,,,7
+`
+	rows, err := parseCSV(strings.NewReader(data), movieCSVColumns, []string{"external_id", "title"}, movieFromCSV)
+	assert.NoError(t, err)
+	if !assert.Len(t, rows, 4) {
+		return
+	}
+
+	inception := rows[0].item
+	assert.Empty(t, rows[0].errors)
+	assert.Equal(t, "tt1375666", inception.ExternalID)
+	assert.Equal(t, time.Date(2010, 7, 16, 0, 0, 0, 0, time.UTC), *inception.ReleaseDate)
+	assert.Equal(t, 148, inception.RuntimeMinutes)
+	assert.Equal(t, []string{"en", "fr"}, inception.Subtitles)
+	assert.Equal(t, []models.Genre{{Name: "Sci-Fi"}, {Name: "Action"}}, inception.Genres)
+	assert.Equal(t, uint(7), inception.ID)
+
+	assert.Len(t, rows[1].errors, 2)
+	assert.Equal(t, []models.Genre{}, rows[1].item.Genres)
+
+	keys := make([]string, len(rows))
+	for i, row := range rows {
+		keys[i] = importKey(row.item.ExternalID, row.item.ID)
+	}
+	assert.Equal(t, []string{`external_id "tt1375666"`, `external_id "tt0133093"`, `external_id "tt1375666"`, "id 7"}, keys,
+		"rows without an external_id are keyed by id")
+	failDuplicates(rows, keys)
+	assert.Equal(t, []string{`external_id "tt1375666" is already used by row 1`}, rows[2].errors)
+	assert.Empty(t, rows[3].errors)
+	assert.Equal(t, "", importKey("", 0))
+
+	_, err = parseCSV(strings.NewReader("external_id,name\n"), movieCSVColumns, []string{"external_id", "title"}, movieFromCSV)
+	assert.ErrorIs(t, err, ErrInvalidImport)
+	_, err = parseCSV(strings.NewReader("external_id\n"), movieCSVColumns, []string{"external_id", "title"}, movieFromCSV)
+	assert.ErrorIs(t, err, ErrInvalidImport)
+}
+
+func TestParseShowtimesJSON(t *testing.T) {
+	data := `[
+		{"external_id": "s1", "movie_external_id": "tt1375666", "start_time": "2025-03-01T18:30:00Z", "capacity": 100},
+		{"external_id": "s2", "start_time": "tomorrow"}
+	]`
+	rows, err := parseJSON[models.Showtime](strings.NewReader(data))
+	assert.NoError(t, err)
+	if !assert.Len(t, rows, 2) {
+		return
+	}
+	assert.Empty(t, rows[0].errors)
+	assert.Equal(t, uint(100), rows[0].item.Capacity)
+	assert.Len(t, rows[1].errors, 1)
+
+	_, err = parseJSON[models.Showtime](strings.NewReader(`{"external_id": "s1"}`))
+	assert.ErrorIs(t, err, ErrInvalidImport)
+}
+
+func TestImportReport(t *testing.T) {
+	rows := []importRow[models.Movie]{{}, {errors: []string{"title is required"}}, {}}
+	report, valid, opts := newImportReport(rows, []string{"a", "b", "c"}, models.ImportOptions{Atomic: true})
+	assert.Equal(t, []int{0, 2}, valid)
+	assert.True(t, opts.DryRun, "an atomic import with invalid rows only checks the others")
+	assert.False(t, report.DryRun)
+	assert.Equal(t, 1, report.Failed)
+
+	report.record(0, true, 5, nil)
+	report.record(2, false, 6, nil)
+	assert.Equal(t, models.ImportResult{Row: 1, ExternalID: "a", Action: models.ImportActionUpdate, ID: 5}, report.Rows[0])
+	assert.Equal(t, models.ImportResult{Row: 3, ExternalID: "c", Action: models.ImportActionCreate}, report.Rows[2])
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Updated)
+}
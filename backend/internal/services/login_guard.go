@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"movie-system/internal/repositories"
+	"time"
+)
+
+const (
+	failureWindow            = 15 * time.Minute
+	usernameFailureThreshold = 5
+	ipFailureThreshold       = 20
+	baseLockout              = 30 * time.Second
+	maxLockout               = failureWindow
+)
+
+// LockedOutError is returned while a username or client address is
+// temporarily blocked after too many failed logins.
+type LockedOutError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedOutError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// LoginGuard throttles password guessing. Failed attempts are tracked per
+// username and per client address; once a threshold is crossed every further
+// failure doubles the lockout, up to maxLockout.
+type LoginGuard struct {
+	attempts *repositories.LoginAttemptRepository
+}
+
+func NewLoginGuard(attempts *repositories.LoginAttemptRepository) *LoginGuard {
+	return &LoginGuard{attempts: attempts}
+}
+
+// Check returns a *LockedOutError if the username or address is locked out.
+func (g *LoginGuard) Check(ctx context.Context, username, ip string) error {
+	failures, since, err := g.attempts.RecentFailuresForUsername(ctx, username, failureWindow)
+	if err != nil {
+		return err
+	}
+	if wait := lockoutDuration(failures, usernameFailureThreshold) - since; wait > 0 {
+		return &LockedOutError{RetryAfter: wait}
+	}
+
+	failures, since, err = g.attempts.RecentFailuresForIP(ctx, ip, failureWindow)
+	if err != nil {
+		return err
+	}
+	if wait := lockoutDuration(failures, ipFailureThreshold) - since; wait > 0 {
+		return &LockedOutError{RetryAfter: wait}
+	}
+
+	return nil
+}
+
+// Record writes the attempt to the audit trail. Failures to record are only
+// logged so that a broken audit table does not lock everyone out.
+func (g *LoginGuard) Record(ctx context.Context, username, ip, result string) {
+	success := result == repositories.LoginResultSuccess
+	if err := g.attempts.RecordAttempt(ctx, username, ip, success, result); err != nil {
+		log.Printf("Error recording login attempt for %s: %v", username, err)
+	}
+}
+
+// lockoutDuration returns how long to block after the given number of recent
+// failures, measured from the latest one.
+func lockoutDuration(failures, threshold int) time.Duration {
+	if failures < threshold {
+		return 0
+	}
+
+	d := baseLockout
+	for i := threshold; i < failures; i++ {
+		d *= 2
+		if d >= maxLockout {
+			return maxLockout
+		}
+	}
+	return d
+}
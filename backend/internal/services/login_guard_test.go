@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"movie-system/internal/repositories"
+	"movie-system/test"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	assert.Equal(t, time.Duration(0), lockoutDuration(0, 5))
+	assert.Equal(t, time.Duration(0), lockoutDuration(4, 5))
+	assert.Equal(t, 30*time.Second, lockoutDuration(5, 5))
+	assert.Equal(t, time.Minute, lockoutDuration(6, 5))
+	assert.Equal(t, 2*time.Minute, lockoutDuration(7, 5))
+	assert.Equal(t, maxLockout, lockoutDuration(10, 5))
+	assert.Equal(t, maxLockout, lockoutDuration(1000, 5))
+}
+
+func TestLoginGuard(t *testing.T) {
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+
+	guard := NewLoginGuard(repositories.NewLoginAttemptRepository(db))
+	ctx := context.Background()
+
+	t.Run("SuccessResetsTheUsername", func(t *testing.T) {
+		err := test.ClearTestDB(db)
+		assert.NoError(t, err)
+
+		for i := 0; i < usernameFailureThreshold-1; i++ {
+			guard.Record(ctx, "jane", fmt.Sprintf("10.0.0.%d", i), repositories.LoginResultInvalid)
+		}
+		assert.NoError(t, guard.Check(ctx, "jane", "10.0.1.1"))
+		guard.Record(ctx, "jane", "10.0.1.1", repositories.LoginResultSuccess)
+		guard.Record(ctx, "jane", "10.0.1.1", repositories.LoginResultInvalid)
+		assert.NoError(t, guard.Check(ctx, "jane", "10.0.1.1"))
+
+		guard.Record(ctx, "jane", "10.0.1.1", repositories.LoginResultInvalid)
+		guard.Record(ctx, "jane", "10.0.1.1", repositories.LoginResultInvalidCode)
+		guard.Record(ctx, "jane", "10.0.1.1", repositories.LoginResultLocked)
+		guard.Record(ctx, "jane", "10.0.1.1", repositories.LoginResultInvalid)
+		assert.NoError(t, guard.Check(ctx, "jane", "10.0.1.2"), "lockouts do not count")
+		guard.Record(ctx, "jane", "10.0.1.1", repositories.LoginResultInvalid)
+
+		var locked *LockedOutError
+		if assert.ErrorAs(t, guard.Check(ctx, "jane", "10.0.1.2"), &locked) {
+			assert.InDelta(t, baseLockout.Seconds(), locked.RetryAfter.Seconds(), 5)
+		}
+		assert.NoError(t, guard.Check(ctx, "john", "10.0.1.2"))
+	})
+
+	t.Run("SuccessDoesNotResetTheAddress", func(t *testing.T) {
+		err := test.ClearTestDB(db)
+		assert.NoError(t, err)
+
+		for i := 0; i < ipFailureThreshold; i++ {
+			guard.Record(ctx, fmt.Sprintf("user%d", i), "10.0.0.1", repositories.LoginResultInvalid)
+		}
+		guard.Record(ctx, "mallory", "10.0.0.1", repositories.LoginResultSuccess)
+
+		var locked *LockedOutError
+		assert.ErrorAs(t, guard.Check(ctx, "jane", "10.0.0.1"), &locked)
+		assert.NoError(t, guard.Check(ctx, "jane", "10.0.0.2"))
+	})
+}
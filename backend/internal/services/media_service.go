@@ -0,0 +1,233 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"log"
+	"movie-system/internal/models"
+	"movie-system/internal/repositories"
+	"movie-system/internal/storage"
+	"net/http"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// MaxUploadBytes is the largest image accepted for upload.
+const MaxUploadBytes = 10 << 20
+
+// maxUploadPixels guards against small files that decode to huge images.
+const maxUploadPixels = 40_000_000
+
+const MediaSizeOriginal = "original"
+
+// thumbnailWidths are the widths, in pixels, of the thumbnails generated for
+// every upload. Images are never scaled up.
+var thumbnailWidths = map[string]int{
+	"small":  185,
+	"medium": 342,
+	"large":  780,
+}
+
+// uploadTypes maps the accepted image types to the extension of the stored
+// original.
+var uploadTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+var (
+	ErrInvalidMedia  = errors.New("invalid media")
+	ErrMediaTooLarge = errors.New("media is too large")
+)
+
+// MediaService stores the images uploaded for movies together with their
+// thumbnails. Files go to the blob store and are served from publicURL.
+type MediaService struct {
+	repo      *repositories.MediaRepository
+	store     storage.BlobStore
+	publicURL string
+}
+
+func NewMediaService(repo *repositories.MediaRepository, store storage.BlobStore, publicURL string) *MediaService {
+	return &MediaService{repo: repo, store: store, publicURL: strings.TrimSuffix(publicURL, "/")}
+}
+
+// Upload validates an image, stores it with its thumbnails and records it
+// for the movie. An uploaded poster becomes the poster of the movie.
+func (s *MediaService) Upload(ctx context.Context, movieID int, kind string, data []byte) (*models.Media, error) {
+	if !models.IsValidMediaKind(kind) {
+		return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidMedia, kind)
+	}
+	if len(data) > MaxUploadBytes {
+		return nil, ErrMediaTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	if _, ok := uploadTypes[contentType]; !ok {
+		return nil, fmt.Errorf("%w: unsupported type %s, use JPEG, PNG or WebP", ErrInvalidMedia, contentType)
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMedia, err)
+	}
+	if config.Width*config.Height > maxUploadPixels {
+		return nil, ErrMediaTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMedia, err)
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	media := &models.Media{
+		MovieID:     uint(movieID),
+		Kind:        kind,
+		ContentType: contentType,
+		Width:       config.Width,
+		Height:      config.Height,
+		SizeBytes:   int64(len(data)),
+		KeyPrefix:   fmt.Sprintf("movies/%d/%s", movieID, token),
+	}
+
+	if err := s.store.Put(ctx, mediaKey(media, MediaSizeOriginal), contentType, data); err != nil {
+		return nil, err
+	}
+	for size, width := range thumbnailWidths {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, thumbnail(img, width), &jpeg.Options{Quality: 85}); err != nil {
+			s.deleteBlobs(ctx, media)
+			return nil, fmt.Errorf("error encoding thumbnail: %w", err)
+		}
+		if err := s.store.Put(ctx, mediaKey(media, size), "image/jpeg", buf.Bytes()); err != nil {
+			s.deleteBlobs(ctx, media)
+			return nil, err
+		}
+	}
+
+	if err := s.repo.InsertMedia(ctx, media); err != nil {
+		s.deleteBlobs(ctx, media)
+		return nil, err
+	}
+	s.setURLs(media)
+
+	if kind == models.MediaKindPoster {
+		if err := s.repo.SetPoster(ctx, movieID, media.URLs["large"]); err != nil {
+			return nil, err
+		}
+	}
+	return media, nil
+}
+
+func (s *MediaService) ListMedia(ctx context.Context, movieID int) ([]models.Media, error) {
+	media, err := s.repo.ListMedia(ctx, movieID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range media {
+		s.setURLs(&media[i])
+	}
+	return media, nil
+}
+
+// Open returns one size of an uploaded image. The caller has to close the
+// blob.
+func (s *MediaService) Open(ctx context.Context, id int, size string) (*storage.Blob, error) {
+	if _, ok := thumbnailWidths[size]; !ok && size != MediaSizeOriginal {
+		return nil, repositories.ErrMediaNotFound
+	}
+	media, err := s.repo.GetMedia(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	blob, err := s.store.Get(ctx, mediaKey(media, size))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, repositories.ErrMediaNotFound
+	}
+	return blob, err
+}
+
+// Delete removes an image and its thumbnails and returns what it was. A
+// movie whose poster it was is left without a poster.
+func (s *MediaService) Delete(ctx context.Context, id int) (*models.Media, error) {
+	media, err := s.repo.GetMedia(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.deleteBlobs(ctx, media); err != nil {
+		return nil, err
+	}
+	if err := s.repo.DeleteMedia(ctx, id); err != nil {
+		return nil, err
+	}
+	s.setURLs(media)
+	return media, s.repo.ClearPoster(ctx, int(media.MovieID), media.URLs["large"])
+}
+
+// DeleteFiles removes the stored files of images whose records are gone
+// already, such as those of a purged movie. There is nothing left to roll
+// back to, so failures are only logged.
+func (s *MediaService) DeleteFiles(ctx context.Context, media []models.Media) {
+	for i := range media {
+		s.deleteBlobs(ctx, &media[i])
+	}
+}
+
+func (s *MediaService) deleteBlobs(ctx context.Context, media *models.Media) error {
+	sizes := []string{MediaSizeOriginal}
+	for size := range thumbnailWidths {
+		sizes = append(sizes, size)
+	}
+	for _, size := range sizes {
+		if err := s.store.Delete(ctx, mediaKey(media, size)); err != nil {
+			log.Printf("Error deleting %s of media %s: %v", size, media.KeyPrefix, err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MediaService) setURLs(media *models.Media) {
+	media.URLs = map[string]string{
+		MediaSizeOriginal: fmt.Sprintf("%s/media/%d/%s", s.publicURL, media.ID, MediaSizeOriginal),
+	}
+	for size := range thumbnailWidths {
+		media.URLs[size] = fmt.Sprintf("%s/media/%d/%s", s.publicURL, media.ID, size)
+	}
+}
+
+// mediaKey is the blob key of one size of an image. Thumbnails are always
+// JPEG.
+func mediaKey(media *models.Media, size string) string {
+	if size == MediaSizeOriginal {
+		return media.KeyPrefix + "/original" + uploadTypes[media.ContentType]
+	}
+	return media.KeyPrefix + "/" + size + ".jpg"
+}
+
+// thumbnail scales img down to width, keeping the aspect ratio. Transparent
+// areas are flattened onto white since thumbnails are JPEG.
+func thumbnail(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	height := bounds.Dy()
+	if bounds.Dx() > width {
+		height = max(1, bounds.Dy()*width/bounds.Dx())
+	} else {
+		width = bounds.Dx()
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+	return dst
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"movie-system/internal/models"
+	"movie-system/internal/storage"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMediaUploadValidation(t *testing.T) {
+	// Validation happens before anything is stored
+	service := NewMediaService(nil, nil, "http://localhost:8080")
+	ctx := context.Background()
+
+	var img bytes.Buffer
+	assert.NoError(t, png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 10, 10))))
+
+	_, err := service.Upload(ctx, 1, "trailer", img.Bytes())
+	assert.ErrorIs(t, err, ErrInvalidMedia)
+
+	_, err = service.Upload(ctx, 1, "poster", []byte("%PDF-1.4 not an image"))
+	assert.ErrorIs(t, err, ErrInvalidMedia)
+
+	_, err = service.Upload(ctx, 1, "poster", img.Bytes()[:40])
+	assert.ErrorIs(t, err, ErrInvalidMedia)
+
+	_, err = service.Upload(ctx, 1, "poster", make([]byte, MaxUploadBytes+1))
+	assert.ErrorIs(t, err, ErrMediaTooLarge)
+}
+
+func TestDeleteFiles(t *testing.T) {
+	// The records are gone already; only the store is touched
+	store := storage.NewLocalStore(t.TempDir())
+	service := NewMediaService(nil, store, "http://localhost:8080")
+	ctx := context.Background()
+
+	media := []models.Media{
+		{KeyPrefix: "movies/1/a", ContentType: "image/png"},
+		{KeyPrefix: "movies/1/b", ContentType: "image/jpeg"},
+	}
+	assert.NoError(t, store.Put(ctx, mediaKey(&media[0], MediaSizeOriginal), "image/png", []byte("a")))
+	assert.NoError(t, store.Put(ctx, mediaKey(&media[0], "small"), "image/jpeg", []byte("a")))
+	assert.NoError(t, store.Put(ctx, mediaKey(&media[1], MediaSizeOriginal), "image/jpeg", []byte("b")))
+
+	service.DeleteFiles(ctx, media)
+	for _, key := range []string{mediaKey(&media[0], MediaSizeOriginal), mediaKey(&media[0], "small"), mediaKey(&media[1], MediaSizeOriginal)} {
+		_, err := store.Get(ctx, key)
+		assert.ErrorIs(t, err, storage.ErrNotFound, key)
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 1000, 1500))
+	src.Set(0, 0, color.NRGBA{R: 255, A: 255})
+
+	thumb := thumbnail(src, 342)
+	assert.Equal(t, image.Rect(0, 0, 342, 513), thumb.Bounds())
+
+	// Transparent pixels become white
+	r, g, b, _ := thumb.At(200, 300).RGBA()
+	assert.Equal(t, []uint32{0xffff, 0xffff, 0xffff}, []uint32{r, g, b})
+
+	// Small images are not scaled up
+	thumb = thumbnail(image.NewRGBA(image.Rect(0, 0, 100, 50)), 780)
+	assert.Equal(t, image.Rect(0, 0, 100, 50), thumb.Bounds())
+}
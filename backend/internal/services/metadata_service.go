@@ -0,0 +1,253 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"movie-system/internal/models"
+	"movie-system/internal/repositories"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MetadataService fills in movies from metadata dumps parsed by the metadata
+// package. Movies are matched by external id, or else by title if they have
+// none yet. Fields an editor has locked are never overwritten; where the
+// dump disagrees with one, the import reports a conflict.
+type MetadataService struct {
+	movies *repositories.MovieRepository
+	genres *repositories.GenreRepository
+	people *repositories.PersonRepository
+	audit  *AuditService
+}
+
+func NewMetadataService(movies *repositories.MovieRepository, genres *repositories.GenreRepository, people *repositories.PersonRepository, audit *AuditService) *MetadataService {
+	return &MetadataService{movies: movies, genres: genres, people: people, audit: audit}
+}
+
+// metadataField reads and copies one of models.MetadataFields. value gives
+// a comparable form of the field, empty when it is not set; genres compare
+// by slug and credits in the order they are listed in.
+type metadataField struct {
+	name  string
+	value func(movie *models.Movie) string
+	copy  func(dst, src *models.Movie)
+}
+
+var metadataFields = []metadataField{
+	{"title", func(m *models.Movie) string { return m.Title }, func(dst, src *models.Movie) { dst.Title = src.Title }},
+	{"description", func(m *models.Movie) string { return m.Description }, func(dst, src *models.Movie) { dst.Description = src.Description }},
+	{"poster_image", func(m *models.Movie) string { return m.PosterImage }, func(dst, src *models.Movie) { dst.PosterImage = src.PosterImage }},
+	{"release_date", func(m *models.Movie) string {
+		if m.ReleaseDate == nil {
+			return ""
+		}
+		return m.ReleaseDate.Format(time.DateOnly)
+	}, func(dst, src *models.Movie) { dst.ReleaseDate = src.ReleaseDate }},
+	{"runtime_minutes", func(m *models.Movie) string {
+		if m.RuntimeMinutes == 0 {
+			return ""
+		}
+		return strconv.Itoa(m.RuntimeMinutes)
+	}, func(dst, src *models.Movie) { dst.RuntimeMinutes = src.RuntimeMinutes }},
+	{"certification", func(m *models.Movie) string { return m.Certification }, func(dst, src *models.Movie) { dst.Certification = src.Certification }},
+	{"original_language", func(m *models.Movie) string { return m.OriginalLanguage }, func(dst, src *models.Movie) { dst.OriginalLanguage = src.OriginalLanguage }},
+	{"genres", func(m *models.Movie) string {
+		slugs := make([]string, len(m.Genres))
+		for i, genre := range m.Genres {
+			slugs[i] = models.Slugify(genre.Name)
+		}
+		slices.Sort(slugs)
+		return strings.Join(slugs, ", ")
+	}, func(dst, src *models.Movie) { dst.Genres = src.Genres }},
+	{"credits", func(m *models.Movie) string {
+		sorted := slices.Clone(m.Credits)
+		slices.SortStableFunc(sorted, func(a, b models.Credit) int {
+			return cmp.Or(strings.Compare(a.Role, b.Role), a.Position-b.Position)
+		})
+		credits := make([]string, len(sorted))
+		for i, credit := range sorted {
+			credits[i] = credit.Name + " (" + credit.Role
+			if credit.Character != "" {
+				credits[i] += ": " + credit.Character
+			}
+			credits[i] += ")"
+		}
+		return strings.Join(credits, ", ")
+	}, func(dst, src *models.Movie) { dst.Credits = src.Credits }},
+}
+
+// Import creates or updates the given movies. Genres and people are
+// matched by slug and name and created when missing. A movie that cannot be
+// saved is reported as failed without stopping the import; every saved
+// change is recorded in the audit log under actor.
+func (s *MetadataService) Import(ctx context.Context, actor string, movies []models.Movie) (*models.MetadataReport, error) {
+	report := &models.MetadataReport{Movies: make([]models.MetadataResult, 0, len(movies))}
+	for i := range movies {
+		result, err := s.importMovie(ctx, actor, &movies[i])
+		if err != nil {
+			return nil, err
+		}
+		switch result.Action {
+		case models.ImportActionCreate:
+			report.Created++
+		case models.ImportActionUpdate:
+			report.Updated++
+		case models.ImportActionUnchanged:
+			report.Unchanged++
+		case models.ImportActionFail:
+			report.Failed++
+		}
+		report.Conflicts += len(result.Conflicts)
+		report.Movies = append(report.Movies, result)
+	}
+	return report, nil
+}
+
+func (s *MetadataService) importMovie(ctx context.Context, actor string, imported *models.Movie) (models.MetadataResult, error) {
+	result := models.MetadataResult{ExternalID: imported.ExternalID, Title: imported.Title}
+	fail := func(err error) (models.MetadataResult, error) {
+		result.Action = models.ImportActionFail
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	current, err := s.movies.GetMovieByExternalID(ctx, imported.ExternalID)
+	if errors.Is(err, repositories.ErrMovieNotFound) {
+		current, err = s.movies.GetMovieByTitle(ctx, imported.Title)
+		if err == nil && current.ExternalID != "" {
+			return fail(fmt.Errorf("the title belongs to movie %d, imported as %q", current.ID, current.ExternalID))
+		}
+	}
+	if err != nil && !errors.Is(err, repositories.ErrMovieNotFound) {
+		return result, err
+	}
+
+	movie, changed, conflicts := mergeMetadata(current, imported)
+	result.Conflicts = conflicts
+	if current != nil {
+		result.ID = current.ID
+		if current.ExternalID == "" {
+			changed = append(changed, "external_id")
+		}
+		if len(changed) == 0 {
+			result.Action = models.ImportActionUnchanged
+			return result, nil
+		}
+	}
+
+	if slices.Contains(changed, "genres") {
+		if movie.Genres, err = s.resolveGenres(ctx, movie.Genres); err != nil {
+			return result, err
+		}
+	} else if current != nil {
+		movie.Genres = nil
+	}
+	if slices.Contains(changed, "credits") {
+		if movie.Credits, err = s.resolveCredits(ctx, movie.Credits); err != nil {
+			return result, err
+		}
+	} else if current != nil {
+		movie.Credits = nil
+	}
+	if err := movie.Validate(); err != nil {
+		return fail(err)
+	}
+
+	var after *models.Movie
+	if current == nil {
+		result.Action = models.ImportActionCreate
+		err = s.movies.InsertMovie(ctx, movie)
+		after = movie
+	} else {
+		result.Action = models.ImportActionUpdate
+		after, err = s.movies.UpdateMovie(ctx, int(current.ID), movie)
+	}
+	if errors.Is(err, repositories.ErrMovieExists) || errors.Is(err, repositories.ErrExternalIDExists) {
+		return fail(err)
+	}
+	if err != nil {
+		return result, err
+	}
+	result.ID = after.ID
+
+	action := models.AuditActionCreate
+	if current != nil {
+		action = models.AuditActionUpdate
+	}
+	if _, err := s.audit.Record(ctx, actor, action, models.AuditEntityMovie, int(after.ID), current, after); err != nil {
+		log.Printf("Failed to record metadata import of movie %d: %v", after.ID, err)
+	}
+	return result, nil
+}
+
+// mergeMetadata lays the imported fields over current, which is nil for a
+// new movie. Fields missing from the import are kept, as are locked ones;
+// it returns the merged movie, the fields that changed and the locked fields
+// the import disagrees with.
+func mergeMetadata(current, imported *models.Movie) (*models.Movie, []string, []models.MetadataConflict) {
+	movie := &models.Movie{ExternalID: imported.ExternalID}
+	if current != nil {
+		*movie = *current
+		movie.ExternalID = imported.ExternalID
+	}
+
+	var changed []string
+	var conflicts []models.MetadataConflict
+	for _, field := range metadataFields {
+		from, to := field.value(movie), field.value(imported)
+		if to == "" || to == from {
+			continue
+		}
+		if slices.Contains(movie.LockedFields, field.name) {
+			conflicts = append(conflicts, models.MetadataConflict{Field: field.name, Current: from, Imported: to})
+			continue
+		}
+		field.copy(movie, imported)
+		changed = append(changed, field.name)
+	}
+	return movie, changed, conflicts
+}
+
+// resolveGenres looks genres up by the slug of their name, creating the
+// missing ones.
+func (s *MetadataService) resolveGenres(ctx context.Context, genres []models.Genre) ([]models.Genre, error) {
+	resolved := make([]models.Genre, 0, len(genres))
+	for _, genre := range genres {
+		found, err := s.genres.GetGenreBySlug(ctx, models.Slugify(genre.Name))
+		if errors.Is(err, repositories.ErrGenreNotFound) {
+			found = &models.Genre{Name: genre.Name}
+			err = s.genres.InsertGenre(ctx, found)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !slices.ContainsFunc(resolved, func(g models.Genre) bool { return g.ID == found.ID }) {
+			resolved = append(resolved, *found)
+		}
+	}
+	return resolved, nil
+}
+
+// resolveCredits looks the credited people up by name, creating the missing
+// ones.
+func (s *MetadataService) resolveCredits(ctx context.Context, credits []models.Credit) ([]models.Credit, error) {
+	resolved := make([]models.Credit, len(credits))
+	for i, credit := range credits {
+		person, err := s.people.FindPersonByName(ctx, credit.Name)
+		if errors.Is(err, repositories.ErrPersonNotFound) {
+			person = &models.Person{Name: credit.Name}
+			err = s.people.InsertPerson(ctx, person)
+		}
+		if err != nil {
+			return nil, err
+		}
+		credit.PersonID = person.ID
+		resolved[i] = credit
+	}
+	return resolved, nil
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"movie-system/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataFields(t *testing.T) {
+	names := make([]string, len(metadataFields))
+	for i, field := range metadataFields {
+		names[i] = field.name
+	}
+	assert.Equal(t, models.MetadataFields, names)
+}
+
+func TestMergeMetadata(t *testing.T) {
+	released := time.Date(2010, 7, 16, 0, 0, 0, 0, time.UTC)
+	imported := &models.Movie{
+		ExternalID:     "tt1375666",
+		Title:          "Inception",
+		Description:    "A thief who steals corporate secrets through dream-sharing.",
+		ReleaseDate:    &released,
+		RuntimeMinutes: 148,
+		Genres:         []models.Genre{{Name: "Science Fiction"}, {Name: "Action"}},
+		Credits: []models.Credit{
+			{Name: "Leonardo DiCaprio", Role: models.CreditRoleCast, Character: "Cobb"},
+			{Name: "Christopher Nolan", Role: models.CreditRoleDirector},
+		},
+	}
+
+	movie, changed, conflicts := mergeMetadata(nil, imported)
+	assert.Equal(t, imported, movie)
+	assert.Equal(t, []string{"title", "description", "release_date", "runtime_minutes", "genres", "credits"}, changed)
+	assert.Empty(t, conflicts)
+
+	current := &models.Movie{
+		ID:             7,
+		Title:          "Inception",
+		Description:    "Our own synopsis.",
+		PosterImage:    "/media/7/poster.jpg",
+		RuntimeMinutes: 150,
+		Certification:  "12A",
+		Subtitles:      []string{"fr"},
+		LockedFields:   []string{"description", "genres"},
+		Genres:         []models.Genre{{ID: 1, Name: "Action", Slug: "action"}, {ID: 2, Name: "Sci-Fi", Slug: "sci-fi"}},
+		Credits: []models.Credit{
+			{PersonID: 3, Name: "Christopher Nolan", Role: models.CreditRoleDirector},
+			{PersonID: 4, Name: "Leonardo DiCaprio", Role: models.CreditRoleCast, Character: "Cobb"},
+		},
+	}
+	movie, changed, conflicts = mergeMetadata(current, imported)
+	assert.Equal(t, []string{"release_date", "runtime_minutes"}, changed, "credits match regardless of their order")
+	assert.Equal(t, []models.MetadataConflict{
+		{Field: "description", Current: "Our own synopsis.", Imported: imported.Description},
+		{Field: "genres", Current: "action, sci-fi", Imported: "action, science-fiction"},
+	}, conflicts)
+	assert.Equal(t, "Our own synopsis.", movie.Description)
+	assert.Equal(t, "/media/7/poster.jpg", movie.PosterImage, "fields missing from the import are kept")
+	assert.Equal(t, "12A", movie.Certification)
+	assert.Equal(t, 148, movie.RuntimeMinutes)
+	assert.Equal(t, []string{"fr"}, movie.Subtitles)
+	assert.Equal(t, "tt1375666", movie.ExternalID)
+	assert.Equal(t, "Our own synopsis.", current.Description, "current is left alone")
+	assert.Equal(t, 150, current.RuntimeMinutes)
+}
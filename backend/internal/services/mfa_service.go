@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"movie-system/internal/models"
+	"movie-system/internal/repositories"
+	"movie-system/internal/totp"
+	"strings"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+)
+
+const recoveryCodeCount = 10
+
+var (
+	ErrInvalidMFACode    = errors.New("invalid two-factor authentication code")
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled    = errors.New("two-factor authentication has not been set up")
+	ErrMFARequired       = errors.New("two-factor authentication is required for this role")
+)
+
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+	QRCode string `json:"qr_code"`
+}
+
+// MFAService manages TOTP enrollment and verification of second factors.
+type MFAService struct {
+	repo   *repositories.MFARepository
+	issuer string
+}
+
+func NewMFAService(repo *repositories.MFARepository, issuer string) *MFAService {
+	return &MFAService{repo: repo, issuer: issuer}
+}
+
+// Enroll generates a new secret for the user. The secret only takes effect
+// once it has been confirmed with Confirm.
+func (s *MFAService) Enroll(ctx context.Context, user *models.User) (*TOTPEnrollment, error) {
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetPendingSecret(ctx, int(user.ID), secret); err != nil {
+		return nil, err
+	}
+
+	uri := totp.URI(s.issuer, user.Username, secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		return nil, fmt.Errorf("error generating qr code: %w", err)
+	}
+
+	return &TOTPEnrollment{
+		Secret: secret,
+		URI:    uri,
+		QRCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// Confirm enables two-factor authentication after the user proves they set up
+// their authenticator, and returns a fresh set of recovery codes.
+func (s *MFAService) Confirm(ctx context.Context, user *models.User, code string) ([]string, error) {
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, _, _, err := s.repo.GetTOTP(ctx, int(user.ID))
+	if err != nil {
+		return nil, err
+	}
+	if secret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+	if err := s.verifyTOTP(ctx, int(user.ID), secret, code); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.EnableTOTP(ctx, int(user.ID)); err != nil {
+		return nil, err
+	}
+	return s.newRecoveryCodes(ctx, int(user.ID))
+}
+
+// Verify checks a TOTP code or an unused recovery code for a user that has
+// two-factor authentication enabled.
+func (s *MFAService) Verify(ctx context.Context, user *models.User, code string) error {
+	secret, enabled, _, err := s.repo.GetTOTP(ctx, int(user.ID))
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return ErrMFANotEnrolled
+	}
+
+	if err := s.verifyTOTP(ctx, int(user.ID), secret, code); err == nil {
+		return nil
+	}
+
+	ok, err := s.repo.ConsumeRecoveryCode(ctx, int(user.ID), hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *MFAService) Disable(ctx context.Context, user *models.User, code string) error {
+	required, err := s.repo.IsRequiredForRole(ctx, user.Role)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrMFARequired
+	}
+
+	if err := s.Verify(ctx, user, code); err != nil {
+		return err
+	}
+	return s.repo.DisableTOTP(ctx, int(user.ID))
+}
+
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, user *models.User, code string) ([]string, error) {
+	if err := s.Verify(ctx, user, code); err != nil {
+		return nil, err
+	}
+	return s.newRecoveryCodes(ctx, int(user.ID))
+}
+
+func (s *MFAService) IsRequired(ctx context.Context, role string) (bool, error) {
+	return s.repo.IsRequiredForRole(ctx, role)
+}
+
+func (s *MFAService) RequiredRoles(ctx context.Context) ([]string, error) {
+	return s.repo.GetRequiredRoles(ctx)
+}
+
+func (s *MFAService) SetRequiredRoles(ctx context.Context, roles []string) error {
+	for _, role := range roles {
+		if !models.IsValidRole(role) {
+			return fmt.Errorf("invalid role %q", role)
+		}
+	}
+	return s.repo.SetRequiredRoles(ctx, roles)
+}
+
+func (s *MFAService) verifyTOTP(ctx context.Context, userID int, secret, code string) error {
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	// Each code may only be used once, even within its validity window.
+	fresh, err := s.repo.AdvanceLastStep(ctx, userID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *MFAService) newRecoveryCodes(ctx context.Context, userID int) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 6)
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("error generating recovery code: %w", err)
+		}
+		raw := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:10]
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashToken(raw)
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"movie-system/internal/auth"
+	"movie-system/internal/models"
+	"movie-system/internal/repositories"
+	"movie-system/internal/totp"
+	"movie-system/test"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// totpCode returns the code of secret offset periods from now.
+func totpCode(t *testing.T, secret string, offset int64) string {
+	code, err := totp.CodeAt(secret, totp.Step(time.Now())+offset)
+	if err != nil {
+		t.Fatalf("Failed to generate code: %v", err)
+	}
+	return code
+}
+
+// wrongCode returns a code that is not valid for secret right now.
+func wrongCode(t *testing.T, secret string) string {
+	valid := []string{totpCode(t, secret, -1), totpCode(t, secret, 0), totpCode(t, secret, 1)}
+	for i := 0; ; i++ {
+		if code := fmt.Sprintf("%06d", i); !slices.Contains(valid, code) {
+			return code
+		}
+	}
+}
+
+func TestMFAService(t *testing.T) {
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+
+	userRepo := repositories.NewUserRepository(db)
+	service := NewMFAService(repositories.NewMFARepository(db), "Movie System")
+	authService := NewAuthService(userRepo, service, NewLoginGuard(repositories.NewLoginAttemptRepository(db)), "secret")
+	ctx := context.Background()
+
+	// enroll signs up jane and sets up two-factor authentication for her.
+	enroll := func(t *testing.T) (*models.User, string, []string) {
+		err := test.ClearTestDB(db)
+		assert.NoError(t, err)
+
+		user, err := authService.SignUp(ctx, "jane", "jane@example.com", "password1")
+		if err != nil {
+			t.Fatalf("Failed to sign up: %v", err)
+		}
+		enrollment, err := service.Enroll(ctx, user)
+		if err != nil {
+			t.Fatalf("Failed to enroll: %v", err)
+		}
+		codes, err := service.Confirm(ctx, user, totpCode(t, enrollment.Secret, 0))
+		if err != nil {
+			t.Fatalf("Failed to confirm: %v", err)
+		}
+		user, err = userRepo.GetUserByID(ctx, int(user.ID))
+		if err != nil {
+			t.Fatalf("Failed to fetch user: %v", err)
+		}
+		return user, enrollment.Secret, codes
+	}
+
+	t.Run("EnrollAndConfirm", func(t *testing.T) {
+		err := test.ClearTestDB(db)
+		assert.NoError(t, err)
+
+		user, err := authService.SignUp(ctx, "jane", "jane@example.com", "password1")
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = service.Confirm(ctx, user, "123456")
+		assert.ErrorIs(t, err, ErrMFANotEnrolled)
+
+		enrollment, err := service.Enroll(ctx, user)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NotEmpty(t, enrollment.Secret)
+		assert.True(t, strings.HasPrefix(enrollment.URI, "otpauth://totp/"))
+		assert.True(t, strings.HasPrefix(enrollment.QRCode, "data:image/png;base64,"))
+
+		// A second enrollment replaces the secret that was never confirmed
+		enrollment, err = service.Enroll(ctx, user)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		_, err = service.Confirm(ctx, user, wrongCode(t, enrollment.Secret))
+		assert.ErrorIs(t, err, ErrInvalidMFACode)
+		user, err = userRepo.GetUserByID(ctx, int(user.ID))
+		assert.NoError(t, err)
+		assert.False(t, user.TOTPEnabled)
+
+		codes, err := service.Confirm(ctx, user, totpCode(t, enrollment.Secret, 0))
+		assert.NoError(t, err)
+		assert.Len(t, codes, recoveryCodeCount)
+
+		user, err = userRepo.GetUserByID(ctx, int(user.ID))
+		assert.NoError(t, err)
+		assert.True(t, user.TOTPEnabled)
+		_, err = service.Enroll(ctx, user)
+		assert.ErrorIs(t, err, ErrMFAAlreadyEnabled)
+		_, err = service.Confirm(ctx, user, totpCode(t, enrollment.Secret, 1))
+		assert.ErrorIs(t, err, ErrMFAAlreadyEnabled)
+	})
+
+	t.Run("RecoveryCodes", func(t *testing.T) {
+		user, _, codes := enroll(t)
+
+		assert.NoError(t, service.Verify(ctx, user, " "+strings.ToUpper(codes[0])+" "))
+		assert.ErrorIs(t, service.Verify(ctx, user, codes[0]), ErrInvalidMFACode, "recovery codes work once")
+		assert.NoError(t, service.Verify(ctx, user, strings.ReplaceAll(codes[1], "-", "")))
+
+		fresh, err := service.RegenerateRecoveryCodes(ctx, user, codes[2])
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Len(t, fresh, recoveryCodeCount)
+		assert.ErrorIs(t, service.Verify(ctx, user, codes[3]), ErrInvalidMFACode, "earlier codes are replaced")
+		assert.NoError(t, service.Verify(ctx, user, fresh[0]))
+	})
+
+	t.Run("CompleteMFALogin", func(t *testing.T) {
+		_, secret, codes := enroll(t)
+
+		result, err := authService.LogIn(ctx, "jane", "password1", "10.0.0.1")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.True(t, result.MFARequired)
+		assert.Empty(t, result.Token)
+
+		_, err = authService.CompleteMFALogin(ctx, result.MFAToken, wrongCode(t, secret), "10.0.0.1")
+		assert.ErrorIs(t, err, ErrInvalidMFACode)
+
+		code := totpCode(t, secret, 1)
+		token, err := authService.CompleteMFALogin(ctx, result.MFAToken, code, "10.0.0.1")
+		if !assert.NoError(t, err) {
+			return
+		}
+		claims, err := auth.ParseToken(token, "secret")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "jane", claims.Username)
+			assert.Empty(t, claims.Purpose)
+		}
+
+		_, err = authService.CompleteMFALogin(ctx, result.MFAToken, code, "10.0.0.1")
+		assert.ErrorIs(t, err, ErrInvalidMFACode, "codes cannot be replayed")
+		_, err = authService.CompleteMFALogin(ctx, token, codes[0], "10.0.0.1")
+		assert.Error(t, err, "session tokens do not complete logins")
+
+		_, err = authService.CompleteMFALogin(ctx, result.MFAToken, codes[0], "10.0.0.1")
+		assert.NoError(t, err)
+	})
+}
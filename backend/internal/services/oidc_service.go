@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"movie-system/internal/models"
+	"movie-system/internal/oidc"
+	"movie-system/internal/repositories"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const oidcStateTTL = 10 * time.Minute
+
+var (
+	ErrUnknownProvider    = errors.New("unknown identity provider")
+	ErrEmailNotVerified   = errors.New("identity provider did not return a verified email")
+	ErrAccountLinkPending = errors.New("an account with this email exists but its email is not verified")
+)
+
+var usernameCleaner = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// OIDCService signs users in through external OpenID Connect providers.
+// Identities are linked to existing users by verified email, and the login
+// ends in the same session token AuthService issues for password logins.
+type OIDCService struct {
+	clients    map[string]*oidc.Client
+	identities *repositories.IdentityRepository
+	users      *repositories.UserRepository
+	auth       *AuthService
+}
+
+func NewOIDCService(configs []oidc.Config, identities *repositories.IdentityRepository, users *repositories.UserRepository, auth *AuthService) *OIDCService {
+	clients := make(map[string]*oidc.Client)
+	for _, config := range configs {
+		clients[config.Name] = oidc.NewClient(config)
+	}
+	return &OIDCService{clients: clients, identities: identities, users: users, auth: auth}
+}
+
+func (s *OIDCService) Providers() []string {
+	names := make([]string, 0, len(s.clients))
+	for name := range s.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BeginLogin returns the provider URL the browser has to visit. The state,
+// nonce and PKCE verifier are kept server side until the callback.
+func (s *OIDCService) BeginLogin(ctx context.Context, provider string) (string, error) {
+	client, ok := s.clients[provider]
+	if !ok {
+		return "", ErrUnknownProvider
+	}
+
+	state, err := oidc.RandomString()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := oidc.RandomString()
+	if err != nil {
+		return "", err
+	}
+	verifier, err := oidc.RandomString()
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.identities.SaveState(ctx, hashToken(state), provider, nonce, verifier, oidcStateTTL); err != nil {
+		return "", err
+	}
+
+	return client.AuthCodeURL(ctx, state, nonce, oidc.S256Challenge(verifier))
+}
+
+// CompleteLogin handles the provider callback: it checks the state, redeems
+// the code, validates the ID token and starts a session for the linked user.
+func (s *OIDCService) CompleteLogin(ctx context.Context, provider, code, state, ip string) (*LoginResult, error) {
+	client, ok := s.clients[provider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	nonce, verifier, err := s.identities.ConsumeState(ctx, hashToken(state), provider)
+	if err != nil {
+		return nil, err
+	}
+
+	rawIDToken, err := client.Exchange(ctx, code, verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	idToken, err := client.VerifyIDToken(ctx, rawIDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.resolveUser(ctx, provider, idToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.auth.StartSession(ctx, user, ip)
+}
+
+// resolveUser finds the user linked to the identity, links it to the user
+// with the same verified email, or creates a new user.
+func (s *OIDCService) resolveUser(ctx context.Context, provider string, idToken *oidc.IDToken) (*models.User, error) {
+	userID, err := s.identities.GetUserIDByIdentity(ctx, provider, idToken.Subject)
+	if err == nil {
+		return s.users.GetUserByID(ctx, userID)
+	}
+	if !errors.Is(err, repositories.ErrIdentityNotFound) {
+		return nil, err
+	}
+
+	if idToken.Email == "" || !idToken.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+
+	user, err := s.users.GetUserByEmail(ctx, idToken.Email)
+	switch {
+	case err == nil:
+		// Linking to an account whose owner never proved the address would
+		// let whoever registered it first take over the provider login.
+		if !user.EmailVerified {
+			return nil, ErrAccountLinkPending
+		}
+	case errors.Is(err, repositories.ErrUserNotFound):
+		user, err = s.createUser(ctx, idToken)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	if err := s.identities.LinkIdentity(ctx, int(user.ID), provider, idToken.Subject, idToken.Email); err != nil {
+		return nil, err
+	}
+	log.Printf("Linked %s identity %s to user %s", provider, idToken.Subject, user.Username)
+	return user, nil
+}
+
+func (s *OIDCService) createUser(ctx context.Context, idToken *oidc.IDToken) (*models.User, error) {
+	base := idToken.PreferredUsername
+	if base == "" {
+		base = strings.SplitN(idToken.Email, "@", 2)[0]
+	}
+	base = usernameCleaner.ReplaceAllString(strings.ToLower(base), "")
+	if base == "" {
+		base = "user"
+	}
+
+	username := base
+	for i := 2; ; i++ {
+		_, err := s.users.GetUserByUsername(ctx, username)
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if i > 100 {
+			return nil, fmt.Errorf("could not find a free username for %s", base)
+		}
+		username = fmt.Sprintf("%s%d", base, i)
+	}
+
+	return s.users.CreateExternalUser(ctx, username, idToken.Email)
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"movie-system/internal/models"
+	"movie-system/internal/oidc"
+	"movie-system/internal/repositories"
+	"movie-system/test"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOIDCResolveUser(t *testing.T) {
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+
+	userRepo := repositories.NewUserRepository(db)
+	mfaService := NewMFAService(repositories.NewMFARepository(db), "Movie System")
+	authService := NewAuthService(userRepo, mfaService, NewLoginGuard(repositories.NewLoginAttemptRepository(db)), "secret")
+	service := NewOIDCService(nil, repositories.NewIdentityRepository(db), userRepo, authService)
+	ctx := context.Background()
+
+	t.Run("LinksVerifiedEmail", func(t *testing.T) {
+		err := test.ClearTestDB(db)
+		assert.NoError(t, err)
+
+		jane, err := authService.SignUp(ctx, "jane", "jane@example.com", "password1")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NoError(t, userRepo.SetEmailVerified(ctx, int(jane.ID), jane.Email))
+
+		_, err = service.resolveUser(ctx, "google", &oidc.IDToken{Subject: "g-1", Email: "jane@example.com"})
+		assert.ErrorIs(t, err, ErrEmailNotVerified, "the provider has to vouch for the address")
+
+		user, err := service.resolveUser(ctx, "google", &oidc.IDToken{Subject: "g-1", Email: "Jane@Example.com", EmailVerified: true})
+		if assert.NoError(t, err) {
+			assert.Equal(t, jane.ID, user.ID)
+		}
+
+		// Once linked the identity is found by subject, whatever the email
+		user, err = service.resolveUser(ctx, "google", &oidc.IDToken{Subject: "g-1", Email: "jane@elsewhere.example"})
+		if assert.NoError(t, err) {
+			assert.Equal(t, jane.ID, user.ID)
+		}
+	})
+
+	t.Run("CreatesUnknownUsers", func(t *testing.T) {
+		err := test.ClearTestDB(db)
+		assert.NoError(t, err)
+
+		_, err = authService.SignUp(ctx, "john", "john@example.com", "password1")
+		assert.NoError(t, err)
+
+		user, err := service.resolveUser(ctx, "google", &oidc.IDToken{Subject: "g-2", Email: "John@other.example", EmailVerified: true})
+		if assert.NoError(t, err) {
+			assert.Equal(t, "john2", user.Username, "usernames are not reused")
+			assert.Equal(t, models.RoleUser, user.Role)
+		}
+	})
+
+	t.Run("RefusesUnverifiedLocalEmail", func(t *testing.T) {
+		err := test.ClearTestDB(db)
+		assert.NoError(t, err)
+
+		// Whoever signed up with the address first never proved it is theirs
+		squatter, err := authService.SignUp(ctx, "squatter", "jane@example.com", "password1")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		_, err = service.resolveUser(ctx, "google", &oidc.IDToken{Subject: "g-1", Email: "jane@example.com", EmailVerified: true})
+		assert.ErrorIs(t, err, ErrAccountLinkPending)
+
+		_, err = repositories.NewIdentityRepository(db).GetUserIDByIdentity(ctx, "google", "g-1")
+		assert.ErrorIs(t, err, repositories.ErrIdentityNotFound, "nothing is linked")
+
+		assert.NoError(t, userRepo.SetEmailVerified(ctx, int(squatter.ID), squatter.Email))
+		user, err := service.resolveUser(ctx, "google", &oidc.IDToken{Subject: "g-1", Email: "jane@example.com", EmailVerified: true})
+		if assert.NoError(t, err) {
+			assert.Equal(t, squatter.ID, user.ID, "linked once the owner verified the address")
+		}
+	})
+}
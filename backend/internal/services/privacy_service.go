@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"log"
+	"movie-system/internal/models"
+	"movie-system/internal/repositories"
+	"time"
+)
+
+// PrivacyService answers data subject requests: exporting everything stored
+// about a user and erasing their personal data.
+type PrivacyService struct {
+	users   *repositories.UserRepository
+	privacy *repositories.PrivacyRepository
+}
+
+func NewPrivacyService(users *repositories.UserRepository, privacy *repositories.PrivacyRepository) *PrivacyService {
+	return &PrivacyService{users: users, privacy: privacy}
+}
+
+func (s *PrivacyService) Export(ctx context.Context, userID int) (*models.UserDataExport, error) {
+	user, err := s.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &models.UserDataExport{
+		ExportedAt: time.Now().UTC(),
+		Profile:    user,
+	}
+	if export.Reservations, err = s.privacy.GetReservations(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.LinkedIdentities, err = s.privacy.GetLinkedIdentities(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.LoginHistory, err = s.privacy.GetLoginHistory(ctx, user.Username); err != nil {
+		return nil, err
+	}
+	if export.Reviews, err = s.privacy.GetReviews(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.AuditEntries, err = s.privacy.GetAuditEntries(ctx, user.Username); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// Erase anonymizes the account of userID. Reservations are kept for
+// accounting but no longer lead back to the person.
+func (s *PrivacyService) Erase(ctx context.Context, userID int) error {
+	if err := s.privacy.EraseUser(ctx, userID); err != nil {
+		return err
+	}
+	log.Printf("Erased personal data of user %d", userID)
+	return nil
+}
+
+// EraseAccount lets a user erase their own account once the password is
+// confirmed.
+func (s *PrivacyService) EraseAccount(ctx context.Context, user *models.User, password string) error {
+	if err := s.users.CheckPassword(ctx, int(user.ID), password); err != nil {
+		return err
+	}
+	return s.Erase(ctx, int(user.ID))
+}
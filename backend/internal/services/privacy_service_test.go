@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"movie-system/internal/repositories"
+	"movie-system/test"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrivacyService(t *testing.T) {
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+
+	userRepo := repositories.NewUserRepository(db)
+	mfaService := NewMFAService(repositories.NewMFARepository(db), "Movie System")
+	loginGuard := NewLoginGuard(repositories.NewLoginAttemptRepository(db))
+	authService := NewAuthService(userRepo, mfaService, loginGuard, "secret")
+	service := NewPrivacyService(userRepo, repositories.NewPrivacyRepository(db))
+	ctx := context.Background()
+
+	err = test.ClearTestDB(db)
+	assert.NoError(t, err)
+
+	user, err := authService.SignUp(ctx, "jane", "jane@example.com", "password1")
+	assert.NoError(t, err)
+	_, err = authService.LogIn(ctx, "jane", "password1", "10.0.0.1")
+	assert.NoError(t, err)
+
+	_, err = db.Exec(ctx, `
+		INSERT INTO movies (id, title, description, poster_image) VALUES
+		(1, 'Test Movie 1', 'Test Description 1', 'poster1.jpg');
+		INSERT INTO cinemas (id, name, timezone) VALUES (1, 'Main', 'UTC');
+		INSERT INTO showtimes (id, cinema_id, movie_id, start_time, capacity, reserved) VALUES
+		(1, 1, 1, NOW(), 100, 2)`)
+	assert.NoError(t, err)
+	_, err = db.Exec(ctx, `
+		INSERT INTO reservations (user_id, movie_id, showtime_id, seats, seat_price)
+		VALUES ($1, 1, 1, ARRAY['A1', 'A2'], 8)`, user.ID)
+	assert.NoError(t, err)
+	_, err = db.Exec(ctx, `
+		INSERT INTO audit_log (actor, action, entity_type, entity_id, changes) VALUES
+		('jane', 'update', 'movie', 1, '{"title": {"from": "Old", "to": "Test Movie 1"}}'),
+		('john', 'delete', 'movie', 2, '{}')`)
+	assert.NoError(t, err)
+
+	t.Run("Export", func(t *testing.T) {
+		export, err := service.Export(ctx, int(user.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, "jane@example.com", export.Profile.Email)
+		assert.Len(t, export.Reservations, 1)
+		assert.Equal(t, "Test Movie 1", export.Reservations[0].MovieTitle)
+		assert.Equal(t, []string{"A1", "A2"}, export.Reservations[0].Seats)
+		assert.Equal(t, 8, export.Reservations[0].SeatPrice)
+		assert.Equal(t, 16, export.Reservations[0].Total)
+		if assert.Len(t, export.AuditEntries, 1) {
+			assert.Equal(t, "update", export.AuditEntries[0].Action)
+			assert.Equal(t, "Test Movie 1", export.AuditEntries[0].Changes["title"].To)
+		}
+		assert.Len(t, export.LoginHistory, 1)
+		assert.Equal(t, "10.0.0.1", export.LoginHistory[0].IP)
+	})
+
+	t.Run("EraseKeepsReservations", func(t *testing.T) {
+		assert.ErrorIs(t, service.EraseAccount(ctx, user, "wrong"), repositories.ErrInvalidCredentials)
+		assert.NoError(t, service.EraseAccount(ctx, user, "password1"))
+		assert.ErrorIs(t, service.Erase(ctx, int(user.ID)), repositories.ErrUserNotFound)
+
+		erased, err := userRepo.GetUserByID(ctx, int(user.ID))
+		assert.NoError(t, err)
+		assert.NotEqual(t, "jane", erased.Username)
+		assert.Empty(t, erased.Email)
+		assert.True(t, erased.Disabled)
+		assert.NotNil(t, erased.ErasedAt)
+
+		_, err = authService.LogIn(ctx, "jane", "password1", "10.0.0.1")
+		assert.Error(t, err)
+
+		var reservations int
+		err = db.QueryRow(ctx, "SELECT COUNT(*) FROM reservations WHERE user_id = $1", user.ID).Scan(&reservations)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, reservations)
+
+		export, err := service.Export(ctx, int(user.ID))
+		assert.NoError(t, err)
+		for _, attempt := range export.LoginHistory {
+			assert.Empty(t, attempt.IP)
+		}
+	})
+}
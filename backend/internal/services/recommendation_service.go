@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"log"
+	"movie-system/internal/models"
+	"movie-system/internal/pagination"
+	"movie-system/internal/recommend"
+	"movie-system/internal/repositories"
+	"time"
+)
+
+const (
+	// popularityWindow is how far back seats sold count towards popularity.
+	popularityWindow = 30 * 24 * time.Hour
+	// storedSuggestions is how many suggestions are kept per user, enough to
+	// fill a response when some movies sold out since the last run.
+	storedSuggestions = 50
+	// showtimesPerRecommendation is how many upcoming showtimes are listed
+	// with each recommended movie.
+	showtimesPerRecommendation = 3
+)
+
+// RecommendationService suggests upcoming showtimes to users from their
+// booking history. The model is computed by a periodic job, Run, and stored
+// so that reading recommendations costs a few indexed queries.
+type RecommendationService struct {
+	repo         *repositories.RecommendationRepository
+	movies       *repositories.MovieRepository
+	showtimes    *repositories.ShowtimeRepository
+	translations *TranslationService
+}
+
+func NewRecommendationService(repo *repositories.RecommendationRepository, movies *repositories.MovieRepository, showtimes *repositories.ShowtimeRepository, translations *TranslationService) *RecommendationService {
+	return &RecommendationService{repo: repo, movies: movies, showtimes: showtimes, translations: translations}
+}
+
+// Refresh recomputes the recommendations of all users and replaces the stored
+// ones.
+func (s *RecommendationService) Refresh(ctx context.Context) error {
+	started := time.Now()
+	in, err := s.repo.LoadInput(ctx, started.Add(-popularityWindow))
+	if err != nil {
+		return err
+	}
+	model := recommend.Compute(*in, storedSuggestions)
+	if err := s.repo.Store(ctx, model); err != nil {
+		return err
+	}
+	log.Printf("Computed recommendations for %d users from %d candidate movies in %s",
+		len(model.Users), len(in.Candidates), time.Since(started).Round(time.Millisecond))
+	return nil
+}
+
+// Run refreshes the recommendations right away and then every interval until
+// ctx is done. Failures are logged and retried on the next tick.
+func (s *RecommendationService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Refresh(ctx); err != nil {
+			log.Printf("Failed to compute recommendations: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// recommendationCursor is the rank of the first recommendation on the next
+// page.
+type recommendationCursor struct {
+	Offset int `json:"offset"`
+}
+
+// ForUser returns a page of the movies to suggest to a user, best first, each
+// with its next showtimes, translated into the first of locales there is a
+// translation for. Users the job has nothing for, such as new ones, get the
+// popular movies. Movies the user has booked, or that have no upcoming
+// showtime with free seats any more, are left out.
+func (s *RecommendationService) ForUser(ctx context.Context, userID int, locales []string, params pagination.Params) (*pagination.Page[models.Recommendation], error) {
+	// The ranking changes with every run of the job, so pages are by position.
+	var cursor recommendationCursor
+	if err := pagination.DecodeCursor(params.Cursor, &cursor); err != nil || cursor.Offset < 0 {
+		return nil, pagination.ErrInvalidCursor
+	}
+
+	personal, err := s.repo.GetForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	popular, err := s.repo.GetPopular(ctx)
+	if err != nil {
+		return nil, err
+	}
+	booked, err := s.repo.BookedMovies(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	suggestions := recommend.Merge(personal, popular, booked)
+
+	ids := make([]int, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		ids = append(ids, suggestion.MovieID)
+	}
+	showtimes, err := s.showtimes.UpcomingShowtimes(ctx, ids, showtimesPerRecommendation)
+	if err != nil {
+		return nil, err
+	}
+
+	var available []recommend.Suggestion
+	for _, suggestion := range suggestions {
+		if len(showtimes[uint(suggestion.MovieID)]) > 0 {
+			available = append(available, suggestion)
+		}
+	}
+	total := len(available)
+	picked := available[min(cursor.Offset, total):min(cursor.Offset+params.Limit+1, total)]
+
+	ids = ids[:0]
+	for _, suggestion := range picked {
+		ids = append(ids, suggestion.MovieID)
+		if suggestion.SimilarTo != 0 {
+			ids = append(ids, suggestion.SimilarTo)
+		}
+	}
+
+	movies, err := s.movies.GetMoviesByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	localized := make([]*models.Movie, 0, len(movies))
+	for _, movie := range movies {
+		localized = append(localized, movie)
+	}
+	if err := s.translations.LocalizeMovies(ctx, locales, localized...); err != nil {
+		return nil, err
+	}
+
+	var recommendations []models.Recommendation
+	for _, suggestion := range picked {
+		movie, ok := movies[uint(suggestion.MovieID)]
+		if !ok {
+			continue
+		}
+		recommendation := models.Recommendation{
+			Movie:     *movie,
+			Score:     suggestion.Score,
+			Reason:    suggestion.Reason,
+			Showtimes: showtimes[uint(suggestion.MovieID)],
+		}
+		if similar, ok := movies[uint(suggestion.SimilarTo)]; ok {
+			recommendation.SimilarTo = similar.Title
+		}
+		recommendations = append(recommendations, recommendation)
+	}
+
+	next := recommendationCursor{Offset: cursor.Offset + params.Limit}
+	return pagination.NewPage(recommendations, params, total, func(models.Recommendation) string {
+		return pagination.EncodeCursor(next)
+	}), nil
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"movie-system/internal/models"
+	"movie-system/internal/pagination"
+	"movie-system/internal/repositories"
+	"movie-system/test"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecommendationService(t *testing.T) {
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+
+	service := NewRecommendationService(repositories.NewRecommendationRepository(db), repositories.NewMovieRepository(db),
+		repositories.NewShowtimeRepository(db), NewTranslationService(repositories.NewTranslationRepository(db), "en"))
+	ctx := context.Background()
+
+	err = test.ClearTestDB(db)
+	assert.NoError(t, err)
+
+	// Jane and John saw 1 and 2; Ann only saw 1 and Bob is new. 3 is
+	// showing but sold out. The seat counter of showtime 4 has drifted, but
+	// nobody booked it.
+	_, err = db.Exec(ctx, `
+		INSERT INTO users (id, username, password_hash, role) VALUES
+		(1, 'jane', '!', 'user'), (2, 'john', '!', 'user'), (3, 'ann', '!', 'user'), (4, 'bob', '!', 'user');
+		INSERT INTO movies (id, title, description, poster_image) VALUES
+		(1, 'Test Movie 1', 'Test Description 1', 'poster1.jpg'),
+		(2, 'Test Movie 2', 'Test Description 2', 'poster2.jpg'),
+		(3, 'Test Movie 3', 'Test Description 3', 'poster3.jpg');
+		INSERT INTO cinemas (id, name, timezone) VALUES (1, 'Main', 'UTC');
+		INSERT INTO showtimes (id, cinema_id, movie_id, start_time, capacity, reserved) VALUES
+		(1, 1, 1, NOW() - INTERVAL '1 day', 100, 3),
+		(2, 1, 2, NOW() - INTERVAL '1 day', 100, 2),
+		(3, 1, 1, NOW() + INTERVAL '1 day', 100, 0),
+		(4, 1, 2, NOW() + INTERVAL '1 day', 100, 100),
+		(5, 1, 3, NOW() + INTERVAL '1 day', 1, 1);
+		INSERT INTO reservations (user_id, movie_id, showtime_id, seats) VALUES
+		(1, 1, 1, ARRAY['A1']), (2, 1, 1, ARRAY['A2']), (3, 1, 1, ARRAY['A3']),
+		(1, 2, 2, ARRAY['A1']), (2, 2, 2, ARRAY['A2']), (4, 3, 5, ARRAY['A1'])`)
+	assert.NoError(t, err)
+
+	assert.NoError(t, service.Refresh(ctx))
+
+	t.Run("CoBooking", func(t *testing.T) {
+		page, err := service.ForUser(ctx, 3, nil, pagination.Params{Limit: 10})
+		if !assert.NoError(t, err) || !assert.Len(t, page.Items, 1) {
+			return
+		}
+		recommendations := page.Items
+		assert.Equal(t, uint(2), recommendations[0].Movie.ID)
+		assert.Equal(t, models.RecommendationReasonSimilar, recommendations[0].Reason)
+		assert.Equal(t, "Test Movie 1", recommendations[0].SimilarTo)
+		if assert.Len(t, recommendations[0].Showtimes, 1) {
+			assert.Equal(t, uint(4), recommendations[0].Showtimes[0].ID)
+		}
+	})
+
+	t.Run("PopularFallback", func(t *testing.T) {
+		_, err := db.Exec(ctx, "INSERT INTO users (id, username, password_hash, role) VALUES (5, 'eve', '!', 'user')")
+		assert.NoError(t, err)
+
+		page, err := service.ForUser(ctx, 5, nil, pagination.Params{Limit: 1})
+		if !assert.NoError(t, err) || !assert.Len(t, page.Items, 1) {
+			return
+		}
+		assert.Equal(t, uint(1), page.Items[0].Movie.ID, "the best seller with free seats")
+		assert.Equal(t, models.RecommendationReasonPopular, page.Items[0].Reason)
+		assert.Equal(t, 2, page.Total)
+		if !assert.NotNil(t, page.NextCursor) {
+			return
+		}
+
+		page, err = service.ForUser(ctx, 5, nil, pagination.Params{Limit: 1, Cursor: *page.NextCursor})
+		if assert.NoError(t, err) && assert.Len(t, page.Items, 1) {
+			assert.Equal(t, uint(2), page.Items[0].Movie.ID)
+			assert.Nil(t, page.NextCursor)
+		}
+	})
+
+	t.Run("BookedMoviesAreLeftOut", func(t *testing.T) {
+		page, err := service.ForUser(ctx, 1, nil, pagination.Params{Limit: 10})
+		if assert.NoError(t, err) {
+			assert.Empty(t, page.Items)
+			assert.Zero(t, page.Total)
+		}
+	})
+}
@@ -15,8 +15,8 @@ func NewReservationService(repo *repositories.ReservationRepository) *Reservatio
 	return &ReservationService{repo: repo}
 }
 
-func (s *ReservationService) GetReservationsPerMovie(movieID int) ([]models.MovieReservationCount, error) {
-	counts, err := s.repo.GetReservationsPerMovie(context.Background(), movieID)
+func (s *ReservationService) GetReservationsPerMovie(movieID, cinemaID int) ([]models.MovieReservationCount, error) {
+	counts, err := s.repo.GetReservationsPerMovie(context.Background(), movieID, cinemaID)
 	if err != nil {
 		return nil, fmt.Errorf("error getting reservations per movie: %v", err)
 	}
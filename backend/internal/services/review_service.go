@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie-system/internal/models"
+	"movie-system/internal/pagination"
+	"movie-system/internal/repositories"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxReviewLength = 4000
+	maxReasonLength = 500
+)
+
+var (
+	ErrInvalidReview = errors.New("invalid review")
+	// ErrReviewNotAllowed is returned when reviewing a movie the user has no
+	// past reservation for.
+	ErrReviewNotAllowed = errors.New("only customers who attended a showtime of the movie can review it")
+	// ErrNotReviewAuthor is returned when changing someone else's review.
+	ErrNotReviewAuthor = errors.New("review belongs to another user")
+)
+
+// ReviewService lets customers rate and review the movies they saw and
+// moderators hide or approve reviews other customers reported.
+type ReviewService struct {
+	repo *repositories.ReviewRepository
+}
+
+func NewReviewService(repo *repositories.ReviewRepository) *ReviewService {
+	return &ReviewService{repo: repo}
+}
+
+// Add publishes a review of review.MovieID by userID, who must hold a
+// reservation for a showtime of it that has already started.
+func (s *ReviewService) Add(ctx context.Context, userID int, review *models.Review) (*models.Review, error) {
+	var err error
+	if review.Body, err = validateReview(review.Rating, review.Body); err != nil {
+		return nil, err
+	}
+
+	attended, err := s.repo.HasAttended(ctx, userID, int(review.MovieID))
+	if err != nil {
+		return nil, err
+	}
+	if !attended {
+		return nil, ErrReviewNotAllowed
+	}
+
+	review.UserID = uint(userID)
+	if err := s.repo.InsertReview(ctx, review); err != nil {
+		return nil, err
+	}
+	return s.repo.GetReview(ctx, int(review.ID))
+}
+
+// Update replaces the rating and text of a review written by userID.
+func (s *ReviewService) Update(ctx context.Context, userID, id, rating int, body string) (*models.Review, error) {
+	review, err := s.repo.GetReview(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if int(review.UserID) != userID {
+		return nil, ErrNotReviewAuthor
+	}
+	if body, err = validateReview(rating, body); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateReview(ctx, id, rating, body); err != nil {
+		return nil, err
+	}
+	return s.repo.GetReview(ctx, id)
+}
+
+// Delete removes a review. Only its author or, with asAdmin, a moderator may
+// delete it.
+func (s *ReviewService) Delete(ctx context.Context, userID, id int, asAdmin bool) error {
+	review, err := s.repo.GetReview(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !asAdmin && int(review.UserID) != userID {
+		return ErrNotReviewAuthor
+	}
+	return s.repo.DeleteReview(ctx, id)
+}
+
+// Report flags a review of someone else for moderation.
+func (s *ReviewService) Report(ctx context.Context, userID, id int, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if utf8.RuneCountInString(reason) > maxReasonLength {
+		return fmt.Errorf("%w: reason must be at most %d characters", ErrInvalidReview, maxReasonLength)
+	}
+
+	review, err := s.repo.GetReview(ctx, id)
+	if err != nil {
+		return err
+	}
+	if int(review.UserID) == userID {
+		return fmt.Errorf("%w: cannot report your own review", ErrInvalidReview)
+	}
+	return s.repo.ReportReview(ctx, id, userID, reason)
+}
+
+// Hide takes a review out of listings and ratings.
+func (s *ReviewService) Hide(ctx context.Context, actor string, id int) (*models.Review, error) {
+	return s.moderate(ctx, actor, id, models.ReviewStatusHidden)
+}
+
+// Approve publishes a reported or hidden review and dismisses the reports
+// about it.
+func (s *ReviewService) Approve(ctx context.Context, actor string, id int) (*models.Review, error) {
+	return s.moderate(ctx, actor, id, models.ReviewStatusPublished)
+}
+
+func (s *ReviewService) moderate(ctx context.Context, actor string, id int, status string) (*models.Review, error) {
+	if err := s.repo.ModerateReview(ctx, id, status, actor); err != nil {
+		return nil, err
+	}
+	return s.repo.GetReview(ctx, id)
+}
+
+// ListForMovie returns a page of the visible reviews of a movie.
+func (s *ReviewService) ListForMovie(ctx context.Context, movieID int, params pagination.Params) (*pagination.Page[models.Review], error) {
+	return s.repo.ListMovieReviews(ctx, movieID, params)
+}
+
+// ListForModeration returns a page of the reviews in status, by default the
+// reported ones awaiting a moderator.
+func (s *ReviewService) ListForModeration(ctx context.Context, status string, params pagination.Params) (*pagination.Page[models.Review], error) {
+	if status == "" {
+		status = models.ReviewStatusReported
+	}
+	if !models.IsValidReviewStatus(status) {
+		return nil, fmt.Errorf("%w: unknown status %q", repositories.ErrInvalidFilter, status)
+	}
+	return s.repo.ListReviewsByStatus(ctx, status, params)
+}
+
+// ListByUser returns a page of the reviews written by a user, hidden ones
+// included.
+func (s *ReviewService) ListByUser(ctx context.Context, userID int, params pagination.Params) (*pagination.Page[models.Review], error) {
+	return s.repo.ListReviewsByUser(ctx, userID, params)
+}
+
+// validateReview checks a rating and returns the trimmed text of a review.
+func validateReview(rating int, body string) (string, error) {
+	if rating < 1 || rating > 5 {
+		return "", fmt.Errorf("%w: rating must be between 1 and 5", ErrInvalidReview)
+	}
+	body = strings.TrimSpace(body)
+	if utf8.RuneCountInString(body) > maxReviewLength {
+		return "", fmt.Errorf("%w: text must be at most %d characters", ErrInvalidReview, maxReviewLength)
+	}
+	return body, nil
+}
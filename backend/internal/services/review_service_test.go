@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"movie-system/internal/models"
+	"movie-system/internal/pagination"
+	"movie-system/internal/repositories"
+	"movie-system/test"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateReview(t *testing.T) {
+	body, err := validateReview(5, "  Loved it.  ")
+	assert.NoError(t, err)
+	assert.Equal(t, "Loved it.", body)
+
+	_, err = validateReview(3, "")
+	assert.NoError(t, err, "the text is optional")
+
+	for _, rating := range []int{0, 6, -1} {
+		_, err := validateReview(rating, "")
+		assert.ErrorIs(t, err, ErrInvalidReview)
+	}
+	_, err = validateReview(4, strings.Repeat("é", maxReviewLength+1))
+	assert.ErrorIs(t, err, ErrInvalidReview)
+}
+
+func TestReviewService(t *testing.T) {
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+
+	service := NewReviewService(repositories.NewReviewRepository(db))
+	movies := repositories.NewMovieRepository(db)
+	ctx := context.Background()
+
+	err = test.ClearTestDB(db)
+	assert.NoError(t, err)
+
+	_, err = db.Exec(ctx, `
+		INSERT INTO users (id, username, password_hash, role) VALUES
+		(1, 'jane', '!', 'user'), (2, 'john', '!', 'user');
+		INSERT INTO movies (id, title, description, poster_image) VALUES
+		(1, 'Test Movie 1', 'Test Description 1', 'poster1.jpg'),
+		(2, 'Test Movie 2', 'Test Description 2', 'poster2.jpg');
+		INSERT INTO cinemas (id, name, timezone) VALUES (1, 'Main', 'UTC');
+		INSERT INTO showtimes (id, cinema_id, movie_id, start_time, capacity, reserved) VALUES
+		(1, 1, 1, NOW() - INTERVAL '1 day', 100, 2),
+		(2, 1, 2, NOW() + INTERVAL '1 day', 100, 1);
+		INSERT INTO reservations (user_id, movie_id, showtime_id, seats) VALUES
+		(1, 1, 1, ARRAY['A1']), (2, 1, 1, ARRAY['A2']), (1, 2, 2, ARRAY['B1'])`)
+	assert.NoError(t, err)
+
+	var review *models.Review
+
+	t.Run("OnlyAttendeesMayReview", func(t *testing.T) {
+		_, err := service.Add(ctx, 1, &models.Review{MovieID: 2, Rating: 4})
+		assert.ErrorIs(t, err, ErrReviewNotAllowed, "the showtime has not started yet")
+
+		review, err = service.Add(ctx, 1, &models.Review{MovieID: 1, Rating: 4, Body: " Great. "})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "jane", review.Author)
+		assert.Equal(t, "Great.", review.Body)
+		assert.Equal(t, models.ReviewStatusPublished, review.Status)
+
+		_, err = service.Add(ctx, 1, &models.Review{MovieID: 1, Rating: 5})
+		assert.ErrorIs(t, err, repositories.ErrReviewExists)
+	})
+
+	t.Run("AuthorEditsAndDeletes", func(t *testing.T) {
+		if review == nil {
+			t.Skip("no review")
+		}
+		_, err := service.Update(ctx, 2, int(review.ID), 1, "")
+		assert.ErrorIs(t, err, ErrNotReviewAuthor)
+		assert.ErrorIs(t, service.Delete(ctx, 2, int(review.ID), false), ErrNotReviewAuthor)
+
+		updated, err := service.Update(ctx, 1, int(review.ID), 2, "Meh.")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, updated.Rating)
+	})
+
+	t.Run("ModerationAndRatings", func(t *testing.T) {
+		if review == nil {
+			t.Skip("no review")
+		}
+		other, err := service.Add(ctx, 2, &models.Review{MovieID: 1, Rating: 5})
+		assert.NoError(t, err)
+
+		movie, err := movies.GetMovieByID(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 3.5, movie.AverageRating)
+		assert.Equal(t, 2, movie.ReviewCount)
+
+		assert.ErrorIs(t, service.Report(ctx, 1, int(review.ID), "mine"), ErrInvalidReview)
+		assert.NoError(t, service.Report(ctx, 2, int(review.ID), "Spoilers"))
+		assert.NoError(t, service.Report(ctx, 2, int(review.ID), "Spoilers"), "reporting twice is a no-op")
+
+		queue, err := service.ListForModeration(ctx, "", pagination.Params{Limit: 10})
+		assert.NoError(t, err)
+		if assert.Len(t, queue.Items, 1) && assert.Len(t, queue.Items[0].Reports, 1) {
+			assert.Equal(t, "Spoilers", queue.Items[0].Reports[0].Reason)
+		}
+
+		hidden, err := service.Hide(ctx, "admin", int(review.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, models.ReviewStatusHidden, hidden.Status)
+		assert.Equal(t, "admin", hidden.ModeratedBy)
+
+		page, err := service.ListForMovie(ctx, 1, pagination.Params{Limit: 10})
+		assert.NoError(t, err)
+		if assert.Len(t, page.Items, 1) {
+			assert.Equal(t, other.ID, page.Items[0].ID)
+		}
+		movie, err = movies.GetMovieByID(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 5.0, movie.AverageRating)
+		assert.Equal(t, 1, movie.ReviewCount)
+
+		approved, err := service.Approve(ctx, "admin", int(review.ID))
+		assert.NoError(t, err)
+		assert.Equal(t, models.ReviewStatusPublished, approved.Status)
+		assert.Equal(t, 0, approved.ReportCount)
+
+		assert.NoError(t, service.Delete(ctx, 2, int(review.ID), true), "admins delete any review")
+		_, err = service.ListForModeration(ctx, "bogus", pagination.Params{Limit: 10})
+		assert.ErrorIs(t, err, repositories.ErrInvalidFilter)
+	})
+
+	t.Run("AttendanceFollowsTheShowtime", func(t *testing.T) {
+		// A reservation whose movie disagrees with its showtime attends the
+		// movie the showtime played.
+		_, err := db.Exec(ctx, `
+			INSERT INTO reservations (user_id, movie_id, showtime_id, seats)
+			VALUES (2, 2, 1, ARRAY['A3'])`)
+		assert.NoError(t, err)
+		_, err = service.Add(ctx, 2, &models.Review{MovieID: 2, Rating: 1})
+		assert.ErrorIs(t, err, ErrReviewNotAllowed)
+
+		reservations := repositories.NewReservationRepository(db)
+		reservation := &models.Reservation{UserID: 2, MovieID: 1, ShowtimeID: 2, Seats: []string{"B2"}}
+		if assert.NoError(t, reservations.ReserveSeat(ctx, reservation)) {
+			assert.Equal(t, uint(2), reservation.MovieID)
+		}
+
+		started := &models.Reservation{UserID: 2, MovieID: 1, ShowtimeID: 1, Seats: []string{"A4"}}
+		assert.ErrorIs(t, reservations.ReserveSeat(ctx, started), repositories.ErrShowtimeUnavailable)
+	})
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie-system/internal/models"
+	"movie-system/internal/pagination"
+	"movie-system/internal/repositories"
+	"movie-system/internal/rrule"
+	"movie-system/internal/site"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// maxScheduleShowtimes bounds the showtimes a single schedule yields.
+	maxScheduleShowtimes = 1000
+	maxScheduleTimes     = 12
+)
+
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// ScheduleService programs runs of showtimes from recurring schedules, such
+// as "daily at 14:00 and 19:30 from March 1 to 14, except Mondays":
+//
+//	{"start_date": "2025-03-01", "times": ["14:00", "19:30"],
+//	 "rrule": "FREQ=DAILY;UNTIL=20250314;BYDAY=TU,WE,TH,FR,SA,SU"}
+//
+// Dates and times are local to the cinema, so a showtime at 19:30 stays at
+// 19:30 when clocks change for daylight saving time.
+type ScheduleService struct {
+	repo *repositories.ScheduleRepository
+}
+
+func NewScheduleService(repo *repositories.ScheduleRepository) *ScheduleService {
+	return &ScheduleService{repo: repo}
+}
+
+// List returns the schedules of one cinema, or of all if cinemaID is 0.
+func (s *ScheduleService) List(ctx context.Context, cinemaID int, params pagination.Params) (*pagination.Page[models.Schedule], error) {
+	return s.repo.ListSchedules(ctx, cinemaID, params)
+}
+
+func (s *ScheduleService) Get(ctx context.Context, id int) (*models.Schedule, error) {
+	return s.repo.GetSchedule(ctx, id)
+}
+
+// Create stores a schedule and creates its upcoming showtimes. With dryRun
+// the report previews the showtimes without saving anything.
+func (s *ScheduleService) Create(ctx context.Context, schedule *models.Schedule, dryRun bool) (*models.ScheduleReport, error) {
+	starts, err := s.expand(ctx, schedule)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.CreateSchedule(ctx, schedule, starts, dryRun)
+}
+
+// Update changes a schedule and its remaining showtimes, leaving the ones
+// that have begun, are booked or were archived as they are.
+func (s *ScheduleService) Update(ctx context.Context, id int, schedule *models.Schedule, dryRun bool) (*models.ScheduleReport, error) {
+	starts, err := s.expand(ctx, schedule)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.UpdateSchedule(ctx, id, schedule, starts, dryRun)
+}
+
+// Cancel deletes the remaining showtimes of a schedule that are not booked.
+func (s *ScheduleService) Cancel(ctx context.Context, id int, dryRun bool) (*models.ScheduleReport, error) {
+	return s.repo.CancelSchedule(ctx, id, dryRun)
+}
+
+// expand places a schedule in its cinema and expands it in the time zone of
+// the cinema.
+func (s *ScheduleService) expand(ctx context.Context, schedule *models.Schedule) ([]time.Time, error) {
+	loc, err := s.repo.ScheduleZone(ctx, schedule)
+	if err != nil {
+		return nil, err
+	}
+	return expandSchedule(schedule, loc)
+}
+
+// expandSchedule validates and normalizes a schedule and returns the start
+// times of all its showtimes in loc, in order.
+func expandSchedule(schedule *models.Schedule, loc *time.Location) ([]time.Time, error) {
+	if schedule.MovieID == 0 {
+		return nil, fmt.Errorf("%w: movie_id is required", ErrInvalidSchedule)
+	}
+	if schedule.Capacity == 0 {
+		return nil, fmt.Errorf("%w: capacity must be positive", ErrInvalidSchedule)
+	}
+
+	start, err := time.Parse(time.DateOnly, schedule.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: start_date must be a date like 2025-03-01, got %q", ErrInvalidSchedule, schedule.StartDate)
+	}
+
+	var clocks []time.Time
+	times := []string{}
+	for _, value := range schedule.Times {
+		value = strings.TrimSpace(value)
+		clock, err := time.Parse("15:04", value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: times must be like 19:30, got %q", ErrInvalidSchedule, value)
+		}
+		value = clock.Format("15:04")
+		if !slices.Contains(times, value) {
+			times = append(times, value)
+			clocks = append(clocks, clock)
+		}
+	}
+	if len(times) == 0 || len(times) > maxScheduleTimes {
+		return nil, fmt.Errorf("%w: give from 1 to %d times", ErrInvalidSchedule, maxScheduleTimes)
+	}
+	slices.Sort(times)
+	slices.SortFunc(clocks, time.Time.Compare)
+	schedule.Times = times
+
+	excluded := make(map[time.Time]bool)
+	exdates := []string{}
+	for _, value := range schedule.ExDates {
+		exdate, err := time.Parse(time.DateOnly, strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%w: exdates must be dates like 2025-03-08, got %q", ErrInvalidSchedule, value)
+		}
+		if !excluded[exdate] {
+			excluded[exdate] = true
+			exdates = append(exdates, exdate.Format(time.DateOnly))
+		}
+	}
+	slices.Sort(exdates)
+	schedule.ExDates = exdates
+
+	schedule.RRule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(schedule.RRule)), "RRULE:")
+	rule, err := rrule.Parse(schedule.RRule)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSchedule, err)
+	}
+	dates, err := rule.Dates(start, maxScheduleShowtimes/len(clocks))
+	if errors.Is(err, rrule.ErrTooMany) {
+		return nil, fmt.Errorf("%w: the rule yields more than %d showtimes; limit it with COUNT or UNTIL", ErrInvalidSchedule, maxScheduleShowtimes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSchedule, err)
+	}
+
+	var starts []time.Time
+	for _, date := range dates {
+		if excluded[date] {
+			continue
+		}
+		for _, clock := range clocks {
+			starts = append(starts, site.At(loc, date, clock.Hour(), clock.Minute()))
+		}
+	}
+	if len(starts) == 0 {
+		return nil, fmt.Errorf("%w: the rule yields no showtimes", ErrInvalidSchedule)
+	}
+	return starts, nil
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"movie-system/internal/models"
+	"movie-system/internal/repositories"
+	"movie-system/internal/site"
+	"movie-system/test"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandSchedule(t *testing.T) {
+	// Daily at 14:00 and 19:30 from March 1 to 14, except Mondays and the 8th
+	schedule := &models.Schedule{
+		MovieID:   1,
+		Capacity:  100,
+		StartDate: "2025-03-01",
+		Times:     []string{"19:30", " 14:00", "14:00"},
+		RRule:     "rrule:FREQ=DAILY;UNTIL=20250314;BYDAY=TU,WE,TH,FR,SA,SU",
+		ExDates:   []string{"2025-03-08"},
+	}
+	starts, err := expandSchedule(schedule, time.UTC)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"14:00", "19:30"}, schedule.Times)
+	assert.Equal(t, "FREQ=DAILY;UNTIL=20250314;BYDAY=TU,WE,TH,FR,SA,SU", schedule.RRule)
+	assert.Len(t, starts, 2*(14-2-1))
+	assert.Equal(t, time.Date(2025, 3, 1, 14, 0, 0, 0, time.UTC), starts[0])
+	assert.Equal(t, time.Date(2025, 3, 1, 19, 30, 0, 0, time.UTC), starts[1])
+	assert.Equal(t, time.Date(2025, 3, 14, 19, 30, 0, 0, time.UTC), starts[len(starts)-1])
+	for _, start := range starts {
+		assert.NotEqual(t, time.Monday, start.Weekday())
+		assert.NotEqual(t, 8, start.Day())
+	}
+
+	for name, schedule := range map[string]models.Schedule{
+		"no movie":     {Capacity: 1, StartDate: "2025-03-01", Times: []string{"14:00"}, RRule: "FREQ=DAILY;COUNT=1"},
+		"no capacity":  {MovieID: 1, StartDate: "2025-03-01", Times: []string{"14:00"}, RRule: "FREQ=DAILY;COUNT=1"},
+		"bad date":     {MovieID: 1, Capacity: 1, StartDate: "01.03.2025", Times: []string{"14:00"}, RRule: "FREQ=DAILY;COUNT=1"},
+		"no times":     {MovieID: 1, Capacity: 1, StartDate: "2025-03-01", RRule: "FREQ=DAILY;COUNT=1"},
+		"bad time":     {MovieID: 1, Capacity: 1, StartDate: "2025-03-01", Times: []string{"25:00"}, RRule: "FREQ=DAILY;COUNT=1"},
+		"bad rule":     {MovieID: 1, Capacity: 1, StartDate: "2025-03-01", Times: []string{"14:00"}, RRule: "FREQ=HOURLY"},
+		"unbounded":    {MovieID: 1, Capacity: 1, StartDate: "2025-03-01", Times: []string{"14:00"}, RRule: "FREQ=DAILY"},
+		"all excluded": {MovieID: 1, Capacity: 1, StartDate: "2025-03-01", Times: []string{"14:00"}, RRule: "FREQ=DAILY;COUNT=1", ExDates: []string{"2025-03-01"}},
+	} {
+		_, err := expandSchedule(&schedule, time.UTC)
+		assert.ErrorIs(t, err, ErrInvalidSchedule, name)
+	}
+}
+
+func TestExpandScheduleDST(t *testing.T) {
+	berlin, err := site.Zone("Europe/Berlin")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Clocks go forward on March 30 and back on October 26, 2025; the evening
+	// show stays at 19:30 local time while its UTC time moves
+	for _, tc := range []struct {
+		start string
+		utc   []int
+		night time.Duration
+	}{
+		{"2025-03-29", []int{18, 17, 17}, 23 * time.Hour},
+		{"2025-10-25", []int{17, 18, 18}, 25 * time.Hour},
+	} {
+		starts, err := expandSchedule(&models.Schedule{
+			MovieID: 1, Capacity: 1, StartDate: tc.start, Times: []string{"19:30"}, RRule: "FREQ=DAILY;COUNT=3",
+		}, berlin)
+		if !assert.NoError(t, err) || !assert.Len(t, starts, 3) {
+			return
+		}
+		for i, start := range starts {
+			assert.Equal(t, "19:30", start.Format("15:04"), tc.start)
+			assert.Equal(t, tc.utc[i], start.UTC().Hour(), tc.start)
+		}
+		assert.Equal(t, tc.night, starts[1].Sub(starts[0]), tc.start)
+		assert.Equal(t, 24*time.Hour, starts[2].Sub(starts[1]), tc.start)
+	}
+}
+
+func TestScheduleService(t *testing.T) {
+	db, err := test.SetupTestDB()
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer db.Close()
+
+	service := NewScheduleService(repositories.NewScheduleRepository(db))
+	ctx := context.Background()
+
+	err = test.ClearTestDB(db)
+	assert.NoError(t, err)
+
+	_, err = db.Exec(ctx, `
+		INSERT INTO users (id, username, password_hash, role) VALUES (1, 'jane', '!', 'user');
+		INSERT INTO movies (id, title, description, poster_image, runtime_minutes) VALUES
+		(1, 'Test Movie 1', 'Test Description 1', 'poster1.jpg', 90);
+		INSERT INTO cinemas (id, name, timezone) VALUES (1, 'Main', 'UTC');
+		INSERT INTO auditoriums (id, cinema_id, name, ad_minutes, cleaning_minutes) VALUES (1, 1, 'Hall 1', 15, 15)`)
+	assert.NoError(t, err)
+
+	// Three days from tomorrow at 14:00 and 19:30
+	schedule := models.Schedule{
+		MovieID:      1,
+		AuditoriumID: 1,
+		Capacity:     50,
+		StartDate:    time.Now().AddDate(0, 0, 1).Format(time.DateOnly),
+		Times:        []string{"14:00", "19:30"},
+		RRule:        "FREQ=DAILY;COUNT=3",
+	}
+
+	report, err := service.Create(ctx, &schedule, true)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.False(t, report.Committed, "a preview saves nothing")
+	assert.Equal(t, 6, report.Created)
+	assert.Zero(t, report.Schedule.ID)
+
+	report, err = service.Create(ctx, &schedule, false)
+	if !assert.NoError(t, err) || !assert.True(t, report.Committed) {
+		return
+	}
+	assert.Equal(t, 6, report.Created)
+	id := int(report.Schedule.ID)
+
+	// The same run again would take the hall twice
+	clash, err := service.Create(ctx, &schedule, false)
+	if assert.NoError(t, err) {
+		assert.False(t, clash.Committed)
+		assert.Equal(t, 6, clash.Failed)
+		assert.Contains(t, clash.Occurrences[0].Error, "Hall 1")
+	}
+
+	// A booked evening showtime survives dropping the evening slot
+	booked := report.Occurrences[1]
+	_, err = db.Exec(ctx, "INSERT INTO reservations (user_id, movie_id, showtime_id, seats) VALUES (1, 1, $1, ARRAY['A1'])", booked.ShowtimeID)
+	assert.NoError(t, err)
+
+	schedule.Times = []string{"14:00"}
+	schedule.Capacity = 80
+	report, err = service.Update(ctx, id, &schedule, false)
+	if assert.NoError(t, err) {
+		assert.True(t, report.Committed)
+		assert.Equal(t, 3, report.Updated, "the matinees are kept under their ids")
+		assert.Equal(t, 2, report.Deleted)
+		assert.Equal(t, 1, report.Kept)
+	}
+
+	saved, err := service.Get(ctx, id)
+	if assert.NoError(t, err) && assert.Len(t, saved.Showtimes, 4) {
+		assert.Equal(t, uint(80), saved.Showtimes[0].Capacity)
+		assert.Equal(t, booked.ShowtimeID, saved.Showtimes[1].ID)
+		assert.Equal(t, uint(50), saved.Showtimes[1].Capacity, "booked showtimes are not changed")
+	}
+
+	report, err = service.Cancel(ctx, id, false)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, report.Deleted)
+		assert.Equal(t, 1, report.Kept)
+		assert.NotNil(t, report.Schedule.CancelledAt)
+	}
+	_, err = service.Update(ctx, id, &schedule, false)
+	assert.ErrorIs(t, err, repositories.ErrScheduleCancelled)
+	_, err = service.Cancel(ctx, id+1000, false)
+	assert.ErrorIs(t, err, repositories.ErrScheduleNotFound)
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie-system/internal/locale"
+	"movie-system/internal/models"
+	"movie-system/internal/pagination"
+	"movie-system/internal/repositories"
+	"strings"
+)
+
+var ErrInvalidTranslation = errors.New("invalid translation")
+
+// TranslationService serves movies and genres in the language a client asks
+// for. The movies and genres tables hold the content in the default locale;
+// translations into other locales are stored beside them and fall back to
+// it.
+type TranslationService struct {
+	repo          *repositories.TranslationRepository
+	defaultLocale string
+}
+
+func NewTranslationService(repo *repositories.TranslationRepository, defaultLocale string) *TranslationService {
+	return &TranslationService{repo: repo, defaultLocale: defaultLocale}
+}
+
+func (s *TranslationService) DefaultLocale() string {
+	return s.defaultLocale
+}
+
+// Negotiate returns the locales to look for translations in, most preferred
+// first. An explicit locale, from the locale query parameter, takes
+// precedence over the Accept-Language header. The result is empty when the
+// default locale is wanted.
+func (s *TranslationService) Negotiate(acceptLanguage, explicit string) ([]string, error) {
+	preferred := locale.Parse(acceptLanguage)
+	if explicit != "" {
+		if _, err := locale.Normalize(explicit); err != nil {
+			return nil, err
+		}
+		preferred = locale.Parse(explicit)
+	}
+	return locale.Candidates(preferred, s.defaultLocale), nil
+}
+
+// LocalizeMovies replaces the title, description and genre names of movies
+// with their translation into the first of locales there is one for.
+func (s *TranslationService) LocalizeMovies(ctx context.Context, locales []string, movies ...*models.Movie) error {
+	var genres []*models.Genre
+	for _, movie := range movies {
+		movie.Locale = s.defaultLocale
+		for i := range movie.Genres {
+			genres = append(genres, &movie.Genres[i])
+		}
+	}
+	if len(locales) == 0 || len(movies) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(movies))
+	for i, movie := range movies {
+		ids[i] = int(movie.ID)
+	}
+	translations, err := s.repo.PreferredMovieTranslations(ctx, ids, locales)
+	if err != nil {
+		return err
+	}
+	for _, movie := range movies {
+		translation, ok := translations[movie.ID]
+		if !ok {
+			continue
+		}
+		movie.Locale = translation.Locale
+		movie.Title = translation.Title
+		if translation.Description != "" {
+			movie.Description = translation.Description
+		}
+	}
+	return s.LocalizeGenres(ctx, locales, genres...)
+}
+
+// LocalizeGenres replaces the names of genres with their translation into
+// the first of locales there is one for. Slugs are never translated.
+func (s *TranslationService) LocalizeGenres(ctx context.Context, locales []string, genres ...*models.Genre) error {
+	if len(locales) == 0 || len(genres) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(genres))
+	for i, genre := range genres {
+		ids[i] = int(genre.ID)
+	}
+	translations, err := s.repo.PreferredGenreTranslations(ctx, ids, locales)
+	if err != nil {
+		return err
+	}
+	for _, genre := range genres {
+		if translation, ok := translations[genre.ID]; ok {
+			genre.Name = translation.Name
+		}
+	}
+	return nil
+}
+
+// SplitTranslation takes the title and description out of an update of a
+// movie that was shown in another locale than the default one, so that they
+// update the translation they came from rather than the movie. It returns
+// nil when movie.Locale is empty or the default locale; otherwise the title
+// and description of movie are reset to those of current.
+func (s *TranslationService) SplitTranslation(movie, current *models.Movie) (*models.MovieTranslation, error) {
+	if movie.Locale == "" {
+		return nil, nil
+	}
+	tag, err := locale.Normalize(movie.Locale)
+	if err != nil {
+		return nil, err
+	}
+	if tag == s.defaultLocale {
+		return nil, nil
+	}
+
+	translation := &models.MovieTranslation{Locale: tag, Title: movie.Title, Description: movie.Description}
+	movie.Title, movie.Description = current.Title, current.Description
+	return translation, nil
+}
+
+func (s *TranslationService) ListMovieTranslations(ctx context.Context, movieID int, params pagination.Params) (*pagination.Page[models.MovieTranslation], error) {
+	return s.repo.ListMovieTranslations(ctx, movieID, params)
+}
+
+// SetMovieTranslation creates or replaces a translation of a movie and
+// returns the one it replaced, if any.
+func (s *TranslationService) SetMovieTranslation(ctx context.Context, movieID int, translation *models.MovieTranslation) (*models.MovieTranslation, error) {
+	var err error
+	if translation.Locale, err = s.checkLocale(translation.Locale); err != nil {
+		return nil, err
+	}
+	translation.Title = strings.TrimSpace(translation.Title)
+	if translation.Title == "" {
+		return nil, fmt.Errorf("%w: title is required", ErrInvalidTranslation)
+	}
+
+	before, err := s.repo.GetMovieTranslation(ctx, movieID, translation.Locale)
+	if err != nil && !errors.Is(err, repositories.ErrTranslationNotFound) {
+		return nil, err
+	}
+	if err := s.repo.SetMovieTranslation(ctx, movieID, translation); err != nil {
+		return nil, err
+	}
+	return before, nil
+}
+
+// DeleteMovieTranslation removes a translation of a movie and returns it.
+func (s *TranslationService) DeleteMovieTranslation(ctx context.Context, movieID int, tag string) (*models.MovieTranslation, error) {
+	tag, err := locale.Normalize(tag)
+	if err != nil {
+		return nil, err
+	}
+	translation, err := s.repo.GetMovieTranslation(ctx, movieID, tag)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.DeleteMovieTranslation(ctx, movieID, tag); err != nil {
+		return nil, err
+	}
+	return translation, nil
+}
+
+func (s *TranslationService) ListGenreTranslations(ctx context.Context, genreID int, params pagination.Params) (*pagination.Page[models.GenreTranslation], error) {
+	return s.repo.ListGenreTranslations(ctx, genreID, params)
+}
+
+// SetGenreTranslation creates or replaces a translation of a genre and
+// returns the one it replaced, if any.
+func (s *TranslationService) SetGenreTranslation(ctx context.Context, genreID int, translation *models.GenreTranslation) (*models.GenreTranslation, error) {
+	var err error
+	if translation.Locale, err = s.checkLocale(translation.Locale); err != nil {
+		return nil, err
+	}
+	translation.Name = strings.TrimSpace(translation.Name)
+	if translation.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidTranslation)
+	}
+
+	before, err := s.repo.GetGenreTranslation(ctx, genreID, translation.Locale)
+	if err != nil && !errors.Is(err, repositories.ErrTranslationNotFound) {
+		return nil, err
+	}
+	if err := s.repo.SetGenreTranslation(ctx, genreID, translation); err != nil {
+		return nil, err
+	}
+	return before, nil
+}
+
+// DeleteGenreTranslation removes a translation of a genre and returns it.
+func (s *TranslationService) DeleteGenreTranslation(ctx context.Context, genreID int, tag string) (*models.GenreTranslation, error) {
+	tag, err := locale.Normalize(tag)
+	if err != nil {
+		return nil, err
+	}
+	translation, err := s.repo.GetGenreTranslation(ctx, genreID, tag)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.DeleteGenreTranslation(ctx, genreID, tag); err != nil {
+		return nil, err
+	}
+	return translation, nil
+}
+
+// checkLocale normalizes the locale of a new translation. The default locale
+// is the movie or genre itself and cannot be translated into.
+func (s *TranslationService) checkLocale(tag string) (string, error) {
+	tag, err := locale.Normalize(tag)
+	if err != nil {
+		return "", err
+	}
+	if tag == s.defaultLocale {
+		return "", fmt.Errorf("%w: %s is the default locale; edit the original instead", ErrInvalidTranslation, tag)
+	}
+	return tag, nil
+}
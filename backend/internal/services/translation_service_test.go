@@ -0,0 +1,52 @@
+package services
+
+import (
+	"movie-system/internal/locale"
+	"movie-system/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateLocales(t *testing.T) {
+	s := NewTranslationService(nil, "en")
+
+	locales, err := s.Negotiate("ru-RU,ru;q=0.9,en-US;q=0.8,en;q=0.7", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ru-ru", "ru", "en-us"}, locales)
+
+	locales, err = s.Negotiate("en, ru", "")
+	assert.NoError(t, err)
+	assert.Empty(t, locales, "the default locale ranks above the translation")
+
+	locales, err = s.Negotiate("ru", "kk-KZ")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"kk-kz", "kk"}, locales, "the locale parameter wins over the header")
+
+	_, err = s.Negotiate("ru", "*")
+	assert.ErrorIs(t, err, locale.ErrInvalidLocale)
+}
+
+func TestSplitTranslation(t *testing.T) {
+	s := NewTranslationService(nil, "en")
+	current := &models.Movie{Title: "Inception", Description: "A thief."}
+
+	movie := &models.Movie{Title: "Начало", Description: "Вор.", RuntimeMinutes: 148, Locale: "RU"}
+	translation, err := s.SplitTranslation(movie, current)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.MovieTranslation{Locale: "ru", Title: "Начало", Description: "Вор."}, translation)
+	assert.Equal(t, "Inception", movie.Title)
+	assert.Equal(t, "A thief.", movie.Description)
+	assert.Equal(t, 148, movie.RuntimeMinutes)
+
+	for _, tag := range []string{"", "en"} {
+		movie := &models.Movie{Title: "Inception (2010)", Locale: tag}
+		translation, err := s.SplitTranslation(movie, current)
+		assert.NoError(t, err)
+		assert.Nil(t, translation)
+		assert.Equal(t, "Inception (2010)", movie.Title)
+	}
+
+	_, err = s.SplitTranslation(&models.Movie{Locale: "not a locale"}, current)
+	assert.ErrorIs(t, err, locale.ErrInvalidLocale)
+}
@@ -0,0 +1,65 @@
+// Package site handles the time zones of the cinemas. Showtimes are stored as
+// instants and shown in the local time of their cinema, so that start times
+// and schedules follow daylight saving time wherever the server runs.
+package site
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	// Zones are embedded so that they load in containers without tzdata.
+	_ "time/tzdata"
+)
+
+var (
+	location = time.UTC
+	zones    sync.Map
+)
+
+// Load sets the default time zone from an IANA name such as
+// "Europe/Berlin". It is the zone of new cinemas that do not name one and
+// of dates in database queries. The zone is UTC until Load is called.
+func Load(name string) error {
+	loc, err := Zone(name)
+	if err != nil {
+		return err
+	}
+	location = loc
+	return nil
+}
+
+// Location returns the default time zone.
+func Location() *time.Location {
+	return location
+}
+
+// Zone returns the time zone with an IANA name. Zones are loaded once.
+func Zone(name string) (*time.Location, error) {
+	if loc, ok := zones.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "" || name == "Local" {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	zones.Store(name, loc)
+	return loc, nil
+}
+
+// Local returns t in the time zone with name, or in the default zone if
+// there is no such zone.
+func Local(t time.Time, name string) time.Time {
+	loc, err := Zone(name)
+	if err != nil {
+		loc = location
+	}
+	return t.In(loc)
+}
+
+// At returns the instant the clocks in loc show hour:minute on the day of
+// date. A time skipped when clocks go forward is moved forward by the gap,
+// and a time that occurs twice when clocks go back is the later one.
+func At(loc *time.Location, date time.Time, hour, minute int) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, loc)
+}
@@ -0,0 +1,52 @@
+package site
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAt(t *testing.T) {
+	berlin, err := Zone("Europe/Berlin")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Clocks go forward at 02:00 on March 30, 2025 and back at 03:00 on
+	// October 26
+	for _, tc := range []struct {
+		name string
+		date time.Time
+		hour int
+		want time.Time
+	}{
+		{"winter", time.Date(2025, 3, 29, 0, 0, 0, 0, time.UTC), 19, time.Date(2025, 3, 29, 18, 0, 0, 0, time.UTC)},
+		{"summer", time.Date(2025, 3, 30, 0, 0, 0, 0, time.UTC), 19, time.Date(2025, 3, 30, 17, 0, 0, 0, time.UTC)},
+		{"skipped", time.Date(2025, 3, 30, 0, 0, 0, 0, time.UTC), 2, time.Date(2025, 3, 30, 1, 0, 0, 0, time.UTC)},
+		{"repeated", time.Date(2025, 10, 26, 0, 0, 0, 0, time.UTC), 2, time.Date(2025, 10, 26, 1, 0, 0, 0, time.UTC)},
+		{"winter again", time.Date(2025, 10, 26, 0, 0, 0, 0, time.UTC), 19, time.Date(2025, 10, 26, 18, 0, 0, 0, time.UTC)},
+	} {
+		got := At(berlin, tc.date, tc.hour, 0)
+		assert.True(t, tc.want.Equal(got), "%s: want %s, got %s", tc.name, tc.want, got.UTC())
+		assert.Equal(t, berlin, got.Location(), tc.name)
+	}
+}
+
+func TestLocal(t *testing.T) {
+	summer := time.Date(2025, 3, 30, 17, 0, 0, 0, time.UTC)
+	assert.Equal(t, "2025-03-30T19:00:00+02:00", Local(summer, "Europe/Berlin").Format(time.RFC3339))
+	assert.Equal(t, "2025-03-30T13:00:00-04:00", Local(summer, "America/New_York").Format(time.RFC3339))
+	assert.Equal(t, "2025-03-30T17:00:00Z", Local(summer, "").Format(time.RFC3339), "unknown zones fall back to the default")
+
+	if !assert.NoError(t, Load("Asia/Tokyo")) {
+		return
+	}
+	defer func() { location = time.UTC }()
+	assert.Equal(t, "2025-03-31T02:00:00+09:00", Local(summer, "Mars/Olympus_Mons").Format(time.RFC3339))
+
+	assert.Error(t, Load("Mars/Olympus_Mons"))
+	assert.Equal(t, "Asia/Tokyo", Location().String(), "a failed load keeps the zone")
+	_, err := Zone("Local")
+	assert.Error(t, err, "the zone of the server is not a cinema zone")
+}
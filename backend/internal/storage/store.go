@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrNotFound is returned by Get when nothing is stored under the key.
+var ErrNotFound = errors.New("blob not found")
+
+// ErrInvalidKey is returned for keys that are empty, absolute or contain
+// ".." segments.
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// Blob is the content of a stored file. The caller has to close it.
+type Blob struct {
+	io.ReadCloser
+	ContentType string
+	Size        int64
+}
+
+// BlobStore keeps uploaded files under slash-separated keys such as
+// "movies/1/abc/original.jpg". Implementations must be safe for concurrent
+// use.
+type BlobStore interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	Get(ctx context.Context, key string) (*Blob, error)
+	// Delete removes the blob under key. Deleting a missing blob is not an
+	// error.
+	Delete(ctx context.Context, key string) error
+}
+
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfcSecret is the SHA1 seed from RFC 6238 Appendix B.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeAt(t *testing.T) {
+	// Test vectors from RFC 6238, truncated to six digits.
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for unix, want := range vectors {
+		code, err := CodeAt(rfcSecret, Step(time.Unix(unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, want, code, "time %d", unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+
+	step, ok := Validate(rfcSecret, "005924", now)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	_, ok = Validate(rfcSecret, "005924", now.Add(Period))
+	assert.True(t, ok, "previous code is accepted within the skew")
+
+	_, ok = Validate(rfcSecret, "005924", now.Add(3*Period))
+	assert.False(t, ok)
+
+	_, ok = Validate(rfcSecret, "123456", now)
+	assert.False(t, ok)
+
+	_, ok = Validate(rfcSecret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestGenerateSecretAndURI(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	uri := URI("Movie System", "admin", secret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Movie%20System:admin?"))
+	assert.Contains(t, uri, "secret="+secret)
+	assert.Contains(t, uri, "issuer=Movie+System")
+}
@@ -6,10 +6,12 @@ import (
 	"log"
 	"movie-system/config"
 	"movie-system/internal/handlers"
+	"movie-system/internal/locale"
 	"movie-system/internal/middleware"
 	"movie-system/internal/repositories"
 	"movie-system/internal/seed"
 	"movie-system/internal/services"
+	"movie-system/internal/site"
 	"movie-system/routes"
 	"net/http"
 	"os"
@@ -23,6 +25,13 @@ func main() {
 		log.Fatal("SECRET_KEY is not set in the environment")
 	}
 
+	// Showtimes are shown and scheduled in the local time of the cinema
+	if value := os.Getenv("SITE_TIMEZONE"); value != "" {
+		if err := site.Load(value); err != nil {
+			log.Fatalf("SITE_TIMEZONE is not a valid time zone: %v", err)
+		}
+	}
+
 	var err error
 	config.DB, err = config.InitDB()
 	if err != nil {
@@ -38,22 +47,91 @@ func main() {
 		log.Fatalf("failed to seed database: %v", err)
 	}
 
-	movieRepo := repositories.NewMovieRepository(config.DB)
-	movieHandler := handlers.NewMovieHandler(movieRepo)
+	appURL := os.Getenv("APP_URL")
+	if appURL == "" {
+		appURL = "http://localhost:5173"
+	}
 
+	publicURL := os.Getenv("PUBLIC_URL")
+	if publicURL == "" {
+		publicURL = "http://localhost:8080"
+	}
+
+	// The language of the movies and genres themselves; other languages are
+	// translations
+	defaultLocale := "en"
+	if value := os.Getenv("DEFAULT_LOCALE"); value != "" {
+		if defaultLocale, err = locale.Normalize(value); err != nil {
+			log.Fatalf("DEFAULT_LOCALE is not a valid locale: %v", err)
+		}
+	}
+
+	movieRepo := repositories.NewMovieRepository(config.DB)
 	showtimeRepo := repositories.NewShowtimeRepository(config.DB)
-	showtimeHandler := handlers.NewShowtimeHandler(showtimeRepo)
+	genreRepo := repositories.NewGenreRepository(config.DB)
+	personRepo := repositories.NewPersonRepository(config.DB)
+	auditService := services.NewAuditService(repositories.NewAuditRepository(config.DB), movieRepo, showtimeRepo, genreRepo, personRepo)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	translationService := services.NewTranslationService(repositories.NewTranslationRepository(config.DB), defaultLocale)
+	translationHandler := handlers.NewTranslationHandler(translationService, auditService)
+
+	mediaService := services.NewMediaService(repositories.NewMediaRepository(config.DB), config.InitBlobStore(), publicURL)
+	mediaHandler := handlers.NewMediaHandler(mediaService, auditService)
+
+	movieHandler := handlers.NewMovieHandler(movieRepo, mediaService, auditService, translationService)
+	personHandler := handlers.NewPersonHandler(personRepo, auditService)
+	genreHandler := handlers.NewGenreHandler(genreRepo, auditService, translationService)
+	showtimeHandler := handlers.NewShowtimeHandler(showtimeRepo, auditService)
+	auditoriumHandler := handlers.NewAuditoriumHandler(repositories.NewAuditoriumRepository(config.DB), auditService)
+	cinemaHandler := handlers.NewCinemaHandler(repositories.NewCinemaRepository(config.DB), auditService)
+	scheduleHandler := handlers.NewScheduleHandler(services.NewScheduleService(repositories.NewScheduleRepository(config.DB)), auditService)
+	importHandler := handlers.NewImportHandler(services.NewImportService(movieRepo, showtimeRepo, genreRepo, auditService))
 
 	userRepo := repositories.NewUserRepository(config.DB)
-	authService := services.NewAuthService(userRepo, jwtSecret)
+	mfaRepo := repositories.NewMFARepository(config.DB)
+	mfaService := services.NewMFAService(mfaRepo, "Movie System")
+	loginAttemptRepo := repositories.NewLoginAttemptRepository(config.DB)
+	loginGuard := services.NewLoginGuard(loginAttemptRepo)
+	authService := services.NewAuthService(userRepo, mfaService, loginGuard, jwtSecret)
 
-	authHandler := handlers.NewAuthHandler(authService)
+	userTokenRepo := repositories.NewUserTokenRepository(config.DB)
+	accountService := services.NewAccountService(userRepo, userTokenRepo, config.InitMailer(), appURL)
+	privacyService := services.NewPrivacyService(userRepo, repositories.NewPrivacyRepository(config.DB))
+
+	authHandler := handlers.NewAuthHandler(authService, accountService)
+	accountHandler := handlers.NewAccountHandler(accountService, userRepo)
+	profileHandler := handlers.NewProfileHandler(accountService, privacyService, userRepo)
+	mfaHandler := handlers.NewMFAHandler(mfaService, authService, userRepo)
+	userHandler := handlers.NewUserHandler(userRepo, loginAttemptRepo, authService, privacyService)
 
 	reservationRepo := repositories.NewReservationRepository(config.DB)
 	reservationService := services.NewReservationService(reservationRepo)
 	reservationHandler := handlers.NewReservationHandler(reservationRepo, authService, reservationService)
+	reviewHandler := handlers.NewReviewHandler(services.NewReviewService(repositories.NewReviewRepository(config.DB)), userRepo)
+
+	// The recommendation job recomputes the model from booking history;
+	// RECOMMENDATIONS_INTERVAL=0 leaves it to cmd/compute-recommendations
+	recommendationInterval := time.Hour
+	if value := os.Getenv("RECOMMENDATIONS_INTERVAL"); value != "" {
+		if recommendationInterval, err = time.ParseDuration(value); err != nil || recommendationInterval < 0 {
+			log.Fatalf("RECOMMENDATIONS_INTERVAL is not a valid duration: %q", value)
+		}
+	}
+	recommendationService := services.NewRecommendationService(repositories.NewRecommendationRepository(config.DB), movieRepo, showtimeRepo, translationService)
+	recommendationHandler := handlers.NewRecommendationHandler(recommendationService, translationService, userRepo)
+	if recommendationInterval > 0 {
+		go recommendationService.Run(context.Background(), recommendationInterval)
+	}
+
+	identityRepo := repositories.NewIdentityRepository(config.DB)
+	oidcService := services.NewOIDCService(config.LoadOIDCProviders(publicURL), identityRepo, userRepo, authService)
+	oidcHandler := handlers.NewOIDCHandler(oidcService, appURL)
+
+	apiKeyRepo := repositories.NewAPIKeyRepository(config.DB)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
 
-	routes.SetupRoutes(movieHandler, showtimeHandler, authHandler, reservationHandler)
+	routes.SetupRoutes(authService, apiKeyService, movieHandler, showtimeHandler, authHandler, reservationHandler, userHandler, accountHandler, mfaHandler, apiKeyHandler, oidcHandler, profileHandler, personHandler, genreHandler, mediaHandler, auditHandler, importHandler, translationHandler, reviewHandler, recommendationHandler, auditoriumHandler, scheduleHandler, cinemaHandler)
 
 	corsHandler := middleware.CORS(http.DefaultServeMux.ServeHTTP)
 
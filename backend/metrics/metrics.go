@@ -15,10 +15,19 @@ var (
 		},
 		[]string{"method", "path"},
 	)
+
+	APIKeyRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "api_key_requests_total",
+			Help: "Total number of requests authenticated with an API key",
+		},
+		[]string{"key", "path", "result"},
+	)
 )
 
 func init() {
 	prometheus.MustRegister(RequestsTotal)
+	prometheus.MustRegister(APIKeyRequestsTotal)
 }
 
 func RequestCounter(next http.Handler) http.Handler {
@@ -3,33 +3,144 @@ package routes
 import (
 	"movie-system/internal/auth"
 	"movie-system/internal/handlers"
+	"movie-system/internal/models"
 	"movie-system/metrics"
 	"net/http"
 )
 
-func SetupRoutes(mh *handlers.MovieHandler, sh *handlers.ShowtimeHandler, ah *handlers.AuthHandler, rh *handlers.ReservationHandler) {
+func SetupRoutes(validator auth.ClaimsValidator, keys auth.APIKeyAuthenticator, mh *handlers.MovieHandler, sh *handlers.ShowtimeHandler, ah *handlers.AuthHandler, rh *handlers.ReservationHandler, uh *handlers.UserHandler, ach *handlers.AccountHandler, mfh *handlers.MFAHandler, akh *handlers.APIKeyHandler, oh *handlers.OIDCHandler, ph *handlers.ProfileHandler, peh *handlers.PersonHandler, gh *handlers.GenreHandler, mdh *handlers.MediaHandler, auh *handlers.AuditHandler, ih *handlers.ImportHandler, th *handlers.TranslationHandler, rvh *handlers.ReviewHandler, rch *handlers.RecommendationHandler, adh *handlers.AuditoriumHandler, sch *handlers.ScheduleHandler, cnh *handlers.CinemaHandler) {
 	// Middleware chain function
 	middleware := func(role string, handlerFunc http.HandlerFunc) http.Handler {
-		return metrics.RequestCounter(auth.RoleMiddleware(role, handlerFunc))
+		return metrics.RequestCounter(auth.RoleMiddleware(validator, role, handlerFunc))
+	}
+
+	// Same as middleware, but machine clients may use an API key with the
+	// given scope instead of a Bearer token
+	limiter := auth.NewRateLimiter()
+	apiKeyMiddleware := func(scope, role string, handlerFunc http.HandlerFunc) http.Handler {
+		return metrics.RequestCounter(auth.APIKeyMiddleware(keys, limiter, scope,
+			auth.RoleMiddleware(validator, role, handlerFunc), handlerFunc))
 	}
 
 	// Movie routes
-	http.Handle("/movies", middleware("user", http.HandlerFunc(mh.HandleGetMovies)))
+	http.Handle("/movies", apiKeyMiddleware(models.ScopeMoviesRead, "user", mh.HandleGetMovies))
+	http.Handle("/movies/search", apiKeyMiddleware(models.ScopeMoviesRead, "user", mh.HandleSearchMovies))
 	http.Handle("/movies/add", middleware("admin", mh.HandleAddMovie))
 	http.Handle("/movies/update/", middleware("admin", mh.HandleUpdateMovie))
-	http.Handle("/movies/delete/", middleware("admin", mh.HandleDeleteMovie))
+	http.Handle("/movies/delete/", middleware("head_office", mh.HandleDeleteMovie))
+	http.Handle("/movies/archived", middleware("admin", mh.HandleGetArchivedMovies))
+	http.Handle("/movies/restore/", middleware("admin", mh.HandleRestoreMovie))
+	http.Handle("/movies/purge/", middleware("head_office", mh.HandlePurgeMovie))
+	http.Handle("/movies/import", middleware("admin", ih.HandleImportMovies))
+	http.Handle("/movies/export", middleware("admin", ih.HandleExportMovies))
+	http.Handle("/movies/translations/", middleware("admin", th.HandleGetMovieTranslations))
+	http.Handle("/movies/translations/set/", middleware("admin", th.HandleSetMovieTranslation))
+	http.Handle("/movies/translations/delete/", middleware("admin", th.HandleDeleteMovieTranslation))
+
+	// Movie image routes; the images themselves are public
+	http.Handle("/media/upload/", middleware("admin", mdh.HandleUploadMedia))
+	http.Handle("/media/delete/", middleware("admin", mdh.HandleDeleteMedia))
+	http.Handle("/media/movie/", apiKeyMiddleware(models.ScopeMoviesRead, "user", mdh.HandleGetMovieMedia))
+	http.Handle("/media/", metrics.RequestCounter(http.HandlerFunc(mdh.HandleServeMedia)))
+
+	// Genre taxonomy routes
+	http.Handle("/genres", apiKeyMiddleware(models.ScopeMoviesRead, "user", gh.HandleGetGenres))
+	http.Handle("/genres/add", middleware("admin", gh.HandleAddGenre))
+	http.Handle("/genres/update/", middleware("admin", gh.HandleUpdateGenre))
+	http.Handle("/genres/delete/", middleware("admin", gh.HandleDeleteGenre))
+	http.Handle("/genres/translations/", middleware("admin", th.HandleGetGenreTranslations))
+	http.Handle("/genres/translations/set/", middleware("admin", th.HandleSetGenreTranslation))
+	http.Handle("/genres/translations/delete/", middleware("admin", th.HandleDeleteGenreTranslation))
+
+	// Cast and crew routes
+	http.Handle("/people", middleware("user", peh.HandleGetPeople))
+	http.Handle("/people/add", middleware("admin", peh.HandleAddPerson))
+	http.Handle("/people/update/", middleware("admin", peh.HandleUpdatePerson))
+	http.Handle("/people/delete/", middleware("admin", peh.HandleDeletePerson))
+
+	// Audit trail of catalogue changes
+	http.Handle("/audit", middleware("head_office", auh.HandleGetAuditLog))
+	http.Handle("/audit/revert/", middleware("head_office", auh.HandleRevert))
 
 	// User routes
-	http.Handle("/auth/signup", middleware("user", http.HandlerFunc(ah.SignUp)))
+	http.Handle("/auth/signup", http.HandlerFunc(ah.SignUp))
 	http.Handle("/auth/login", http.HandlerFunc(ah.LogIn))
 	http.Handle("/auth/getid", http.HandlerFunc(ah.GetId))
 
+	// External identity provider routes
+	http.Handle("/auth/oidc/providers", http.HandlerFunc(oh.HandleGetProviders))
+	http.Handle("/auth/oidc/login/", http.HandlerFunc(oh.HandleLogin))
+	http.Handle("/auth/oidc/callback/", http.HandlerFunc(oh.HandleCallback))
+
+	// Two-factor authentication routes
+	http.Handle("/auth/login/2fa", http.HandlerFunc(mfh.HandleLoginMFA))
+	http.Handle("/auth/2fa/enroll", http.HandlerFunc(mfh.HandleEnroll))
+	http.Handle("/auth/2fa/confirm", http.HandlerFunc(mfh.HandleConfirm))
+	http.Handle("/auth/2fa/disable", middleware("user", mfh.HandleDisable))
+	http.Handle("/auth/2fa/recovery-codes", middleware("user", mfh.HandleRecoveryCodes))
+	http.Handle("/auth/2fa/roles", middleware("head_office", mfh.HandleGetRequiredRoles))
+	http.Handle("/auth/2fa/roles/update", middleware("head_office", mfh.HandleUpdateRequiredRoles))
+
+	// Account recovery routes
+	http.Handle("/auth/verify/send", middleware("user", ach.HandleSendVerification))
+	http.Handle("/auth/verify", http.HandlerFunc(ach.HandleVerifyEmail))
+	http.Handle("/auth/password/forgot", http.HandlerFunc(ach.HandleForgotPassword))
+	http.Handle("/auth/password/reset", http.HandlerFunc(ach.HandleResetPassword))
+
+	// Profile routes for the signed-in user
+	http.Handle("/me", middleware("user", ph.HandleGetProfile))
+	http.Handle("/me/update", middleware("user", ph.HandleUpdateProfile))
+	http.Handle("/me/password", middleware("user", ph.HandleChangePassword))
+	http.Handle("/me/delete", middleware("user", ph.HandleDeleteAccount))
+	http.Handle("/me/export", middleware("user", ph.HandleExportData))
+	http.Handle("/me/recommendations", middleware("user", rch.HandleGetRecommendations))
+
+	// User management routes
+	http.Handle("/users", middleware("admin", uh.HandleGetUsers))
+	http.Handle("/users/add", middleware("admin", uh.HandleAddUser))
+	http.Handle("/users/role/", middleware("admin", uh.HandleUpdateUserRole))
+	http.Handle("/users/status/", middleware("admin", uh.HandleSetUserStatus))
+	http.Handle("/users/delete/", middleware("admin", uh.HandleDeleteUser))
+	http.Handle("/users/export/", middleware("admin", uh.HandleExportUser))
+	http.Handle("/users/erase/", middleware("admin", uh.HandleEraseUser))
+	http.Handle("/users/login-attempts", middleware("head_office", uh.HandleGetLoginAttempts))
+
+	// API key management routes
+	http.Handle("/api-keys", middleware("head_office", akh.HandleGetAPIKeys))
+	http.Handle("/api-keys/add", middleware("head_office", akh.HandleAddAPIKey))
+	http.Handle("/api-keys/revoke/", middleware("head_office", akh.HandleRevokeAPIKey))
+
 	// Showtime routes
-	http.Handle("/showtimes", middleware("user", sh.HandleGetShowtimes))
+	http.Handle("/showtimes", apiKeyMiddleware(models.ScopeShowtimesRead, "user", sh.HandleGetShowtimes))
 	http.Handle("/showtimes/add", middleware("admin", sh.HandleAddShowtime))
 	http.Handle("/showtimes/update/", middleware("admin", sh.HandleUpdateShowtime))
 	http.Handle("/showtimes/delete/", middleware("admin", sh.HandleDeleteShowtime))
-	http.Handle("/showtimes/seats/", middleware("user", sh.HandleGetSeats))
+	http.Handle("/showtimes/archived", middleware("admin", sh.HandleGetArchivedShowtimes))
+	http.Handle("/showtimes/restore/", middleware("admin", sh.HandleRestoreShowtime))
+	http.Handle("/showtimes/purge/", middleware("admin", sh.HandlePurgeShowtime))
+	http.Handle("/showtimes/import", middleware("head_office", ih.HandleImportShowtimes))
+	http.Handle("/showtimes/export", middleware("head_office", ih.HandleExportShowtimes))
+	http.Handle("/showtimes/seats/", apiKeyMiddleware(models.ScopeShowtimesRead, "user", sh.HandleGetSeats))
+	http.Handle("/showtimes/movie/", apiKeyMiddleware(models.ScopeShowtimesRead, "user", sh.HandleGetMovieShowtimes))
+
+	// Cinema routes; admins run one cinema, head office runs them all
+	http.Handle("/cinemas", middleware("user", cnh.HandleGetCinemas))
+	http.Handle("/cinemas/add", middleware("head_office", cnh.HandleAddCinema))
+	http.Handle("/cinemas/update/", middleware("head_office", cnh.HandleUpdateCinema))
+	http.Handle("/cinemas/delete/", middleware("head_office", cnh.HandleDeleteCinema))
+
+	// Auditorium routes; showtimes in the same auditorium cannot overlap
+	http.Handle("/auditoriums", middleware("user", adh.HandleGetAuditoriums))
+	http.Handle("/auditoriums/add", middleware("admin", adh.HandleAddAuditorium))
+	http.Handle("/auditoriums/update/", middleware("admin", adh.HandleUpdateAuditorium))
+	http.Handle("/auditoriums/delete/", middleware("admin", adh.HandleDeleteAuditorium))
+
+	// Recurring schedules; dry_run=true previews the showtimes
+	http.Handle("/schedules", middleware("admin", sch.HandleGetSchedules))
+	http.Handle("/schedules/", middleware("admin", sch.HandleGetSchedule))
+	http.Handle("/schedules/add", middleware("admin", sch.HandleAddSchedule))
+	http.Handle("/schedules/update/", middleware("admin", sch.HandleUpdateSchedule))
+	http.Handle("/schedules/cancel/", middleware("admin", sch.HandleCancelSchedule))
 
 	// Reservation routes
 	http.Handle("/reserve/add", middleware("user", rh.HandleReservation))
@@ -38,6 +149,17 @@ func SetupRoutes(mh *handlers.MovieHandler, sh *handlers.ShowtimeHandler, ah *ha
 	http.Handle("/reserve/all", middleware("admin", rh.HandleGetAllReservations))
 	http.Handle("/reserve/movie/", middleware("admin", rh.HandleGetReservationsPerMovie))
 
+	// Review routes; only customers who attended a showtime may post
+	http.Handle("/reviews/movie/", apiKeyMiddleware(models.ScopeMoviesRead, "user", rvh.HandleGetMovieReviews))
+	http.Handle("/reviews/mine", middleware("user", rvh.HandleGetMyReviews))
+	http.Handle("/reviews/add", middleware("user", rvh.HandleAddReview))
+	http.Handle("/reviews/update/", middleware("user", rvh.HandleUpdateReview))
+	http.Handle("/reviews/delete/", middleware("user", rvh.HandleDeleteReview))
+	http.Handle("/reviews/report/", middleware("user", rvh.HandleReportReview))
+	http.Handle("/reviews/moderation", middleware("admin", rvh.HandleGetModerationQueue))
+	http.Handle("/reviews/hide/", middleware("admin", rvh.HandleHideReview))
+	http.Handle("/reviews/approve/", middleware("admin", rvh.HandleApproveReview))
+
 	// Revenue routes
 	http.Handle("/revenue", middleware("admin", rh.HandleGetTotalRevenue))
 
@@ -31,10 +31,32 @@ func SetupTestDB() (*pgxpool.Pool, error) {
 
 func ClearTestDB(db *pgxpool.Pool) error {
 	tables := []string{
+		"oidc_states",
+		"user_identities",
+		"user_tokens",
+		"user_recovery_codes",
+		"mfa_required_roles",
+		"login_attempts",
+		"audit_log",
+		"api_keys",
+		"review_reports",
+		"reviews",
+		"recommendations",
+		"popular_movies",
 		"reservations",
 		"showtimes",
+		"schedules",
+		"auditoriums",
+		"movie_credits",
+		"people",
+		"movie_genres",
+		"movie_media",
+		"movie_translations",
+		"genre_translations",
+		"genres",
 		"movies",
 		"users",
+		"cinemas",
 	}
 
 	ctx := context.Background()
@@ -7,7 +7,8 @@ import {
   DialogTitle,
   DialogTrigger,
 } from "./components/ui/dialog";
-import { Movie } from "./lib/types";
+import { Genre, Media, Movie } from "./lib/types";
+import { GenrePicker } from "./GenrePicker";
 import React, { useState } from "react";
 import { Label } from "./components/ui/label";
 import { Input } from "./components/ui/input";
@@ -22,11 +23,44 @@ const EditMovieDialog = ({ movie, onMovieUpdate }: EditMovieDialogProps) => {
   const [open, setOpen] = useState(false);
   const [title, setTitle] = useState(movie.title);
   const [description, setDescription] = useState(movie.description);
-  const [genre, setGenre] = useState(movie.genre);
+  const [genres, setGenres] = useState<Genre[]>(movie.genres);
   const [posterImage, setPosterImage] = useState(movie.poster_image || "");
   const [loading, setLoading] = useState(false);
+  const [uploading, setUploading] = useState(false);
   const [error, setError] = useState("");
 
+  // The uploaded poster replaces the poster of the movie right away
+  const handlePosterUpload = async (e: React.ChangeEvent<HTMLInputElement>) => {
+    const file = e.target.files?.[0];
+    if (!file) return;
+    setUploading(true);
+    setError("");
+    const token = localStorage.getItem("token");
+
+    try {
+      const form = new FormData();
+      form.append("kind", "poster");
+      form.append("file", file);
+      const response = await fetch(
+        `http://localhost:8080/media/upload/${movie.id}`,
+        {
+          method: "POST",
+          headers: { Authorization: `Bearer ${token}` },
+          body: form,
+        }
+      );
+      if (!response.ok) {
+        throw new Error(await response.text());
+      }
+      const media: Media = await response.json();
+      setPosterImage(media.urls.large);
+    } catch (err: any) {
+      setError(err.message || "Failed to upload poster");
+    } finally {
+      setUploading(false);
+    }
+  };
+
   const handleSubmit = async (e: React.FormEvent) => {
     e.preventDefault();
     setLoading(true);
@@ -45,7 +79,9 @@ const EditMovieDialog = ({ movie, onMovieUpdate }: EditMovieDialogProps) => {
           body: JSON.stringify({
             title,
             description,
-            genre,
+            // Keeps a movie shown translated from overwriting the original
+            locale: movie.locale,
+            genres: genres.map((genre) => ({ id: genre.id })),
             poster_image: posterImage,
           }),
         }
@@ -98,15 +134,10 @@ const EditMovieDialog = ({ movie, onMovieUpdate }: EditMovieDialogProps) => {
               />
             </div>
             <div>
-              <Label className="mb-1" htmlFor="genre">
-                Genre
-              </Label>
-              <Input
-                className="mt-1"
-                id="genre"
-                value={genre}
-                onChange={(e) => setGenre(e.target.value)}
-              />
+              <Label className="mb-1">Genres</Label>
+              <div className="mt-1">
+                <GenrePicker selected={genres} onChange={setGenres} />
+              </div>
             </div>
             <div>
               <Label className="mb-1" htmlFor="posterImage">
@@ -118,9 +149,17 @@ const EditMovieDialog = ({ movie, onMovieUpdate }: EditMovieDialogProps) => {
                 value={posterImage}
                 onChange={(e) => setPosterImage(e.target.value)}
               />
+              <Input
+                className="mt-1"
+                id="posterUpload"
+                type="file"
+                accept="image/jpeg,image/png,image/webp"
+                onChange={handlePosterUpload}
+                disabled={uploading}
+              />
             </div>
             {error && <p className="text-red-500">{error}</p>}
-            <Button type="submit" disabled={loading}>
+            <Button type="submit" disabled={loading || uploading}>
               {loading ? "Updating..." : "Update Movie"}
             </Button>
           </form>
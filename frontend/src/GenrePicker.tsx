@@ -0,0 +1,46 @@
+import { useEffect, useState } from "react";
+import { Button } from "@/components/ui/button";
+import { Genre } from "./lib/types";
+import { fetchAllPages } from "./useMoviesAndShowtimes";
+
+interface GenrePickerProps {
+  selected: Genre[];
+  onChange: (genres: Genre[]) => void;
+}
+
+// Lets an admin pick any number of genres from the taxonomy.
+export function GenrePicker({ selected, onChange }: GenrePickerProps) {
+  const [genres, setGenres] = useState<Genre[]>([]);
+
+  useEffect(() => {
+    const token = localStorage.getItem("token");
+    if (!token) return;
+    fetchAllPages<Genre>("http://localhost:8080/genres", token)
+      .then(setGenres)
+      .catch((error) => console.error("Error fetching genres:", error));
+  }, []);
+
+  const toggle = (genre: Genre) => {
+    if (selected.some((g) => g.id === genre.id)) {
+      onChange(selected.filter((g) => g.id !== genre.id));
+    } else {
+      onChange([...selected, genre]);
+    }
+  };
+
+  return (
+    <div className="flex flex-wrap gap-2">
+      {genres.map((genre) => (
+        <Button
+          key={genre.id}
+          type="button"
+          size="sm"
+          variant={selected.some((g) => g.id === genre.id) ? "default" : "outline"}
+          onClick={() => toggle(genre)}
+        >
+          {genre.name}
+        </Button>
+      ))}
+    </div>
+  );
+}
@@ -128,7 +128,7 @@ function MovieCard({
       <CardContent className="px-4 pb-2 space-y-2">
         <p className="text-sm text-muted-foreground">{movie.description}</p>
         <p className="text-sm text-muted-foreground italic">
-          Genre: {movie.genre}
+          Genres: {movie.genres.map((genre) => genre.name).join(", ")}
         </p>
       </CardContent>
 
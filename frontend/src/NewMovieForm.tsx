@@ -7,7 +7,8 @@ import { Button } from "@/components/ui/button";
 import { Input } from "@/components/ui/input";
 import { Label } from "@/components/ui/label";
 import { Textarea } from "@/components/ui/textarea";
-import { Movie } from "./lib/types";
+import { Genre, Movie } from "./lib/types";
+import { GenrePicker } from "./GenrePicker";
 
 interface NewMovieFormProps {
   onSuccess: (movie: Movie) => void;
@@ -17,7 +18,7 @@ interface NewMovieFormProps {
 export function NewMovieForm({ onSuccess, onCancel }: NewMovieFormProps) {
   const [title, setTitle] = useState("");
   const [description, setDescription] = useState("");
-  const [genre, setGenre] = useState("");
+  const [genres, setGenres] = useState<Genre[]>([]);
   const [posterImage, setPosterImage] = useState("");
   const [isSubmitting, setIsSubmitting] = useState(false);
   const [error, setError] = useState("");
@@ -42,7 +43,7 @@ export function NewMovieForm({ onSuccess, onCancel }: NewMovieFormProps) {
         body: JSON.stringify({
           title,
           description,
-          genre,
+          genres: genres.map((genre) => ({ id: genre.id })),
           poster_image: posterImage,
         }),
       });
@@ -56,7 +57,7 @@ export function NewMovieForm({ onSuccess, onCancel }: NewMovieFormProps) {
         id: newMovie.id,
         title: newMovie.title,
         description: newMovie.description,
-        genre: newMovie.genre,
+        genres: newMovie.genres,
         poster_image: newMovie.poster_image,
       });
     } catch (error) {
@@ -97,14 +98,8 @@ export function NewMovieForm({ onSuccess, onCancel }: NewMovieFormProps) {
       </div>
 
       <div className="space-y-2">
-        <Label htmlFor="genre">Genre</Label>
-        <Input
-          id="genre"
-          type="text"
-          value={genre}
-          onChange={(e) => setGenre(e.target.value)}
-          required
-        />
+        <Label>Genres</Label>
+        <GenrePicker selected={genres} onChange={setGenres} />
       </div>
 
       <div className="space-y-2">
@@ -1,16 +1,150 @@
 
 export interface Movie {
   id: number;
+  external_id?: string;
   title: string;
   description: string;
-  genre: string;
+  genres: Genre[];
   poster_image: string;
+  release_date?: string | null;
+  runtime_minutes?: number;
+  certification?: string;
+  original_language?: string;
+  subtitles?: string[];
+  locale?: string;
+  locked_fields?: string[];
+  credits?: Credit[];
+  archived_at?: string | null;
+  average_rating?: number;
+  review_count?: number;
+}
+
+export interface Review {
+  id: number;
+  movie_id: number;
+  user_id: number;
+  author: string;
+  rating: number;
+  body: string;
+  status: "published" | "reported" | "hidden";
+  report_count: number;
+  reports?: { user_id: number; reason: string; created_at: string }[];
+  moderated_by?: string;
+  moderated_at?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface Recommendation {
+  movie: Movie;
+  score: number;
+  reason: "similar" | "genre" | "popular";
+  similar_to?: string;
+  showtimes: Showtime[];
+}
+
+export interface Media {
+  id: number;
+  movie_id: number;
+  kind: "poster" | "backdrop" | "still";
+  content_type: string;
+  width: number;
+  height: number;
+  size_bytes: number;
+  created_at: string;
+  urls: Record<"original" | "small" | "medium" | "large", string>;
+}
+
+export interface Genre {
+  id: number;
+  name: string;
+  slug: string;
+}
+
+export interface Credit {
+  person_id: number;
+  name: string;
+  role: "cast" | "director" | "writer" | "producer";
+  character?: string;
+  position: number;
 }
 
 export interface Showtime {
   id: number;
+  external_id?: string;
+  cinema_id: number;
+  timezone?: string;
   movie_id: number;
+  schedule_id?: number;
+  auditorium_id?: number;
   start_time: string;
+  ends_at?: string | null;
   capacity: number;
   reserved: number;
+  available?: number;
+  archived_at?: string | null;
+}
+
+export interface ShowtimeDay {
+  date: string;
+  showtimes: Showtime[];
+}
+
+export interface MovieShowtimes {
+  movie_id: number;
+  days: ShowtimeDay[];
+}
+
+export interface Cinema {
+  id: number;
+  name: string;
+  address: string;
+  timezone: string;
+  seat_price: number;
+  created_at: string;
+}
+
+export interface Auditorium {
+  id: number;
+  cinema_id: number;
+  name: string;
+  ad_minutes: number;
+  cleaning_minutes: number;
+  created_at: string;
+}
+
+export interface Schedule {
+  id: number;
+  cinema_id: number;
+  movie_id: number;
+  auditorium_id?: number;
+  capacity: number;
+  start_date: string;
+  times: string[];
+  rrule: string;
+  exdates: string[];
+  cancelled_at?: string | null;
+  created_at: string;
+  updated_at: string;
+  showtimes?: Showtime[];
+}
+
+export interface ScheduleOccurrence {
+  start_time: string;
+  showtime_id?: number;
+  action: "create" | "update" | "keep" | "delete" | "fail";
+  reason?: "started" | "booked" | "archived";
+  error?: string;
+}
+
+export interface ScheduleReport {
+  schedule: Schedule;
+  dry_run: boolean;
+  committed: boolean;
+  created: number;
+  updated: number;
+  kept: number;
+  deleted: number;
+  failed: number;
+  occurrences: ScheduleOccurrence[];
 }
@@ -9,6 +9,35 @@ interface Showtime {
   reserved: number;
 }
 
+interface Page<T> {
+  items: T[];
+  next_cursor: string | null;
+  total: number;
+}
+
+// List endpoints are paginated; follow next_cursor until the last page.
+export async function fetchAllPages<T>(url: string, token: string): Promise<T[]> {
+  const items: T[] = [];
+  let cursor: string | null = null;
+  do {
+    const pageUrl: string =
+      `${url}?limit=100` + (cursor ? `&cursor=${encodeURIComponent(cursor)}` : "");
+    const response = await fetch(pageUrl, {
+      headers: {
+        Authorization: `Bearer ${token}`,
+        "Content-Type": "application/json",
+      },
+    });
+    if (!response.ok) {
+      throw new Error(`Failed to fetch ${url}`);
+    }
+    const page: Page<T> = await response.json();
+    items.push(...page.items);
+    cursor = page.next_cursor;
+  } while (cursor);
+  return items;
+}
+
 export function useMoviesAndShowtimes(token: string | null) {
   const [movies, setMovies] = useState<Movie[]>([]);
   const [showtimes, setShowtimes] = useState<Showtime[]>([]);
@@ -21,44 +50,17 @@ export function useMoviesAndShowtimes(token: string | null) {
 
       setLoading(true);
       try {
-        const movieResponse = await fetch("http://localhost:8080/movies", {
-          headers: {
-            Authorization: `Bearer ${token}`,
-            "Content-Type": "application/json",
-          },
-        });
-
-        if (!movieResponse.ok) {
-          throw new Error("Failed to fetch movies");
-        }
-
-        const moviesData = await movieResponse.json();
+        const moviesData = await fetchAllPages<Movie>(
+          "http://localhost:8080/movies",
+          token
+        );
         setMovies(moviesData);
 
-        const showtimeResponse = await fetch(
+        const showtimeData = await fetchAllPages<Showtime>(
           "http://localhost:8080/showtimes",
-          {
-            headers: {
-              Authorization: `Bearer ${token}`,
-              "Content-Type": "application/json",
-            },
-          }
+          token
         );
-
-        if (!showtimeResponse.ok) {
-          throw new Error("Failed to fetch showtimes");
-        }
-
-        const showtimeData = await showtimeResponse.json();
-        if (showtimeData === null) {
-          setShowtimes([]);
-        } else {
-          const uniqueShowtimes = showtimeData.filter(
-            (showtime: Showtime, index: number, self: Showtime[]) =>
-              index === self.findIndex((s) => s.id === showtime.id)
-          );
-          setShowtimes(uniqueShowtimes);
-        }
+        setShowtimes(showtimeData);
       } catch (err) {
         setError(err instanceof Error ? err.message : "Error fetching data");
         console.error("Error fetching data:", err);
@@ -1,33 +1,540 @@
+-- A cinema is one site. Its showtimes are shown and scheduled in its time
+-- zone, an IANA name, and a seat in it costs seat_price.
+CREATE TABLE IF NOT EXISTS cinemas (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) UNIQUE NOT NULL,
+    address TEXT NOT NULL DEFAULT '',
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    seat_price INTEGER NOT NULL DEFAULT 5 CHECK (seat_price >= 0),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
 CREATE TABLE IF NOT EXISTS users (
     id SERIAL PRIMARY KEY,
     username VARCHAR(255) UNIQUE NOT NULL,
+    email VARCHAR(255) UNIQUE,
+    email_verified BOOLEAN NOT NULL DEFAULT FALSE,
     password_hash TEXT NOT NULL,
     role VARCHAR(50) NOT NULL,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    -- Admins run one cinema; head office runs them all
+    cinema_id INTEGER REFERENCES cinemas(id),
+    disabled BOOLEAN NOT NULL DEFAULT FALSE,
+    totp_secret TEXT,
+    totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    totp_last_step BIGINT NOT NULL DEFAULT 0,
+    display_name VARCHAR(255),
+    phone VARCHAR(32),
+    preferred_language VARCHAR(16) NOT NULL DEFAULT 'en',
+    marketing_consent BOOLEAN NOT NULL DEFAULT FALSE,
+    erased_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT users_admin_cinema CHECK ((role = 'admin') = (cinema_id IS NOT NULL))
 );
 
+-- Databases created before accounts had email addresses lack the columns.
+-- Addresses that differ only in case reach the same mailbox, so they are
+-- unique regardless of it.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email VARCHAR(255) UNIQUE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_lower_key ON users (lower(email));
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+-- Profile and erasure columns of databases created before users managed
+-- their own accounts.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS display_name VARCHAR(255);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone VARCHAR(32);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS preferred_language VARCHAR(16) NOT NULL DEFAULT 'en';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS marketing_consent BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS erased_at TIMESTAMPTZ;
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
 CREATE TABLE IF NOT EXISTS movies (
     id SERIAL PRIMARY KEY,
+    -- Key used by bulk imports
+    external_id VARCHAR(100) UNIQUE,
     title VARCHAR(255) UNIQUE NOT NULL,
     description TEXT,
-    genre VARCHAR(100),
-    poster_image TEXT
+    poster_image TEXT,
+    release_date DATE,
+    runtime_minutes INTEGER NOT NULL DEFAULT 0 CHECK (runtime_minutes >= 0),
+    certification VARCHAR(16),
+    original_language VARCHAR(16),
+    subtitles TEXT[] NOT NULL DEFAULT '{}',
+    -- Fields edited by hand that metadata imports must not overwrite
+    locked_fields TEXT[] NOT NULL DEFAULT '{}',
+    -- Set when an admin deletes the movie; see MovieRepository.ArchiveMovie
+    archived_at TIMESTAMPTZ,
+    -- Maintained by MovieRepository.RefreshSearchDocuments
+    search_vector TSVECTOR,
+    search_text TEXT NOT NULL DEFAULT ''
+);
+
+-- Databases created before search lack the columns; the server fills them in
+-- for every movie when it starts.
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS search_vector TSVECTOR;
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS search_text TEXT NOT NULL DEFAULT '';
+
+-- Release details of databases created before movies carried them; locked
+-- fields guard manual edits against metadata imports.
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS release_date DATE;
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS runtime_minutes INTEGER NOT NULL DEFAULT 0 CHECK (runtime_minutes >= 0);
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS certification VARCHAR(16);
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS original_language VARCHAR(16);
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS subtitles TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS locked_fields TEXT[] NOT NULL DEFAULT '{}';
+
+-- Movies of databases created before archiving are all live.
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+
+-- Import keys of databases created before bulk imports. The index has the
+-- name of the one behind the UNIQUE column constraint, so it is only built
+-- when that is missing.
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS external_id VARCHAR(100);
+CREATE UNIQUE INDEX IF NOT EXISTS movies_external_id_key ON movies (external_id);
+
+CREATE INDEX IF NOT EXISTS idx_movies_search_vector ON movies USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_movies_search_text ON movies USING GIN (search_text gin_trgm_ops);
+
+CREATE TABLE IF NOT EXISTS genres (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    -- models.Slugify of the name
+    slug VARCHAR(100) UNIQUE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS movie_genres (
+    movie_id INTEGER NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    genre_id INTEGER NOT NULL REFERENCES genres(id) ON DELETE CASCADE,
+    PRIMARY KEY (movie_id, genre_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_movie_genres_genre ON movie_genres (genre_id);
+
+-- Titles and descriptions in other languages than the default locale, which
+-- the movies table itself is in
+CREATE TABLE IF NOT EXISTS movie_translations (
+    movie_id INTEGER NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    locale VARCHAR(35) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (movie_id, locale)
+);
+
+CREATE TABLE IF NOT EXISTS genre_translations (
+    genre_id INTEGER NOT NULL REFERENCES genres(id) ON DELETE CASCADE,
+    locale VARCHAR(35) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (genre_id, locale)
+);
+
+-- Databases created before the genre taxonomy keep a free-text genre per
+-- movie. Every comma or slash separated part becomes a genre, spellings that
+-- share a slug are merged, and the column is dropped.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_name = 'movies' AND column_name = 'genre') THEN
+        CREATE TEMPORARY TABLE legacy_genres ON COMMIT DROP AS
+        SELECT movie_id, name,
+               trim(both '-' from regexp_replace(lower(name), '[^[:alnum:]]+', '-', 'g')) AS slug
+        FROM (
+            SELECT id AS movie_id, trim(part) AS name
+            FROM movies, regexp_split_to_table(COALESCE(genre, ''), '[,/]') AS part
+        ) parts;
+
+        INSERT INTO genres (name, slug)
+        SELECT DISTINCT ON (slug) name, slug
+        FROM legacy_genres
+        WHERE slug <> ''
+        ORDER BY slug, name
+        ON CONFLICT (slug) DO NOTHING;
+
+        INSERT INTO movie_genres (movie_id, genre_id)
+        SELECT DISTINCT l.movie_id, g.id
+        FROM legacy_genres l
+        JOIN genres g ON g.slug = l.slug
+        ON CONFLICT DO NOTHING;
+
+        ALTER TABLE movies DROP COLUMN genre;
+    END IF;
+END $$;
+
+-- Uploaded images. The files themselves live in the blob store under
+-- key_prefix.
+CREATE TABLE IF NOT EXISTS movie_media (
+    id SERIAL PRIMARY KEY,
+    movie_id INTEGER NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    content_type VARCHAR(50) NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    key_prefix TEXT UNIQUE NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_movie_media_movie ON movie_media (movie_id);
+
+CREATE TABLE IF NOT EXISTS people (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS movie_credits (
+    movie_id INTEGER NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    person_id INTEGER NOT NULL REFERENCES people(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL,
+    character VARCHAR(255),
+    position INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (movie_id, person_id, role)
+);
+
+CREATE INDEX IF NOT EXISTS idx_movie_credits_person ON movie_credits (person_id);
+
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- A showtime occupies its auditorium from its start time, through the ads and
+-- the movie, until the cleaning after it is done.
+CREATE TABLE IF NOT EXISTS auditoriums (
+    id SERIAL PRIMARY KEY,
+    cinema_id INTEGER NOT NULL REFERENCES cinemas(id),
+    name VARCHAR(100) NOT NULL,
+    ad_minutes INTEGER NOT NULL DEFAULT 15 CHECK (ad_minutes >= 0),
+    cleaning_minutes INTEGER NOT NULL DEFAULT 15 CHECK (cleaning_minutes >= 0),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (cinema_id, name),
+    -- Lets showtimes and schedules check their auditorium is in their cinema
+    UNIQUE (id, cinema_id)
+);
+
+-- Recurring showtimes: the movie is shown at each of times on every date the
+-- rrule (RFC 5545) yields from start_date on, except the exdates. The
+-- occurrences are the showtimes with the schedule_id.
+CREATE TABLE IF NOT EXISTS schedules (
+    id SERIAL PRIMARY KEY,
+    movie_id INTEGER NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    cinema_id INTEGER NOT NULL REFERENCES cinemas(id),
+    auditorium_id INTEGER REFERENCES auditoriums(id),
+    capacity INTEGER NOT NULL,
+    start_date DATE NOT NULL,
+    times TEXT[] NOT NULL,
+    rrule TEXT NOT NULL,
+    exdates DATE[] NOT NULL DEFAULT '{}',
+    cancelled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT schedules_auditorium_cinema_fkey FOREIGN KEY (auditorium_id, cinema_id)
+        REFERENCES auditoriums (id, cinema_id)
 );
 
 CREATE TABLE IF NOT EXISTS showtimes (
     id SERIAL PRIMARY KEY,
+    external_id VARCHAR(100) UNIQUE,
     movie_id INTEGER REFERENCES movies(id) ON DELETE CASCADE,
-    start_time TIMESTAMP NOT NULL,
+    -- Set for the occurrences of a recurring schedule
+    schedule_id INTEGER REFERENCES schedules(id) ON DELETE SET NULL,
+    cinema_id INTEGER NOT NULL REFERENCES cinemas(id),
+    -- Showtimes without an auditorium are not checked for overlaps
+    auditorium_id INTEGER REFERENCES auditoriums(id),
+    start_time TIMESTAMPTZ NOT NULL,
+    -- When the auditorium is free again; maintained by scheduleShowtime
+    ends_at TIMESTAMPTZ,
     capacity INTEGER NOT NULL,
-    reserved INTEGER DEFAULT 0
+    reserved INTEGER DEFAULT 0,
+    archived_at TIMESTAMPTZ,
+    CONSTRAINT showtimes_ends_at_check CHECK ((auditorium_id IS NULL) = (ends_at IS NULL)),
+    CONSTRAINT showtimes_auditorium_cinema_fkey FOREIGN KEY (auditorium_id, cinema_id)
+        REFERENCES auditoriums (id, cinema_id),
+    CONSTRAINT showtimes_no_overlap EXCLUDE USING gist (
+        auditorium_id WITH =,
+        tstzrange(start_time, ends_at) WITH &&
+    ) WHERE (archived_at IS NULL)
 );
 
+-- Showtimes of databases created before archiving are all live.
+ALTER TABLE showtimes ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+
+ALTER TABLE showtimes ADD COLUMN IF NOT EXISTS external_id VARCHAR(100);
+CREATE UNIQUE INDEX IF NOT EXISTS showtimes_external_id_key ON showtimes (external_id);
+
+-- Showtimes of databases created before auditoriums have none and are not
+-- checked for overlaps. The overlap constraint needs TIMESTAMPTZ start times;
+-- on older databases the conversion at the end of this file adds it.
+ALTER TABLE showtimes ADD COLUMN IF NOT EXISTS auditorium_id INTEGER REFERENCES auditoriums(id);
+ALTER TABLE showtimes ADD COLUMN IF NOT EXISTS ends_at TIMESTAMPTZ;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'showtimes_ends_at_check') THEN
+        ALTER TABLE showtimes ADD CONSTRAINT showtimes_ends_at_check
+            CHECK ((auditorium_id IS NULL) = (ends_at IS NULL));
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'showtimes_no_overlap')
+       AND EXISTS (SELECT 1 FROM information_schema.columns
+                   WHERE table_schema = current_schema() AND table_name = 'showtimes'
+                   AND column_name = 'start_time' AND data_type = 'timestamp with time zone') THEN
+        ALTER TABLE showtimes ADD CONSTRAINT showtimes_no_overlap EXCLUDE USING gist (
+            auditorium_id WITH =,
+            tstzrange(start_time, ends_at) WITH &&
+        ) WHERE (archived_at IS NULL);
+    END IF;
+END $$;
+
+-- Showtimes of databases created before schedules were all added one by one.
+ALTER TABLE showtimes ADD COLUMN IF NOT EXISTS schedule_id INTEGER REFERENCES schedules(id) ON DELETE SET NULL;
+
+-- Databases created before there were several cinemas hold a single site. It
+-- becomes the cinema Main, in the movie_system.site_timezone zone (see the
+-- TIMESTAMPTZ conversion below), which gets every auditorium, schedule and
+-- showtime. Its admins ran the whole site and become head office.
+ALTER TABLE auditoriums ADD COLUMN IF NOT EXISTS cinema_id INTEGER REFERENCES cinemas(id);
+ALTER TABLE schedules ADD COLUMN IF NOT EXISTS cinema_id INTEGER REFERENCES cinemas(id);
+ALTER TABLE showtimes ADD COLUMN IF NOT EXISTS cinema_id INTEGER REFERENCES cinemas(id);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS cinema_id INTEGER REFERENCES cinemas(id);
+ALTER TABLE auditoriums DROP CONSTRAINT IF EXISTS auditoriums_name_key;
+UPDATE users SET role = 'head_office' WHERE role = 'admin' AND cinema_id IS NULL;
+
+DO $$
+DECLARE
+    main INTEGER;
+BEGIN
+    IF EXISTS (SELECT 1 FROM auditoriums WHERE cinema_id IS NULL)
+       OR EXISTS (SELECT 1 FROM schedules WHERE cinema_id IS NULL)
+       OR EXISTS (SELECT 1 FROM showtimes WHERE cinema_id IS NULL) THEN
+        INSERT INTO cinemas (name, timezone)
+        VALUES ('Main', COALESCE(NULLIF(current_setting('movie_system.site_timezone', true), ''), 'UTC'))
+        ON CONFLICT (name) DO NOTHING;
+        SELECT id INTO main FROM cinemas WHERE name = 'Main';
+
+        UPDATE auditoriums SET cinema_id = main WHERE cinema_id IS NULL;
+        UPDATE schedules SET cinema_id = main WHERE cinema_id IS NULL;
+        UPDATE showtimes SET cinema_id = main WHERE cinema_id IS NULL;
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'auditoriums_cinema_id_name_key') THEN
+        ALTER TABLE auditoriums ADD CONSTRAINT auditoriums_cinema_id_name_key UNIQUE (cinema_id, name);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'auditoriums_id_cinema_id_key') THEN
+        ALTER TABLE auditoriums ADD CONSTRAINT auditoriums_id_cinema_id_key UNIQUE (id, cinema_id);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'schedules_auditorium_cinema_fkey') THEN
+        ALTER TABLE schedules ADD CONSTRAINT schedules_auditorium_cinema_fkey
+            FOREIGN KEY (auditorium_id, cinema_id) REFERENCES auditoriums (id, cinema_id);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'showtimes_auditorium_cinema_fkey') THEN
+        ALTER TABLE showtimes ADD CONSTRAINT showtimes_auditorium_cinema_fkey
+            FOREIGN KEY (auditorium_id, cinema_id) REFERENCES auditoriums (id, cinema_id);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'users_admin_cinema') THEN
+        ALTER TABLE users ADD CONSTRAINT users_admin_cinema
+            CHECK ((role = 'admin') = (cinema_id IS NOT NULL));
+    END IF;
+END $$;
+
+ALTER TABLE auditoriums ALTER COLUMN cinema_id SET NOT NULL;
+ALTER TABLE schedules ALTER COLUMN cinema_id SET NOT NULL;
+ALTER TABLE showtimes ALTER COLUMN cinema_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_showtimes_cinema ON showtimes (cinema_id, start_time);
+CREATE INDEX IF NOT EXISTS idx_showtimes_movie ON showtimes (movie_id, start_time);
+
+-- Reservations are financial records and outlive the user who made them.
 CREATE TABLE IF NOT EXISTS reservations (
     id SERIAL PRIMARY KEY,
-    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
     movie_id INTEGER REFERENCES movies(id) ON DELETE CASCADE,
     showtime_id INTEGER REFERENCES showtimes(id) ON DELETE CASCADE,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    seats TEXT[] 
-);
\ No newline at end of file
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    seats TEXT[],
+    -- The seat price of the cinema when the seats were booked
+    seat_price INTEGER NOT NULL DEFAULT 5
+);
+
+-- Databases created before seat prices were kept with the reservation lack
+-- the column; the seats were sold at the default price.
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS seat_price INTEGER NOT NULL DEFAULT 5;
+
+-- Databases created before reservations outlived their users still have the
+-- cascading foreign key.
+ALTER TABLE reservations DROP CONSTRAINT IF EXISTS reservations_user_id_fkey;
+ALTER TABLE reservations ADD CONSTRAINT reservations_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+-- Free seats are counted from the reservations of a showtime
+CREATE INDEX IF NOT EXISTS idx_reservations_showtime ON reservations (showtime_id);
+
+-- Ratings and reviews by customers who saw the movie. Unlike reservations they
+-- are personal content and go with the account.
+CREATE TABLE IF NOT EXISTS reviews (
+    id SERIAL PRIMARY KEY,
+    movie_id INTEGER NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    body TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'published',
+    moderated_by VARCHAR(255),
+    moderated_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (movie_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reviews_movie ON reviews (movie_id, id);
+CREATE INDEX IF NOT EXISTS idx_reviews_status ON reviews (status, id);
+
+CREATE TABLE IF NOT EXISTS review_reports (
+    review_id INTEGER NOT NULL REFERENCES reviews(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (review_id, user_id)
+);
+
+-- Recommendations computed from booking history by the periodic
+-- recommendation job, which replaces both tables on every run.
+CREATE TABLE IF NOT EXISTS recommendations (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    movie_id INTEGER NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    score DOUBLE PRECISION NOT NULL,
+    reason VARCHAR(20) NOT NULL,
+    similar_to INTEGER REFERENCES movies(id) ON DELETE SET NULL,
+    computed_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, movie_id)
+);
+
+CREATE TABLE IF NOT EXISTS popular_movies (
+    movie_id INTEGER PRIMARY KEY REFERENCES movies(id) ON DELETE CASCADE,
+    score DOUBLE PRECISION NOT NULL,
+    computed_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS user_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    -- Address the token was mailed to
+    email VARCHAR(255),
+    purpose VARCHAR(50) NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tokens issued before they recorded their address no longer verify one.
+ALTER TABLE user_tokens ADD COLUMN IF NOT EXISTS email VARCHAR(255);
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS mfa_required_roles (
+    role VARCHAR(50) PRIMARY KEY
+);
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id SERIAL PRIMARY KEY,
+    username VARCHAR(255) NOT NULL,
+    ip VARCHAR(64) NOT NULL,
+    success BOOLEAN NOT NULL,
+    result VARCHAR(50) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS login_attempts_username_idx ON login_attempts (username, created_at);
+CREATE INDEX IF NOT EXISTS login_attempts_ip_idx ON login_attempts (ip, created_at);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(16) UNIQUE NOT NULL,
+    key_hash TEXT UNIQUE NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    rate_limit INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_by VARCHAR(255),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS user_identities (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(100) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (provider, subject)
+);
+
+CREATE TABLE IF NOT EXISTS oidc_states (
+    state_hash TEXT PRIMARY KEY,
+    provider VARCHAR(100) NOT NULL,
+    nonce TEXT NOT NULL,
+    code_verifier TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- Admin changes to the catalogue, with JSON snapshots of the entity before
+-- and after each change.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id SERIAL PRIMARY KEY,
+    actor VARCHAR(255) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    entity_type VARCHAR(20) NOT NULL,
+    entity_id INTEGER NOT NULL,
+    before JSONB,
+    after JSONB,
+    changes JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON audit_log (entity_type, entity_id, id);
+CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON audit_log (actor, id);
+
+-- Databases created before instants carried their time zone store wall
+-- clocks in TIMESTAMP columns. Those were the local time of the site, which
+-- is taken from the movie_system.site_timezone setting (by default UTC), e.g.
+-- PGOPTIONS="-c movie_system.site_timezone=Europe/Berlin" psql -f init.sql.
+-- The overlap constraint cannot be built on TIMESTAMP start times, so it is
+-- added here once they are converted.
+DO $$
+DECLARE
+    zone TEXT := COALESCE(NULLIF(current_setting('movie_system.site_timezone', true), ''), 'UTC');
+    col RECORD;
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'showtimes'
+               AND column_name = 'start_time' AND data_type = 'timestamp without time zone') THEN
+        ALTER TABLE showtimes DROP CONSTRAINT IF EXISTS showtimes_no_overlap;
+    END IF;
+
+    FOR col IN
+        SELECT table_name, column_name
+        FROM information_schema.columns
+        WHERE table_schema = current_schema() AND data_type = 'timestamp without time zone'
+    LOOP
+        EXECUTE format('ALTER TABLE %I ALTER COLUMN %I TYPE TIMESTAMPTZ USING %I AT TIME ZONE %L',
+                       col.table_name, col.column_name, col.column_name, zone);
+    END LOOP;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'showtimes_no_overlap') THEN
+        ALTER TABLE showtimes ADD CONSTRAINT showtimes_no_overlap EXCLUDE USING gist (
+            auditorium_id WITH =,
+            tstzrange(start_time, ends_at) WITH &&
+        ) WHERE (archived_at IS NULL);
+    END IF;
+END $$;